@@ -1,21 +1,86 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+// apiVersionPattern matches TEI release versions such as "1.2" or "v1.7.1"
+var apiVersionPattern = regexp.MustCompile(`^v?\d+\.\d+(\.\d+)?(-[0-9A-Za-z.]+)?$`)
+
 type Config struct {
-	TEI    TEIConfig    `mapstructure:"tei"`
-	Client ClientConfig `mapstructure:"client"`
-	GRPC   GRPCConfig   `mapstructure:"grpc"`
-	Log    LogConfig    `mapstructure:"log"`
+	TEI        TEIConfig        `mapstructure:"tei"`
+	Client     ClientConfig     `mapstructure:"client"`
+	GRPC       GRPCConfig       `mapstructure:"grpc"`
+	Log        LogConfig        `mapstructure:"log"`
+	Embedding  EmbeddingConfig  `mapstructure:"embedding"`
+	Cache      CacheConfig      `mapstructure:"cache"`
+	Similarity SimilarityConfig `mapstructure:"similarity"`
+	Validation ValidationConfig `mapstructure:"validation"`
+	Tracing    TracingConfig    `mapstructure:"tracing"`
 }
 
 type GRPCConfig struct {
-	Port int `mapstructure:"port"`
+	Port        int `mapstructure:"port"`
+	MetricsPort int `mapstructure:"metrics_port"`
+
+	// RequestTimeout is applied to unary RPCs that arrive without a
+	// deadline. MethodTimeouts overrides it per RPC, keyed by method name
+	// (e.g. "EmbedAll"); keys are matched case-insensitively. Streaming RPCs
+	// are bounded only by a MethodTimeouts entry.
+	RequestTimeout time.Duration            `mapstructure:"request_timeout"`
+	MethodTimeouts map[string]time.Duration `mapstructure:"method_timeouts"`
+
+	// ShutdownTimeout bounds how long in-flight RPCs may drain on SIGTERM
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+
+	// MaxConcurrentRequests bounds the RPCs handled at once. 0 means no
+	// limit. Calls beyond it wait in a queue of up to QueueDepth calls for
+	// at most QueueTimeout, and are rejected with ResourceExhausted when
+	// the queue is full or the wait runs out; with a QueueDepth of 0 they
+	// are rejected immediately.
+	MaxConcurrentRequests int           `mapstructure:"max_concurrent_requests"`
+	QueueDepth            int           `mapstructure:"queue_depth"`
+	QueueTimeout          time.Duration `mapstructure:"queue_timeout"`
+
+	// Message size limits in bytes for received and sent gRPC messages
+	MaxRecvMsgSize int `mapstructure:"max_recv_msg_size"`
+	MaxSendMsgSize int `mapstructure:"max_send_msg_size"`
+
+	// TLS is enabled when a certificate and key are set; ClientCAFile
+	// additionally requires callers to present a certificate (mTLS).
+	// Without TLS the server only starts if AllowInsecure is set.
+	TLSCertFile   string `mapstructure:"tls_cert_file"`
+	TLSKeyFile    string `mapstructure:"tls_key_file"`
+	ClientCAFile  string `mapstructure:"client_ca_file"`
+	AllowInsecure bool   `mapstructure:"allow_insecure"`
+
+	// APIKeyHashes are the hex SHA-256 hashes of the API keys callers may
+	// present in the authorization metadata, bare or as "Bearer <key>".
+	// Empty leaves the server open. Health and reflection are exempt.
+	APIKeyHashes []string `mapstructure:"api_key_hashes"`
+
+	// APIKeyLimits rate limits the callers of individual API keys
+	APIKeyLimits []APIKeyLimitConfig `mapstructure:"api_key_limits"`
+}
+
+// APIKeyLimitConfig is a token bucket for the API key hashed as KeyHash:
+// RequestsPerSecond RPCs on average with bursts of up to Burst. Name labels
+// the key's usage metrics in place of a hash prefix.
+type APIKeyLimitConfig struct {
+	Name              string  `mapstructure:"name"`
+	KeyHash           string  `mapstructure:"key_hash"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	Burst             int     `mapstructure:"burst"`
 }
 
 type TEIConfig struct {
@@ -24,6 +89,95 @@ type TEIConfig struct {
 	MaxRetries     int           `mapstructure:"max_retries"`
 	RetryDelay     time.Duration `mapstructure:"retry_delay"`
 	MaxConnections int           `mapstructure:"max_connections"`
+
+	// Mode is "live" to call TEI or "fake" to answer every request the
+	// client makes locally, with deterministic unit vectors of
+	// FakeDimension per input or per word for embed_all and word-count
+	// sparse vectors, for tests and local development without TEI. Fake
+	// mode must be selected explicitly.
+	Mode          string `mapstructure:"mode"`
+	FakeDimension int    `mapstructure:"fake_dimension"`
+
+	// APIVersion is the TEI release to adapt requests to, e.g. "1.2", so
+	// that fields an older server does not know are left out. "auto"
+	// detects it from /info at startup; empty targets the latest API.
+	APIVersion string `mapstructure:"api_version"`
+
+	// EndpointTimeouts replaces Timeout for requests to the given TEI
+	// endpoints, keyed by path (e.g. "/embed_all"). Like Timeout it bounds
+	// each attempt; a shorter deadline on the request context still wins.
+	EndpointTimeouts map[string]time.Duration `mapstructure:"endpoint_timeouts"`
+
+	// Connection pool tuning. MaxConnections bounds idle connections across
+	// all replicas; MaxIdleConnsPerHost bounds them per replica and defaults
+	// to MaxConnections when 0.
+	MaxIdleConnsPerHost int           `mapstructure:"max_idle_conns_per_host"`
+	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout"`
+	TLSHandshakeTimeout time.Duration `mapstructure:"tls_handshake_timeout"`
+
+	// MaxConnectionAge recycles HTTP/1.1 connections older than this before
+	// they are reused, forcing the TEI host name to be resolved again. 0 keeps
+	// connections for as long as they stay usable. HTTP/2 connections are
+	// shared by concurrent requests and never recycled.
+	MaxConnectionAge time.Duration `mapstructure:"max_connection_age"`
+
+	// TracePhases records DNS lookup, connect, TLS handshake and time to
+	// first byte for every TEI request attempt, logged at debug level and
+	// exported as metrics. It adds per-request overhead.
+	TracePhases bool `mapstructure:"trace_phases"`
+
+	// MaxRequestBytes rejects request bodies larger than this, before
+	// compression, as a validation error without sending them. 0 is
+	// unlimited.
+	MaxRequestBytes int `mapstructure:"max_request_bytes"`
+
+	// MaxInFlight bounds the requests the client has outstanding to TEI
+	// across all replicas, usually set to TEI's max_concurrent_requests.
+	// Further requests wait for a slot until their context ends. 0 means
+	// unlimited.
+	MaxInFlight int `mapstructure:"max_in_flight"`
+
+	// BaseURLs lists TEI replicas to balance across; when set it takes
+	// precedence over BaseURL. LoadBalancing is "round_robin" or
+	// "least_pending", and a replica that fails with a network or
+	// unhealthy error is skipped for FailoverCooldown.
+	BaseURLs         []string      `mapstructure:"base_urls"`
+	LoadBalancing    string        `mapstructure:"load_balancing"`
+	FailoverCooldown time.Duration `mapstructure:"failover_cooldown"`
+
+	// TLS settings for HTTPS endpoints. CAFile replaces the system roots,
+	// ClientCertFile/ClientKeyFile enable mTLS and InsecureSkipVerify
+	// disables certificate verification entirely.
+	CAFile             string `mapstructure:"ca_file"`
+	ClientCertFile     string `mapstructure:"client_cert_file"`
+	ClientKeyFile      string `mapstructure:"client_key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+
+	// HTTP2 multiplexes requests over HTTP/2: negotiated via ALPN for https
+	// URLs and spoken with prior knowledge (h2c) for http URLs. All base
+	// URLs must then share one scheme.
+	HTTP2 bool `mapstructure:"http2"`
+
+	// CompressRequests gzips request bodies larger than
+	// CompressionThreshold bytes. Only enable it for backends that accept
+	// Content-Encoding: gzip.
+	CompressRequests     bool `mapstructure:"compress_requests"`
+	CompressionThreshold int  `mapstructure:"compression_threshold"`
+
+	// MaxResponseBytes caps the size of a TEI response body
+	MaxResponseBytes int64 `mapstructure:"max_response_bytes"`
+
+	// Warmup checks /health on every replica at startup, opening pooled
+	// connections and refusing to start if none is reachable
+	Warmup bool `mapstructure:"warmup"`
+}
+
+// Endpoints returns the configured TEI base URLs
+func (c *TEIConfig) Endpoints() []string {
+	if len(c.BaseURLs) > 0 {
+		return c.BaseURLs
+	}
+	return []string{c.BaseURL}
 }
 
 type ClientConfig struct {
@@ -32,22 +186,169 @@ type ClientConfig struct {
 	DefaultTimeout time.Duration `mapstructure:"default_timeout"`
 }
 
+type EmbeddingConfig struct {
+	AutoBatch            bool `mapstructure:"auto_batch"`
+	MaxConcurrentBatches int  `mapstructure:"max_concurrent_batches"`
+
+	// DefaultPromptName and DefaultTruncate apply to embed requests that
+	// leave prompt_name or truncate unset. The default prompt is not used
+	// when a request selects an input role instead.
+	DefaultPromptName string `mapstructure:"default_prompt_name"`
+	DefaultTruncate   bool   `mapstructure:"default_truncate"`
+
+	// DefaultTruncationDirection is the side TEI cuts from when a request
+	// truncates without naming a direction: "right" keeps the beginning
+	// of an input, "left" keeps its end, as chat logs and transcripts
+	// usually want
+	DefaultTruncationDirection string `mapstructure:"default_truncation_direction"`
+
+	// Deduplicate is the default for embed requests that do not set it:
+	// duplicate inputs are sent to TEI only once
+	Deduplicate bool `mapstructure:"deduplicate"`
+
+	// CoalesceRequests lets concurrent identical embed requests share a
+	// single cache lookup and TEI call
+	CoalesceRequests bool `mapstructure:"coalesce_requests"`
+
+	// Prompts maps prompt names to templates containing a {text}
+	// placeholder. With ExpandPrompts the client applies the template
+	// itself and rejects unknown names; otherwise prompt names are passed
+	// through to TEI's server-side prompts. Viper lowercases the names.
+	Prompts       map[string]string `mapstructure:"prompts"`
+	ExpandPrompts bool              `mapstructure:"expand_prompts"`
+
+	// RolePrefixes are the query/document prefixes of asymmetric models,
+	// selected by the model ID TEI reports. Model "*" matches any model.
+	RolePrefixes []RolePrefixConfig `mapstructure:"role_prefixes"`
+
+	// NormCheck verifies that vectors requested with normalize=true have
+	// unit length within NormTolerance: "off", "warn" to log violations or
+	// "renormalize" to also fix them locally
+	NormCheck     string  `mapstructure:"norm_check"`
+	NormTolerance float64 `mapstructure:"norm_tolerance"`
+
+	// StreamErrorPolicy decides what a failed batch of a streamed embed
+	// does: "abort" ends the stream with the error and the batch's first
+	// and last ids, "continue" reports the error for each input of the
+	// batch and carries on
+	StreamErrorPolicy string `mapstructure:"stream_error_policy"`
+
+	// DetectTruncation tokenizes the inputs of truncate=true requests to
+	// report which ones TEI cut. It costs an extra /tokenize call.
+	DetectTruncation bool `mapstructure:"detect_truncation"`
+
+	// PreflightTokens tokenizes each batch before sending it to /embed and
+	// rejects batches over the model's token limits as a validation error
+	// instead of waiting for TEI's 413. It costs an extra /tokenize call
+	// and only applies once the limits are known from /info.
+	PreflightTokens bool `mapstructure:"preflight_tokens"`
+}
+
+type RolePrefixConfig struct {
+	Model    string `mapstructure:"model"`
+	Query    string `mapstructure:"query"`
+	Document string `mapstructure:"document"`
+}
+
+type SimilarityConfig struct {
+	ComputeLocally bool `mapstructure:"compute_locally"`
+
+	// MaxConcurrentRequests bounds the /similarity calls issued at once
+	// by CalculatePairwiseSimilarity
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests"`
+}
+
+type ValidationConfig struct {
+	MaxInputLength    int  `mapstructure:"max_input_length"`
+	MaxBatchSize      int  `mapstructure:"max_batch_size"`
+	MaxSentencesCount int  `mapstructure:"max_sentences_count"`
+	AllowEmptyStrings bool `mapstructure:"allow_empty_strings"`
+
+	// FailFast stops request validation at the first failing check rather
+	// than reporting every violation; FailFastTexts stops at the first
+	// invalid text of a list. Fail-fast is cheaper, collecting everything
+	// is friendlier to callers fixing a request.
+	FailFast      bool `mapstructure:"fail_fast"`
+	FailFastTexts bool `mapstructure:"fail_fast_texts"`
+
+	// MaxImageBytes bounds the size of images sent as data to EmbedImage;
+	// 0 is unlimited
+	MaxImageBytes int `mapstructure:"max_image_bytes"`
+
+	// UseModelInfo fetches TEI's /info at startup and tightens the limits
+	// above to the deployed model's reported capacity
+	UseModelInfo bool `mapstructure:"use_model_info"`
+}
+
+type CacheConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	MaxEntries    int           `mapstructure:"max_entries"`
+	TTL           time.Duration `mapstructure:"ttl"`
+	TTLJitter     float64       `mapstructure:"ttl_jitter"`
+	SweepInterval time.Duration `mapstructure:"sweep_interval"`
+}
+
+// TracingConfig controls OpenTelemetry span export. Endpoint is the
+// host:port of an OTLP/HTTP collector.
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	ServiceName string  `mapstructure:"service_name"`
+}
+
 type LogConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level        string `mapstructure:"level"`
+	Format       string `mapstructure:"format"`
+	RedactInputs bool   `mapstructure:"redact_inputs"`
+
+	// Sampling keeps the first SamplingInitial entries with the same level
+	// and message each second, then every SamplingThereafter-th. Error
+	// entries are never sampled. SamplingInitial 0 disables sampling. Both
+	// default to 100 for the json and logfmt formats and to 0 for console.
+	SamplingInitial    int `mapstructure:"sampling_initial"`
+	SamplingThereafter int `mapstructure:"sampling_thereafter"`
+
+	// OutputPath writes logs to a file instead of stderr. The file is
+	// rotated once it reaches MaxSizeMB; rotated files older than
+	// MaxAgeDays or beyond MaxBackups are removed (0 keeps them all).
+	OutputPath string `mapstructure:"output_path"`
+	MaxSizeMB  int    `mapstructure:"max_size_mb"`
+	MaxAgeDays int    `mapstructure:"max_age_days"`
+	MaxBackups int    `mapstructure:"max_backups"`
+	Compress   bool   `mapstructure:"compress"`
 }
 
-func LoadConfig() (*Config, error) {
-	viper.SetConfigName("docker")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./configs")
-	viper.AddConfigPath(".")
+// LoadConfig reads the config file at path, or configs/docker.yaml if path
+// is empty, and applies TEI_CLIENT_* environment variables over it. The
+// default file is optional: every key maps to a variable named after its
+// path, such as TEI_CLIENT_TEI_BASE_URL for tei.base_url, so the service
+// can run from the environment alone. List values are comma-separated.
+// Map and list-of-object keys, such as grpc.method_timeouts,
+// tei.endpoint_timeouts, grpc.api_key_limits, embedding.prompts and
+// embedding.role_prefixes, cannot be set from the environment and must come
+// from the file. A file given by path must exist; its format follows its
+// extension.
+func LoadConfig(path string) (*Config, error) {
+	if path != "" {
+		viper.SetConfigFile(path)
+	} else {
+		viper.SetConfigName("docker")
+		viper.SetConfigType("yaml")
+		viper.AddConfigPath("./configs")
+		viper.AddConfigPath(".")
+	}
 
 	setDefaults()
 	setGRPCDefaults()
 
-	viper.AutomaticEnv()
 	viper.SetEnvPrefix("TEI_CLIENT")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+	if err := bindEnvs(reflect.TypeOf(Config{}), ""); err != nil {
+		return nil, fmt.Errorf("failed to bind environment variables: %w", err)
+	}
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -59,16 +360,85 @@ func LoadConfig() (*Config, error) {
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	setLogSamplingDefaults(&config.Log)
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
 	return &config, nil
 }
 
+// setLogSamplingDefaults samples the JSON and logfmt formats, meant for
+// production, unless sampling is configured; console logs are kept whole
+func setLogSamplingDefaults(cfg *LogConfig) {
+	if cfg.Format == "console" {
+		return
+	}
+	if !viper.IsSet("log.sampling_initial") {
+		cfg.SamplingInitial = 100
+	}
+	if !viper.IsSet("log.sampling_thereafter") {
+		cfg.SamplingThereafter = 100
+	}
+}
+
+// bindEnvs binds an environment variable to every key of t, including the
+// ones without a default, which AutomaticEnv alone does not find when
+// unmarshalling. Maps and lists of structs have no single-string form and
+// are left unbound.
+func bindEnvs(t reflect.Type, prefix string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" || key == "-" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			if err := bindEnvs(field.Type, key); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Map ||
+			(field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct) {
+			continue
+		}
+		if err := viper.BindEnv(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func setDefaults() {
 	viper.SetDefault("tei.base_url", "http://text-embeddings-inference:8080")
 	viper.SetDefault("tei.timeout", "30s")
+	viper.SetDefault("tei.mode", "live")
+	viper.SetDefault("tei.fake_dimension", 384)
+	viper.SetDefault("tei.api_version", "")
 	viper.SetDefault("tei.max_retries", 3)
 	viper.SetDefault("tei.retry_delay", "1s")
 	viper.SetDefault("tei.max_connections", 10)
+	viper.SetDefault("tei.max_idle_conns_per_host", 0)
+	viper.SetDefault("tei.idle_conn_timeout", "90s")
+	viper.SetDefault("tei.tls_handshake_timeout", "10s")
+	viper.SetDefault("tei.max_connection_age", "0s")
+	viper.SetDefault("tei.trace_phases", false)
+	viper.SetDefault("tei.max_in_flight", 0)
+	viper.SetDefault("tei.max_request_bytes", 0)
+	viper.SetDefault("tei.load_balancing", "round_robin")
+	viper.SetDefault("tei.failover_cooldown", "10s")
+	viper.SetDefault("tei.insecure_skip_verify", false)
+	viper.SetDefault("tei.http2", false)
+	viper.SetDefault("tei.compress_requests", false)
+	viper.SetDefault("tei.compression_threshold", 65536)
+	viper.SetDefault("tei.max_response_bytes", 64<<20)
+	viper.SetDefault("tei.warmup", false)
 
 	viper.SetDefault("client.name", "text-embeddings-client")
 	viper.SetDefault("client.version", "1.0.0")
@@ -76,22 +446,157 @@ func setDefaults() {
 
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
+	viper.SetDefault("log.redact_inputs", true)
+	viper.SetDefault("log.output_path", "")
+	viper.SetDefault("log.max_size_mb", 100)
+	viper.SetDefault("log.max_age_days", 28)
+	viper.SetDefault("log.max_backups", 3)
+	viper.SetDefault("log.compress", false)
+
+	viper.SetDefault("embedding.auto_batch", false)
+	viper.SetDefault("embedding.max_concurrent_batches", 4)
+	viper.SetDefault("embedding.default_prompt_name", "")
+	viper.SetDefault("embedding.default_truncate", false)
+	viper.SetDefault("embedding.default_truncation_direction", "right")
+	viper.SetDefault("embedding.deduplicate", false)
+	viper.SetDefault("embedding.coalesce_requests", false)
+	viper.SetDefault("embedding.expand_prompts", false)
+	viper.SetDefault("embedding.norm_check", "off")
+	viper.SetDefault("embedding.stream_error_policy", "abort")
+	viper.SetDefault("embedding.detect_truncation", false)
+	viper.SetDefault("embedding.preflight_tokens", false)
+	viper.SetDefault("embedding.norm_tolerance", 1e-3)
+
+	viper.SetDefault("similarity.compute_locally", false)
+	viper.SetDefault("similarity.max_concurrent_requests", 4)
+
+	viper.SetDefault("validation.max_input_length", 8192)
+	viper.SetDefault("validation.max_batch_size", 32)
+	viper.SetDefault("validation.max_sentences_count", 100)
+	viper.SetDefault("validation.allow_empty_strings", false)
+	viper.SetDefault("validation.fail_fast", false)
+	viper.SetDefault("validation.fail_fast_texts", true)
+	viper.SetDefault("validation.max_image_bytes", 10<<20)
+	viper.SetDefault("validation.use_model_info", true)
+
+	viper.SetDefault("cache.enabled", false)
+	viper.SetDefault("cache.max_entries", 10000)
+	viper.SetDefault("cache.ttl", "1h")
+	viper.SetDefault("cache.ttl_jitter", 0.1)
+	viper.SetDefault("cache.sweep_interval", "1m")
+
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.insecure", true)
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("tracing.service_name", "embedding-inference")
 }
 
 func setGRPCDefaults() {
 	// gRPC server defaults
-	viper.SetDefault("grpc.port", "9090")
+	viper.SetDefault("grpc.port", 9090)
+	viper.SetDefault("grpc.metrics_port", 9100)
+	viper.SetDefault("grpc.max_recv_msg_size", 16<<20)
+	viper.SetDefault("grpc.max_concurrent_requests", 0)
+	viper.SetDefault("grpc.queue_depth", 0)
+	viper.SetDefault("grpc.queue_timeout", "1s")
+	viper.SetDefault("grpc.max_send_msg_size", 16<<20)
+	viper.SetDefault("grpc.request_timeout", "60s")
+	viper.SetDefault("grpc.shutdown_timeout", "30s")
+	viper.SetDefault("grpc.allow_insecure", false)
+	viper.SetDefault("grpc.api_key_hashes", []string{})
 }
 
 func (c *Config) Validate() error {
-	if c.TEI.BaseURL == "" {
-		return fmt.Errorf("tei.base_url is required")
+	for _, endpoint := range c.TEI.Endpoints() {
+		if endpoint == "" {
+			return fmt.Errorf("tei.base_url is required")
+		}
+	}
+
+	// Serving https replicas turns HTTP/1.1 back on for the whole transport,
+	// which would quietly stop h2c to the http ones
+	if c.TEI.HTTP2 {
+		var schemes []string
+		for _, endpoint := range c.TEI.Endpoints() {
+			if u, err := url.Parse(endpoint); err == nil && !slices.Contains(schemes, strings.ToLower(u.Scheme)) {
+				schemes = append(schemes, strings.ToLower(u.Scheme))
+			}
+		}
+		if len(schemes) > 1 {
+			return fmt.Errorf("tei.http2 requires every TEI base URL to use the same scheme, got %s", strings.Join(schemes, " and "))
+		}
+	}
+
+	for _, hash := range c.GRPC.APIKeyHashes {
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("grpc.api_key_hashes must hold hex SHA-256 hashes")
+		}
+	}
+
+	for _, limit := range c.GRPC.APIKeyLimits {
+		if !slices.Contains(c.GRPC.APIKeyHashes, limit.KeyHash) {
+			return fmt.Errorf("grpc.api_key_limits key_hash %q is not listed in grpc.api_key_hashes", limit.KeyHash)
+		}
+		if limit.RequestsPerSecond <= 0 || limit.Burst <= 0 {
+			return fmt.Errorf("grpc.api_key_limits requests_per_second and burst must be positive")
+		}
+	}
+
+	switch c.TEI.Mode {
+	case "live":
+	case "fake":
+		if c.TEI.FakeDimension <= 0 {
+			return fmt.Errorf("tei.fake_dimension must be positive")
+		}
+	default:
+		return fmt.Errorf("tei.mode must be one of live, fake, got %q", c.TEI.Mode)
+	}
+
+	switch c.TEI.LoadBalancing {
+	case "round_robin", "least_pending":
+	default:
+		return fmt.Errorf("tei.load_balancing must be one of round_robin, least_pending, got %q", c.TEI.LoadBalancing)
+	}
+
+	if c.TEI.MaxResponseBytes <= 0 {
+		return fmt.Errorf("tei.max_response_bytes must be positive")
+	}
+
+	if c.GRPC.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("grpc.max_concurrent_requests must be non-negative")
+	}
+
+	if c.GRPC.QueueDepth < 0 || c.GRPC.QueueTimeout < 0 {
+		return fmt.Errorf("grpc.queue_depth and grpc.queue_timeout must be non-negative")
+	}
+
+	if c.GRPC.QueueDepth > 0 && c.GRPC.QueueTimeout == 0 {
+		return fmt.Errorf("grpc.queue_timeout must be positive when grpc.queue_depth is set")
+	}
+
+	if c.GRPC.MaxRecvMsgSize <= 0 || c.GRPC.MaxSendMsgSize <= 0 {
+		return fmt.Errorf("grpc.max_recv_msg_size and grpc.max_send_msg_size must be positive")
+	}
+
+	if c.TEI.FailoverCooldown < 0 {
+		return fmt.Errorf("tei.failover_cooldown must be non-negative")
 	}
 
 	if c.TEI.Timeout <= 0 {
 		return fmt.Errorf("tei.timeout must be positive")
 	}
 
+	if v := c.TEI.APIVersion; v != "" && v != "auto" && !apiVersionPattern.MatchString(v) {
+		return fmt.Errorf("tei.api_version must be empty, auto or a version such as 1.2, got %q", v)
+	}
+
+	for endpoint, timeout := range c.TEI.EndpointTimeouts {
+		if timeout <= 0 {
+			return fmt.Errorf("tei.endpoint_timeouts[%s] must be positive", endpoint)
+		}
+	}
+
 	if c.TEI.MaxRetries < 0 {
 		return fmt.Errorf("tei.max_retries must be non-negative")
 	}
@@ -100,5 +605,122 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("tei.max_connections must be positive")
 	}
 
+	if c.TEI.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("tei.max_idle_conns_per_host must be non-negative")
+	}
+
+	if c.TEI.MaxRequestBytes < 0 {
+		return fmt.Errorf("tei.max_request_bytes must be non-negative")
+	}
+
+	if c.TEI.MaxInFlight < 0 {
+		return fmt.Errorf("tei.max_in_flight must be non-negative")
+	}
+
+	if c.TEI.IdleConnTimeout < 0 || c.TEI.TLSHandshakeTimeout < 0 || c.TEI.MaxConnectionAge < 0 {
+		return fmt.Errorf("tei.idle_conn_timeout, tei.tls_handshake_timeout and tei.max_connection_age must be non-negative")
+	}
+
+	if c.Embedding.MaxConcurrentBatches <= 0 {
+		return fmt.Errorf("embedding.max_concurrent_batches must be positive")
+	}
+
+	switch c.Embedding.NormCheck {
+	case "off", "warn", "renormalize":
+	default:
+		return fmt.Errorf("embedding.norm_check must be one of off, warn, renormalize, got %q", c.Embedding.NormCheck)
+	}
+
+	switch c.Embedding.DefaultTruncationDirection {
+	case "left", "right":
+	default:
+		return fmt.Errorf("embedding.default_truncation_direction must be one of left, right, got %q", c.Embedding.DefaultTruncationDirection)
+	}
+
+	switch c.Embedding.StreamErrorPolicy {
+	case "abort", "continue":
+	default:
+		return fmt.Errorf("embedding.stream_error_policy must be one of abort, continue, got %q", c.Embedding.StreamErrorPolicy)
+	}
+
+	if name := c.Embedding.DefaultPromptName; name != "" && c.Embedding.ExpandPrompts {
+		if _, ok := c.Embedding.Prompts[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("embedding.default_prompt_name %q is not a configured prompt", name)
+		}
+	}
+
+	if c.Embedding.NormTolerance <= 0 {
+		return fmt.Errorf("embedding.norm_tolerance must be positive")
+	}
+
+	if c.Similarity.MaxConcurrentRequests <= 0 {
+		return fmt.Errorf("similarity.max_concurrent_requests must be positive")
+	}
+
+	if c.Validation.MaxInputLength <= 0 {
+		return fmt.Errorf("validation.max_input_length must be positive")
+	}
+
+	if c.Validation.MaxBatchSize <= 0 {
+		return fmt.Errorf("validation.max_batch_size must be positive")
+	}
+
+	if c.Validation.MaxSentencesCount <= 0 {
+		return fmt.Errorf("validation.max_sentences_count must be positive")
+	}
+
+	if c.Validation.MaxImageBytes < 0 {
+		return fmt.Errorf("validation.max_image_bytes must be non-negative")
+	}
+
+	if c.Cache.TTLJitter < 0 || c.Cache.TTLJitter >= 1 {
+		return fmt.Errorf("cache.ttl_jitter must be in [0, 1)")
+	}
+
+	if c.GRPC.Port <= 0 || c.GRPC.Port > 65535 {
+		return fmt.Errorf("grpc.port must be in [1, 65535], got %d", c.GRPC.Port)
+	}
+
+	// A metrics port of 0 disables the metrics server
+	if c.GRPC.MetricsPort < 0 || c.GRPC.MetricsPort > 65535 {
+		return fmt.Errorf("grpc.metrics_port must be in [0, 65535], got %d", c.GRPC.MetricsPort)
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be in [0, 1]")
+	}
+
+	if c.Tracing.Enabled && c.Tracing.Endpoint == "" {
+		return fmt.Errorf("tracing.endpoint is required when tracing is enabled")
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("log.level must be one of debug, info, warn, error, got %q", c.Log.Level)
+	}
+
+	switch c.Log.Format {
+	case "json", "console", "logfmt":
+	default:
+		return fmt.Errorf("log.format must be one of json, console, logfmt, got %q", c.Log.Format)
+	}
+
+	if c.Log.SamplingInitial < 0 || c.Log.SamplingThereafter < 0 {
+		return fmt.Errorf("log.sampling_initial and log.sampling_thereafter must be non-negative")
+	}
+
+	if c.Log.SamplingInitial > 0 && c.Log.SamplingThereafter == 0 {
+		return fmt.Errorf("log.sampling_thereafter must be positive when log.sampling_initial is set")
+	}
+
+	if c.Log.OutputPath != "" && c.Log.MaxSizeMB <= 0 {
+		return fmt.Errorf("log.max_size_mb must be positive when log.output_path is set")
+	}
+
+	if c.Log.MaxAgeDays < 0 || c.Log.MaxBackups < 0 {
+		return fmt.Errorf("log.max_age_days and log.max_backups must be non-negative")
+	}
+
 	return nil
 }
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// loadTestConfig loads the configuration from path, or from defaults and
+// the environment alone when path is empty, resetting viper afterwards
+func loadTestConfig(t *testing.T, path string) *Config {
+	t.Helper()
+	t.Cleanup(viper.Reset)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return cfg
+}
+
+func TestLoadConfigFromEnvironment(t *testing.T) {
+	t.Setenv("TEI_CLIENT_TEI_BASE_URL", "http://tei.internal:8080")
+	t.Setenv("TEI_CLIENT_TEI_TIMEOUT", "45s")
+	t.Setenv("TEI_CLIENT_TEI_BASE_URLS", "http://tei-a:8080,http://tei-b:8080")
+	t.Setenv("TEI_CLIENT_GRPC_PORT", "9191")
+	t.Setenv("TEI_CLIENT_GRPC_MAX_CONCURRENT_REQUESTS", "64")
+	t.Setenv("TEI_CLIENT_CACHE_ENABLED", "true")
+	// Keys without a default are bound too
+	t.Setenv("TEI_CLIENT_GRPC_TLS_CERT_FILE", "/etc/tls/server.crt")
+	t.Setenv("TEI_CLIENT_GRPC_TLS_KEY_FILE", "/etc/tls/server.key")
+
+	cfg := loadTestConfig(t, "")
+
+	if cfg.TEI.BaseURL != "http://tei.internal:8080" {
+		t.Errorf("tei.base_url = %q, want %q", cfg.TEI.BaseURL, "http://tei.internal:8080")
+	}
+	if cfg.TEI.Timeout != 45*time.Second {
+		t.Errorf("tei.timeout = %v, want 45s", cfg.TEI.Timeout)
+	}
+	if len(cfg.TEI.BaseURLs) != 2 || cfg.TEI.BaseURLs[1] != "http://tei-b:8080" {
+		t.Errorf("tei.base_urls = %q, want both replicas", cfg.TEI.BaseURLs)
+	}
+	if cfg.GRPC.Port != 9191 {
+		t.Errorf("grpc.port = %d, want 9191", cfg.GRPC.Port)
+	}
+	if cfg.GRPC.MaxConcurrentRequests != 64 {
+		t.Errorf("grpc.max_concurrent_requests = %d, want 64", cfg.GRPC.MaxConcurrentRequests)
+	}
+	if !cfg.Cache.Enabled {
+		t.Error("cache.enabled = false, want true")
+	}
+	if cfg.GRPC.TLSCertFile != "/etc/tls/server.crt" || cfg.GRPC.TLSKeyFile != "/etc/tls/server.key" {
+		t.Errorf("grpc.tls_cert_file, grpc.tls_key_file = %q, %q, want the environment values",
+			cfg.GRPC.TLSCertFile, cfg.GRPC.TLSKeyFile)
+	}
+}
+
+func TestLoadConfigIgnoresEnvironmentForMapKeys(t *testing.T) {
+	t.Setenv("TEI_CLIENT_EMBEDDING_PROMPTS", "query")
+	t.Setenv("TEI_CLIENT_GRPC_METHOD_TIMEOUTS", "30s")
+
+	cfg := loadTestConfig(t, "")
+
+	if len(cfg.Embedding.Prompts) != 0 || len(cfg.GRPC.MethodTimeouts) != 0 {
+		t.Errorf("embedding.prompts, grpc.method_timeouts = %v, %v, want both empty",
+			cfg.Embedding.Prompts, cfg.GRPC.MethodTimeouts)
+	}
+}
+
+func TestLoadConfigDefaultsWithoutFile(t *testing.T) {
+	cfg := loadTestConfig(t, "")
+
+	if cfg.GRPC.Port != 9090 {
+		t.Errorf("grpc.port = %d, want 9090", cfg.GRPC.Port)
+	}
+}
+
+func TestLogSamplingDefaultsByFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		initial    int
+		thereafter int
+	}{
+		{"json", nil, 100, 100},
+		{"logfmt", map[string]string{"TEI_CLIENT_LOG_FORMAT": "logfmt"}, 100, 100},
+		{"console", map[string]string{"TEI_CLIENT_LOG_FORMAT": "console"}, 0, 0},
+		{"console with sampling set", map[string]string{
+			"TEI_CLIENT_LOG_FORMAT":              "console",
+			"TEI_CLIENT_LOG_SAMPLING_INITIAL":    "10",
+			"TEI_CLIENT_LOG_SAMPLING_THEREAFTER": "5",
+		}, 10, 5},
+		{"json with sampling disabled", map[string]string{"TEI_CLIENT_LOG_SAMPLING_INITIAL": "0"}, 0, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg := loadTestConfig(t, "")
+
+			if cfg.Log.SamplingInitial != tt.initial || cfg.Log.SamplingThereafter != tt.thereafter {
+				t.Errorf("sampling = %d/%d, want %d/%d", cfg.Log.SamplingInitial, cfg.Log.SamplingThereafter, tt.initial, tt.thereafter)
+			}
+		})
+	}
+}
+
+func TestLogSamplingRejectsZeroThereafter(t *testing.T) {
+	t.Setenv("TEI_CLIENT_LOG_SAMPLING_THEREAFTER", "0")
+	t.Cleanup(viper.Reset)
+
+	if _, err := LoadConfig(""); err == nil {
+		t.Error("LoadConfig accepted log.sampling_thereafter 0 with sampling enabled")
+	}
+}
+
+func TestQueueDepthRequiresQueueTimeout(t *testing.T) {
+	t.Setenv("TEI_CLIENT_GRPC_QUEUE_DEPTH", "8")
+	t.Setenv("TEI_CLIENT_GRPC_QUEUE_TIMEOUT", "0s")
+	t.Cleanup(viper.Reset)
+
+	if _, err := LoadConfig(""); err == nil {
+		t.Error("LoadConfig accepted grpc.queue_timeout 0 with a request queue")
+	}
+}
+
+func TestHTTP2RejectsMixedSchemes(t *testing.T) {
+	t.Setenv("TEI_CLIENT_TEI_HTTP2", "true")
+	t.Setenv("TEI_CLIENT_TEI_BASE_URLS", "http://tei-a:8080,https://tei-b:8443")
+	t.Cleanup(viper.Reset)
+
+	if _, err := LoadConfig(""); err == nil {
+		t.Error("LoadConfig accepted tei.http2 with both http and https base URLs")
+	}
+
+	t.Setenv("TEI_CLIENT_TEI_HTTP2", "false")
+	viper.Reset()
+	if _, err := LoadConfig(""); err != nil {
+		t.Errorf("LoadConfig rejected mixed schemes without tei.http2: %v", err)
+	}
+}
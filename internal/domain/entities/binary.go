@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// embeddingFormatV1 is the version byte of the binary embedding format: the
+// version, the dimension as a little-endian uint32, then each value as a
+// little-endian IEEE 754 float32
+const embeddingFormatV1 byte = 1
+
+const embeddingHeaderSize = 5
+
+// MarshalEmbedding packs v into the compact binary embedding format, for
+// storage in blob stores or caches. Values are stored bit for bit, so NaN
+// and ±Inf survive a round trip.
+func MarshalEmbedding(v []float32) []byte {
+	data := make([]byte, embeddingHeaderSize+4*len(v))
+	data[0] = embeddingFormatV1
+	binary.LittleEndian.PutUint32(data[1:], uint32(len(v)))
+	for i, value := range v {
+		binary.LittleEndian.PutUint32(data[embeddingHeaderSize+4*i:], math.Float32bits(value))
+	}
+	return data
+}
+
+// UnmarshalEmbedding restores an embedding packed by MarshalEmbedding
+func UnmarshalEmbedding(data []byte) ([]float32, error) {
+	if len(data) < embeddingHeaderSize {
+		return nil, fmt.Errorf("embedding data of %d bytes is shorter than its header", len(data))
+	}
+	if data[0] != embeddingFormatV1 {
+		return nil, fmt.Errorf("unsupported embedding format version %d", data[0])
+	}
+
+	dimension := binary.LittleEndian.Uint32(data[1:])
+	if payload := uint64(len(data) - embeddingHeaderSize); payload != 4*uint64(dimension) {
+		return nil, fmt.Errorf("embedding of dimension %d needs %d bytes of values, got %d", dimension, 4*uint64(dimension), payload)
+	}
+
+	v := make([]float32, dimension)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[embeddingHeaderSize+4*i:]))
+	}
+	return v, nil
+}
+
+// MarshalEmbeddingBase64 is MarshalEmbedding encoded as standard base64, for
+// stores that only hold text
+func MarshalEmbeddingBase64(v []float32) string {
+	return base64.StdEncoding.EncodeToString(MarshalEmbedding(v))
+}
+
+// UnmarshalEmbeddingBase64 restores an embedding encoded by
+// MarshalEmbeddingBase64
+func UnmarshalEmbeddingBase64(s string) ([]float32, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 embedding: %w", err)
+	}
+	return UnmarshalEmbedding(data)
+}
@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"math"
+	"testing"
+)
+
+// sameFloats compares bit patterns, so that NaN equals NaN
+func sameFloats(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Float32bits(a[i]) != math.Float32bits(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEmbeddingRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		v    []float32
+	}{
+		{"empty", []float32{}},
+		{"values", []float32{0.25, -1.5, 3e-8, math.MaxFloat32}},
+		{"special values", []float32{float32(math.NaN()), float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.Copysign(0, -1))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := MarshalEmbedding(tt.v)
+			if want := embeddingHeaderSize + 4*len(tt.v); len(data) != want {
+				t.Errorf("encoded %d bytes, want %d", len(data), want)
+			}
+			got, err := UnmarshalEmbedding(data)
+			if err != nil {
+				t.Fatalf("UnmarshalEmbedding: %v", err)
+			}
+			if !sameFloats(got, tt.v) {
+				t.Errorf("round trip = %v, want %v", got, tt.v)
+			}
+
+			got, err = UnmarshalEmbeddingBase64(MarshalEmbeddingBase64(tt.v))
+			if err != nil {
+				t.Fatalf("UnmarshalEmbeddingBase64: %v", err)
+			}
+			if !sameFloats(got, tt.v) {
+				t.Errorf("base64 round trip = %v, want %v", got, tt.v)
+			}
+		})
+	}
+}
+
+func TestMarshalEmbeddingLayout(t *testing.T) {
+	data := MarshalEmbedding([]float32{1})
+
+	// Version 1, dimension 1 as uint32 LE, then 1.0 as float32 LE
+	want := []byte{1, 1, 0, 0, 0, 0x00, 0x00, 0x80, 0x3f}
+	if string(data) != string(want) {
+		t.Errorf("MarshalEmbedding([1]) = % x, want % x", data, want)
+	}
+}
+
+func TestUnmarshalEmbeddingRejectsInvalidData(t *testing.T) {
+	valid := MarshalEmbedding([]float32{1, 2})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"nil", nil},
+		{"short header", valid[:3]},
+		{"unknown version", append([]byte{2}, valid[1:]...)},
+		{"truncated values", valid[:len(valid)-1]},
+		{"trailing bytes", append(append([]byte(nil), valid...), 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if v, err := UnmarshalEmbedding(tt.data); err == nil {
+				t.Errorf("UnmarshalEmbedding(% x) = %v, want an error", tt.data, v)
+			}
+		})
+	}
+
+	if _, err := UnmarshalEmbeddingBase64("not base64!"); err == nil {
+		t.Error("UnmarshalEmbeddingBase64 accepted invalid base64")
+	}
+}
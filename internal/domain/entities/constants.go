@@ -10,6 +10,8 @@ const (
 	EndpointSimilarity  = "/similarity"
 	EndpointTokenize    = "/tokenize"
 	EndpointDecode      = "/decode"
+	EndpointInfo        = "/info"
+	EndpointHealth      = "/health"
 )
 
 const (
@@ -18,8 +20,11 @@ const (
 	HeaderUserAgent     = "User-Agent"
 	HeaderAuthorization = "Authorization"
 	HeaderRequestID     = "X-Request-ID"
+	HeaderEncoding      = "Content-Encoding"
 )
 
+const EncodingGzip = "gzip"
+
 const (
 	ContentTypeJSON       = "application/json"
 	ContentTypeTextPlain  = "text/plain"
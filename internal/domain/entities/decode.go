@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeEmbeddings parses an /embed, /embed_all or /embed_sparse response.
+// TEI returns a bare array with one entry per input, but some versions and
+// compatible servers wrap it as {"embeddings": [...]}; both are accepted.
+func DecodeEmbeddings[T any](data []byte) ([]T, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Embeddings *[]T `json:"embeddings"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapped); err != nil {
+			return nil, err
+		}
+		if wrapped.Embeddings == nil {
+			return nil, fmt.Errorf("response object has no embeddings field")
+		}
+		return *wrapped.Embeddings, nil
+	}
+
+	var embeddings []T
+	if err := json.Unmarshal(data, &embeddings); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
@@ -1,6 +1,8 @@
 package entities
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -20,6 +22,7 @@ type EncodingFormat string
 const (
 	EncodingFloat  EncodingFormat = "float"
 	EncodingBase64 EncodingFormat = "base64"
+	EncodingInt8   EncodingFormat = "int8"
 )
 
 type InputType any
@@ -77,6 +80,40 @@ type EmbedRequest struct {
 	PromptName          *string             `json:"prompt_name,omitempty"`
 	Truncate            *bool               `json:"truncate,omitempty"`
 	TruncationDirection TruncationDirection `json:"truncation_direction,omitempty"`
+
+	// Dimensions requests Matryoshka embeddings of reduced size. Vectors
+	// TEI returns at full size are truncated and re-normalized locally.
+	Dimensions *int `json:"dimensions,omitempty"`
+
+	// InputRole prepends the current model's query or document prefix to
+	// every input. It is ignored when PromptName is set and never sent to
+	// TEI.
+	InputRole InputRole `json:"-"`
+
+	// EncodingFormat EncodingInt8 returns EmbedResponse.Quantized in place
+	// of float embeddings. It is never sent to TEI.
+	EncodingFormat EncodingFormat `json:"-"`
+
+	// AutoBatch splits inputs larger than the maximum batch size into
+	// sub-batches instead of rejecting them. It is never sent to TEI.
+	AutoBatch *bool `json:"-"`
+
+	// Deduplicate embeds each distinct input once and copies the result
+	// to its duplicates. It is never sent to TEI.
+	Deduplicate *bool `json:"-"`
+
+	// EchoRequest asks for a summary of the request to be returned in
+	// EmbedResponse.Echo for correlation. It is never sent to TEI.
+	EchoRequest *bool `json:"-"`
+
+	// AllowDegraded returns cached or zero vectors flagged as degraded
+	// instead of an error when TEI is unreachable. It is never sent to TEI.
+	AllowDegraded *bool `json:"-"`
+
+	// PartialResults returns the embeddings of the sub-batches that
+	// succeeded along with an error for every input that failed, instead
+	// of failing the whole request. It is never sent to TEI.
+	PartialResults *bool `json:"-"`
 }
 
 func (r *EmbedRequest) Validate() error {
@@ -87,7 +124,53 @@ func (r *EmbedRequest) Validate() error {
 }
 
 type EmbedResponse struct {
-	Embeddings [][]float32 `json:"-"`
+	Embeddings [][]float32  `json:"-"`
+	Echo       *RequestEcho `json:"-"`
+
+	// Quantized replaces Embeddings when int8 encoding was requested
+	Quantized []QuantizedEmbedding `json:"-"`
+
+	// Truncated flags, per input, those TEI cut to the model's maximum
+	// input length. It is only set when truncation detection ran.
+	Truncated []bool `json:"-"`
+
+	// Degraded is set when the embeddings were served from the cache or
+	// zero-filled because TEI was unavailable
+	Degraded bool `json:"-"`
+
+	// Errors lists the inputs that failed in partial results mode, in
+	// input order. Their entries in Embeddings are nil.
+	Errors []InputError `json:"-"`
+}
+
+// InputError is the error for a single input of a partial response
+type InputError struct {
+	Index int
+	Err   error
+}
+
+// RequestEcho summarises the request an EmbedResponse was produced for
+type RequestEcho struct {
+	RequestID  string
+	InputCount int
+	InputHash  string
+}
+
+// NewRequestEcho builds the echo for a set of inputs. InputHash is the hex
+// SHA-256 of the inputs separated by NUL bytes.
+func NewRequestEcho(inputs []string) *RequestEcho {
+	hash := sha256.New()
+	for i, input := range inputs {
+		if i > 0 {
+			hash.Write([]byte{0})
+		}
+		hash.Write([]byte(input))
+	}
+
+	return &RequestEcho{
+		InputCount: len(inputs),
+		InputHash:  hex.EncodeToString(hash.Sum(nil)),
+	}
 }
 
 type EmbedAllRequest struct {
@@ -95,6 +178,15 @@ type EmbedAllRequest struct {
 	PromptName          *string             `json:"prompt_name,omitempty"`
 	Truncate            *bool               `json:"truncate,omitempty"`
 	TruncationDirection TruncationDirection `json:"truncation_direction,omitempty"`
+
+	// AutoBatch splits inputs larger than the maximum batch size into
+	// sub-batches. It is never sent to TEI.
+	AutoBatch *bool `json:"-"`
+
+	// MaxTokensPerInput keeps only the first token embeddings of each
+	// input, bounding the response size of long inputs. It is never sent
+	// to TEI.
+	MaxTokensPerInput *int `json:"-"`
 }
 
 func (r *EmbedAllRequest) Validate() error {
@@ -118,12 +210,19 @@ type EmbedSparseRequest struct {
 	PromptName          *string             `json:"prompt_name,omitempty"`
 	Truncate            *bool               `json:"truncate,omitempty"`
 	TruncationDirection TruncationDirection `json:"truncation_direction,omitempty"`
+
+	// TopK prunes each sparse embedding to its TopK highest values. It is
+	// never sent to TEI.
+	TopK *int `json:"-"`
 }
 
 func (r *EmbedSparseRequest) Validate() error {
 	if validationErr := r.Inputs.Validate(); validationErr != nil {
 		return validationErr
 	}
+	if r.TopK != nil && *r.TopK <= 0 {
+		return errors.NewValidationError("top_k", "must be positive", *r.TopK)
+	}
 	return nil
 }
 
@@ -139,12 +238,14 @@ func StringPtr(s string) *string {
 	return &s
 }
 
+// SetDefaults fills in the options the caller left unset. An explicit
+// Normalize or Truncate of false is kept and sent to TEI as is.
 func (r *EmbedRequest) SetDefaults() {
 	if r.Normalize == nil {
-		r.Normalize = BoolPtr(true)
+		r.Normalize = BoolPtr(DefaultNormalize)
 	}
 	if r.Truncate == nil {
-		r.Truncate = BoolPtr(false)
+		r.Truncate = BoolPtr(DefaultTruncate)
 	}
 	if r.TruncationDirection == "" {
 		r.TruncationDirection = TruncationRight
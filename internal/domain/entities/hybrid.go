@@ -0,0 +1,44 @@
+package entities
+
+// EmbedHybridRequest asks for dense and sparse embeddings of the same
+// inputs in one call
+type EmbedHybridRequest struct {
+	Inputs              Input
+	Normalize           *bool
+	PromptName          *string
+	Truncate            *bool
+	TruncationDirection TruncationDirection
+
+	// SparseTopK prunes each sparse embedding to its SparseTopK highest
+	// values
+	SparseTopK *int
+}
+
+// DenseRequest returns the /embed leg of the hybrid request
+func (r *EmbedHybridRequest) DenseRequest() *EmbedRequest {
+	return &EmbedRequest{
+		Inputs:              Input{Data: append([]string(nil), r.Inputs.Data...)},
+		Normalize:           r.Normalize,
+		PromptName:          r.PromptName,
+		Truncate:            r.Truncate,
+		TruncationDirection: r.TruncationDirection,
+	}
+}
+
+// SparseRequest returns the /embed_sparse leg of the hybrid request
+func (r *EmbedHybridRequest) SparseRequest() *EmbedSparseRequest {
+	return &EmbedSparseRequest{
+		Inputs:              Input{Data: append([]string(nil), r.Inputs.Data...)},
+		PromptName:          r.PromptName,
+		Truncate:            r.Truncate,
+		TruncationDirection: r.TruncationDirection,
+		TopK:                r.SparseTopK,
+	}
+}
+
+// EmbedHybridResponse holds dense and sparse embeddings aligned by input
+// index
+type EmbedHybridResponse struct {
+	Dense  [][]float32
+	Sparse [][]SparseValue
+}
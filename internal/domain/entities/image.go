@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+)
+
+// SupportedImageTypes are the content types accepted for image inputs
+var SupportedImageTypes = []string{"image/png", "image/jpeg", "image/webp", "image/gif"}
+
+// ImageInput is one image to embed, given either as an http(s) URL that
+// TEI fetches or as the encoded image bytes. ContentType is detected from
+// Data when empty.
+type ImageInput struct {
+	URL         string
+	Data        []byte
+	ContentType string
+}
+
+// EmbedImageRequest embeds images with a multimodal model such as CLIP.
+// TEI receives each image in the inputs of /embed, as its URL or as a
+// base64 data URI, and returns one vector per image.
+type EmbedImageRequest struct {
+	Images    []ImageInput `json:"-"`
+	Normalize *bool        `json:"-"`
+}
+
+func (r *EmbedImageRequest) SetDefaults() {
+	if r.Normalize == nil {
+		r.Normalize = BoolPtr(DefaultNormalize)
+	}
+}
+
+// MarshalJSON encodes the request as TEI's /embed body
+func (r *EmbedImageRequest) MarshalJSON() ([]byte, error) {
+	inputs := make([]string, len(r.Images))
+	for i, image := range r.Images {
+		if image.URL != "" {
+			inputs[i] = image.URL
+			continue
+		}
+		inputs[i] = fmt.Sprintf("data:%s;base64,%s", image.contentType(), base64.StdEncoding.EncodeToString(image.Data))
+	}
+
+	return json.Marshal(struct {
+		Inputs    []string `json:"inputs"`
+		Normalize *bool    `json:"normalize,omitempty"`
+	}{inputs, r.Normalize})
+}
+
+func (i ImageInput) contentType() string {
+	if i.ContentType != "" {
+		return i.ContentType
+	}
+	return http.DetectContentType(i.Data)
+}
+
+// ValidateImages checks that there is at least one image and no more than
+// the maximum batch size, and that each image is either an http(s) URL or
+// data of a supported type within the maximum image size
+func (v *Validator) ValidateImages(images []ImageInput, fieldName string) *errors.MultiValidationError {
+	validationErr := &errors.MultiValidationError{}
+
+	if len(images) == 0 {
+		validationErr.Add(fieldName, "cannot be empty", len(images))
+		return validationErr
+	}
+
+	if len(images) > v.config.MaxBatchSize {
+		validationErr.Add(fieldName, "exceeds maximum batch size", map[string]any{
+			"size":     len(images),
+			"max_size": v.config.MaxBatchSize,
+		})
+	}
+
+	for i, image := range images {
+		field := fmt.Sprintf("%s[%d]", fieldName, i)
+		switch {
+		case image.URL != "" && len(image.Data) > 0:
+			validationErr.Add(field, "must set either a URL or data, not both", nil)
+		case image.URL != "":
+			if parsed, err := url.Parse(image.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				validationErr.Add(field+".url", "must be an http or https URL", image.URL)
+			}
+		case len(image.Data) == 0:
+			validationErr.Add(field, "must set a URL or data", nil)
+		default:
+			if v.config.MaxImageBytes > 0 && len(image.Data) > v.config.MaxImageBytes {
+				validationErr.Add(field+".data", "exceeds maximum image size", map[string]any{
+					"bytes":     len(image.Data),
+					"max_bytes": v.config.MaxImageBytes,
+				})
+			}
+			if contentType := image.contentType(); !isSupportedImageType(contentType) {
+				validationErr.Add(field+".content_type", "unsupported image type", contentType)
+			}
+		}
+		if validationErr.HasErrors() && v.config.FailFastTexts {
+			break
+		}
+	}
+
+	if validationErr.HasErrors() {
+		return validationErr
+	}
+	return nil
+}
+
+func isSupportedImageType(contentType string) bool {
+	for _, supported := range SupportedImageTypes {
+		if contentType == supported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package entities
+
+// ModelInfo is the subset of TEI's /info response used to tune the client
+type ModelInfo struct {
+	ModelID               string `json:"model_id"`
+	ModelDType            string `json:"model_dtype"`
+	MaxConcurrentRequests int    `json:"max_concurrent_requests"`
+	MaxInputLength        int    `json:"max_input_length"`
+	MaxBatchTokens        int    `json:"max_batch_tokens"`
+	MaxBatchRequests      *int   `json:"max_batch_requests"`
+	MaxClientBatchSize    int    `json:"max_client_batch_size"`
+	AutoTruncate          bool   `json:"auto_truncate"`
+	Version               string `json:"version"`
+}
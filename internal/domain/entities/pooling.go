@@ -0,0 +1,90 @@
+package entities
+
+import "math"
+
+// PoolingStrategy reduces the token embeddings of one input to a single
+// sentence vector
+type PoolingStrategy string
+
+const (
+	PoolingMean PoolingStrategy = "mean"
+	PoolingMax  PoolingStrategy = "max"
+	PoolingCLS  PoolingStrategy = "cls"
+)
+
+// Pool reduces every input's token embeddings to one vector using the given
+// strategy, optionally L2-normalizing the result. An input without token
+// embeddings pools to an empty vector.
+func (r *EmbedAllResponse) Pool(strategy PoolingStrategy, normalize bool) [][]float32 {
+	pooled := make([][]float32, len(r.Embeddings))
+	for i, tokens := range r.Embeddings {
+		pooled[i] = PoolTokens(tokens, strategy)
+		if normalize {
+			L2Normalize(pooled[i])
+		}
+	}
+	return pooled
+}
+
+// MeanPool is Pool with PoolingMean
+func (r *EmbedAllResponse) MeanPool(normalize bool) [][]float32 {
+	return r.Pool(PoolingMean, normalize)
+}
+
+// PoolTokens reduces a single input's token embeddings to one vector.
+// Unknown strategies fall back to mean pooling.
+func PoolTokens(tokens [][]float32, strategy PoolingStrategy) []float32 {
+	if len(tokens) == 0 {
+		return []float32{}
+	}
+
+	pooled := append([]float32(nil), tokens[0]...)
+
+	switch strategy {
+	case PoolingCLS:
+		return pooled
+	case PoolingMax:
+		for _, token := range tokens[1:] {
+			for j, value := range token {
+				if value > pooled[j] {
+					pooled[j] = value
+				}
+			}
+		}
+	default:
+		for _, token := range tokens[1:] {
+			for j, value := range token {
+				pooled[j] += value
+			}
+		}
+		count := float32(len(tokens))
+		for j := range pooled {
+			pooled[j] /= count
+		}
+	}
+
+	return pooled
+}
+
+// L2Norm returns the Euclidean length of v
+func L2Norm(v []float32) float64 {
+	var sum float64
+	for _, value := range v {
+		sum += float64(value) * float64(value)
+	}
+	return math.Sqrt(sum)
+}
+
+// L2Normalize scales v in place to unit length. Zero vectors are left
+// unchanged.
+func L2Normalize(v []float32) {
+	norm := L2Norm(v)
+	if norm == 0 {
+		return
+	}
+
+	scale := float32(1 / norm)
+	for i := range v {
+		v[i] *= scale
+	}
+}
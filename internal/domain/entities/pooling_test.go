@@ -0,0 +1,98 @@
+package entities
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestPoolTokens(t *testing.T) {
+	tokens := [][]float32{{1, -2, 3}, {3, 4, -1}}
+
+	tests := []struct {
+		name     string
+		tokens   [][]float32
+		strategy PoolingStrategy
+		want     []float32
+	}{
+		{"mean", tokens, PoolingMean, []float32{2, 1, 1}},
+		{"max", tokens, PoolingMax, []float32{3, 4, 3}},
+		{"cls", tokens, PoolingCLS, []float32{1, -2, 3}},
+		{"unknown strategy falls back to mean", tokens, "median", []float32{2, 1, 1}},
+		{"single token mean", tokens[:1], PoolingMean, []float32{1, -2, 3}},
+		{"single token max", tokens[:1], PoolingMax, []float32{1, -2, 3}},
+		{"no tokens", nil, PoolingMean, []float32{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PoolTokens(tt.tokens, tt.strategy)
+			if !slices.Equal(got, tt.want) || got == nil {
+				t.Errorf("PoolTokens() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolTokensDoesNotModifyInput(t *testing.T) {
+	tokens := [][]float32{{1, 2}, {3, 4}}
+
+	PoolTokens(tokens, PoolingMean)
+
+	if !slices.Equal(tokens[0], []float32{1, 2}) {
+		t.Errorf("first token changed to %v", tokens[0])
+	}
+}
+
+func TestEmbedAllResponsePool(t *testing.T) {
+	resp := &EmbedAllResponse{Embeddings: [][][]float32{
+		{{3, 0}, {3, 8}},
+		{},
+		{{0, 2}},
+	}}
+
+	pooled := resp.MeanPool(true)
+
+	if len(pooled) != 3 {
+		t.Fatalf("got %d pooled vectors, want 3", len(pooled))
+	}
+	if want := []float32{0.6, 0.8}; !approxEqual(pooled[0], want) {
+		t.Errorf("pooled[0] = %v, want %v", pooled[0], want)
+	}
+	if len(pooled[1]) != 0 {
+		t.Errorf("pooled[1] = %v, want an empty vector for an input without tokens", pooled[1])
+	}
+	if want := []float32{0, 1}; !approxEqual(pooled[2], want) {
+		t.Errorf("pooled[2] = %v, want %v", pooled[2], want)
+	}
+
+	if raw := resp.Pool(PoolingMean, false); !slices.Equal(raw[0], []float32{3, 4}) {
+		t.Errorf("unnormalized pooled[0] = %v, want [3 4]", raw[0])
+	}
+}
+
+func TestL2Normalize(t *testing.T) {
+	v := []float32{3, 4}
+	L2Normalize(v)
+	if !approxEqual(v, []float32{0.6, 0.8}) {
+		t.Errorf("L2Normalize([3 4]) = %v, want [0.6 0.8]", v)
+	}
+
+	zero := []float32{0, 0}
+	L2Normalize(zero)
+	if !slices.Equal(zero, []float32{0, 0}) {
+		t.Errorf("L2Normalize(zero) = %v, want it unchanged", zero)
+	}
+}
+
+func approxEqual(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package entities
+
+// NewQueryEmbedRequest returns a request embedding search queries: the
+// model's query prefix is applied, vectors are normalized for cosine
+// scoring and overlong queries are truncated rather than rejected
+func NewQueryEmbedRequest(queries ...string) *EmbedRequest {
+	return &EmbedRequest{
+		Inputs:    Input{Data: queries},
+		Normalize: BoolPtr(true),
+		Truncate:  BoolPtr(true),
+		InputRole: InputRoleQuery,
+	}
+}
+
+// NewDocumentEmbedRequest returns a request embedding documents for
+// indexing: the model's document prefix is applied, vectors are normalized,
+// overlong documents are truncated and batches larger than the maximum
+// batch size are split rather than rejected
+func NewDocumentEmbedRequest(documents ...string) *EmbedRequest {
+	return &EmbedRequest{
+		Inputs:    Input{Data: documents},
+		Normalize: BoolPtr(true),
+		Truncate:  BoolPtr(true),
+		InputRole: InputRoleDocument,
+		AutoBatch: BoolPtr(true),
+	}
+}
+
+// WithPromptName uses TEI's named prompt in place of the role prefix
+func (r *EmbedRequest) WithPromptName(name string) *EmbedRequest {
+	r.PromptName = StringPtr(name)
+	return r
+}
+
+// WithDimensions requests Matryoshka embeddings of the given size
+func (r *EmbedRequest) WithDimensions(dimensions int) *EmbedRequest {
+	r.Dimensions = &dimensions
+	return r
+}
+
+// WithTruncationDirection sets which end of overlong inputs is cut, in
+// place of the service default
+func (r *EmbedRequest) WithTruncationDirection(direction TruncationDirection) *EmbedRequest {
+	r.TruncationDirection = direction
+	return r
+}
@@ -0,0 +1,123 @@
+package entities
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+)
+
+// PromptPlaceholder marks where the input text goes in a prompt template
+const PromptPlaceholder = "{text}"
+
+// PromptRegistry holds named prompt templates such as "query: {text}".
+// Names are matched case-insensitively. A template without the placeholder
+// is used as a prefix.
+type PromptRegistry struct {
+	templates map[string]string
+}
+
+func NewPromptRegistry(templates map[string]string) *PromptRegistry {
+	registry := &PromptRegistry{templates: make(map[string]string, len(templates))}
+	for name, template := range templates {
+		registry.templates[strings.ToLower(name)] = template
+	}
+	return registry
+}
+
+// Has reports whether name is a registered prompt
+func (r *PromptRegistry) Has(name string) bool {
+	_, ok := r.templates[strings.ToLower(name)]
+	return ok
+}
+
+// Expand applies the named template to every input, returning a new slice.
+// It returns a ValidationError if the prompt is not registered.
+func (r *PromptRegistry) Expand(name string, inputs []string) ([]string, error) {
+	template, ok := r.templates[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.NewValidationError("prompt_name", "unknown prompt", name)
+	}
+
+	expanded := make([]string, len(inputs))
+	for i, input := range inputs {
+		if strings.Contains(template, PromptPlaceholder) {
+			expanded[i] = strings.ReplaceAll(template, PromptPlaceholder, input)
+		} else {
+			expanded[i] = template + input
+		}
+	}
+	return expanded, nil
+}
+
+// InputRole marks inputs as queries or documents for asymmetric retrieval
+// models such as e5 and bge, which expect a different prefix on each side
+type InputRole string
+
+const (
+	InputRoleQuery    InputRole = "query"
+	InputRoleDocument InputRole = "document"
+)
+
+// RolePrefixes are the prefixes a model expects on queries and documents,
+// e.g. "query: " and "passage: "
+type RolePrefixes struct {
+	Query    string
+	Document string
+}
+
+// Apply returns inputs with the prefix for role prepended. Inputs are
+// returned unchanged when the role has no prefix.
+func (p RolePrefixes) Apply(role InputRole, inputs []string) []string {
+	var prefix string
+	switch role {
+	case InputRoleQuery:
+		prefix = p.Query
+	case InputRoleDocument:
+		prefix = p.Document
+	}
+	if prefix == "" {
+		return inputs
+	}
+
+	prefixed := make([]string, len(inputs))
+	for i, input := range inputs {
+		prefixed[i] = prefix + input
+	}
+	return prefixed
+}
+
+// RolePrefixWildcard is the model name whose prefixes apply to any model
+// without an entry of its own
+const RolePrefixWildcard = "*"
+
+// RolePrefixRegistry holds role prefixes per model ID and tracks which model
+// TEI is serving. Model IDs are matched case-insensitively.
+type RolePrefixRegistry struct {
+	byModel map[string]RolePrefixes
+	current atomic.Pointer[RolePrefixes]
+}
+
+func NewRolePrefixRegistry(byModel map[string]RolePrefixes) *RolePrefixRegistry {
+	registry := &RolePrefixRegistry{byModel: make(map[string]RolePrefixes, len(byModel))}
+	for model, prefixes := range byModel {
+		registry.byModel[strings.ToLower(model)] = prefixes
+	}
+	registry.UseModel("")
+	return registry
+}
+
+// UseModel selects the prefixes for modelID, falling back to the wildcard
+// entry
+func (r *RolePrefixRegistry) UseModel(modelID string) {
+	prefixes, ok := r.byModel[strings.ToLower(modelID)]
+	if !ok {
+		prefixes = r.byModel[RolePrefixWildcard]
+	}
+	r.current.Store(&prefixes)
+}
+
+// Prefixes returns the prefixes for the current model
+func (r *RolePrefixRegistry) Prefixes() RolePrefixes {
+	return *r.current.Load()
+}
@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+)
+
+// QuantizedEmbedding is an embedding stored as int8 with symmetric
+// per-vector quantization: value ≈ Values[i] * Scale
+type QuantizedEmbedding struct {
+	Values []int8
+	Scale  float32
+}
+
+// QuantizeInt8 maps v onto [-127, 127] using its largest absolute value as
+// the scale. A zero vector quantizes to zeros with a zero scale; a vector
+// with a NaN or infinite value has no scale and is rejected.
+func QuantizeInt8(v []float32) (QuantizedEmbedding, error) {
+	var maxAbs float64
+	for i, value := range v {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return QuantizedEmbedding{}, errors.NewTEIError(
+				fmt.Sprintf("embedding value %d is %v and cannot be quantized to int8", i, value),
+				errors.ErrorTypeResponseMalformed)
+		}
+		maxAbs = math.Max(maxAbs, math.Abs(float64(value)))
+	}
+
+	quantized := QuantizedEmbedding{Values: make([]int8, len(v))}
+	if maxAbs == 0 {
+		return quantized, nil
+	}
+
+	scale := maxAbs / 127
+	for i, value := range v {
+		quantized.Values[i] = int8(math.Round(float64(value) / scale))
+	}
+	quantized.Scale = float32(scale)
+
+	return quantized, nil
+}
+
+// Dequantize restores an approximation of the original float32 vector
+func (q QuantizedEmbedding) Dequantize() []float32 {
+	values := make([]float32, len(q.Values))
+	for i, value := range q.Values {
+		values[i] = float32(value) * q.Scale
+	}
+	return values
+}
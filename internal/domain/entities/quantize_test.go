@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestQuantizeInt8RoundTrip(t *testing.T) {
+	v := []float32{0.5, -1, 0.25, 0}
+
+	quantized, err := QuantizeInt8(v)
+	if err != nil {
+		t.Fatalf("QuantizeInt8: %v", err)
+	}
+
+	if want := []int8{64, -127, 32, 0}; !slices.Equal(quantized.Values, want) {
+		t.Errorf("values = %v, want %v", quantized.Values, want)
+	}
+	for i, value := range quantized.Dequantize() {
+		if math.Abs(float64(value-v[i])) > float64(quantized.Scale)/2 {
+			t.Errorf("dequantized[%d] = %v, want within half a step of %v", i, value, v[i])
+		}
+	}
+
+	zero, err := QuantizeInt8([]float32{0, 0})
+	if err != nil || zero.Scale != 0 || !slices.Equal(zero.Values, []int8{0, 0}) {
+		t.Errorf("QuantizeInt8(zero) = %+v, %v, want zeros with a zero scale", zero, err)
+	}
+}
+
+func TestQuantizeInt8RejectsNonFinite(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float32
+	}{
+		{"NaN", float32(math.NaN())},
+		{"+Inf", float32(math.Inf(1))},
+		{"-Inf", float32(math.Inf(-1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if quantized, err := QuantizeInt8([]float32{0.5, tt.value}); err == nil {
+				t.Errorf("QuantizeInt8 = %+v, want an error", quantized)
+			}
+		})
+	}
+}
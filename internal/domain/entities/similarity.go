@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/blackprince001/embedding-inference/internal/domain/errors"
@@ -23,8 +24,8 @@ func (s *SimilarityInput) Validate() error {
 	} else {
 		for idx, sentence := range s.Sentences {
 			if strings.TrimSpace(sentence) == "" {
-				validationErr.Add("sentences",
-					"sentence at index "+string(rune(idx))+" cannot be empty", sentence)
+				validationErr.Add(fmt.Sprintf("sentences[%d]", idx),
+					fmt.Sprintf("sentence at index %d cannot be empty", idx), sentence)
 			}
 		}
 	}
@@ -35,10 +36,29 @@ func (s *SimilarityInput) Validate() error {
 	return nil
 }
 
+// SimilarityMetric selects how similarity is scored when it is computed
+// locally from embeddings. Every metric follows "higher is more similar":
+// euclidean scores are the negated distance.
+type SimilarityMetric string
+
+const (
+	SimilarityCosine    SimilarityMetric = "cosine"
+	SimilarityDot       SimilarityMetric = "dot"
+	SimilarityEuclidean SimilarityMetric = "euclidean"
+)
+
 type SimilarityParameters struct {
 	PromptName          *string             `json:"prompt_name,omitempty"`
 	Truncate            *bool               `json:"truncate,omitempty"`
 	TruncationDirection TruncationDirection `json:"truncation_direction,omitempty"`
+
+	// Metric computes similarity locally from embeddings instead of using
+	// TEI's /similarity route. It is never sent to TEI.
+	Metric SimilarityMetric `json:"-"`
+
+	// Ranked also returns SimilarityResponse.Ranked. It is never sent to
+	// TEI.
+	Ranked *bool `json:"-"`
 }
 
 func (p *SimilarityParameters) SetDefaults() {
@@ -68,4 +88,38 @@ func (r *SimilarityRequest) SetDefaults() {
 
 type SimilarityResponse struct {
 	Similarities []float32 `json:"-"`
+
+	// Ranked holds every sentence with its score, best first, when the
+	// request asked for it
+	Ranked []SimilarSentence `json:"-"`
+}
+
+// SimilarSentence is a candidate sentence labeled with its index in the
+// request and its similarity to the source sentence
+type SimilarSentence struct {
+	Index      int     `json:"index"`
+	Sentence   string  `json:"sentence"`
+	Similarity float32 `json:"similarity"`
+}
+
+// Neighbor is a vector of a precomputed corpus, by index, and its dot
+// product with the query
+type Neighbor struct {
+	Index int     `json:"index"`
+	Score float32 `json:"score"`
+}
+
+// SimilarityMatch is a candidate sentence, by index, and its score
+type SimilarityMatch struct {
+	Index int
+	Score float32
+}
+
+// SimilarityProgress is one partial result of a streamed similarity
+// request: the best matches among the first Scored of Total candidates
+type SimilarityProgress struct {
+	TopMatches []SimilarityMatch
+	Scored     int
+	Total      int
+	Done       bool
 }
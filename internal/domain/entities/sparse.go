@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PruneSparse keeps the k highest-valued entries of a sparse embedding,
+// returned in index order. Ties keep the lower index. The input is not
+// modified; if it has at most k entries it is returned as is.
+func PruneSparse(values []SparseValue, k int) []SparseValue {
+	if k < 0 {
+		k = 0
+	}
+	if len(values) <= k {
+		return values
+	}
+
+	pruned := make([]SparseValue, len(values))
+	copy(pruned, values)
+	sort.Slice(pruned, func(i, j int) bool {
+		if pruned[i].Value != pruned[j].Value {
+			return pruned[i].Value > pruned[j].Value
+		}
+		return pruned[i].Index < pruned[j].Index
+	})
+
+	pruned = pruned[:k]
+	sort.Slice(pruned, func(i, j int) bool {
+		return pruned[i].Index < pruned[j].Index
+	})
+
+	return pruned
+}
+
+// SparseToDense expands a sparse embedding into a vector of vocabSize
+// values, failing if an index falls outside the vocabulary
+func SparseToDense(values []SparseValue, vocabSize int) ([]float32, error) {
+	dense := make([]float32, vocabSize)
+	for _, value := range values {
+		if value.Index < 0 || value.Index >= vocabSize {
+			return nil, fmt.Errorf("sparse index %d outside vocabulary of size %d", value.Index, vocabSize)
+		}
+		dense[value.Index] = value.Value
+	}
+	return dense, nil
+}
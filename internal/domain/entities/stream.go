@@ -0,0 +1,16 @@
+package entities
+
+// StreamInput is one text of a streamed embed request, identified by a
+// caller-chosen ID that is echoed in its StreamResult
+type StreamInput struct {
+	ID   string
+	Text string
+}
+
+// StreamResult is the embedding of one StreamInput, or the error of the
+// batch it was embedded in when the stream continues past failures
+type StreamResult struct {
+	ID        string
+	Embedding []float32
+	Err       error
+}
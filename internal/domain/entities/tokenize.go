@@ -0,0 +1,52 @@
+package entities
+
+type TokenizeRequest struct {
+	Inputs           Input   `json:"inputs" validate:"required"`
+	AddSpecialTokens *bool   `json:"add_special_tokens,omitempty"`
+	PromptName       *string `json:"prompt_name,omitempty"`
+}
+
+func (r *TokenizeRequest) SetDefaults() {
+	if r.AddSpecialTokens == nil {
+		r.AddSpecialTokens = BoolPtr(DefaultAddSpecialTokens)
+	}
+}
+
+func (r *TokenizeRequest) Validate() error {
+	if validationErr := r.Inputs.Validate(); validationErr != nil {
+		return validationErr
+	}
+	return nil
+}
+
+// Token is one token of a /tokenize response. Start and Stop are byte
+// offsets into the input and are absent for special tokens.
+type Token struct {
+	ID      int    `json:"id"`
+	Text    string `json:"text"`
+	Special bool   `json:"special"`
+	Start   *int   `json:"start"`
+	Stop    *int   `json:"stop"`
+}
+
+type TokenizeResponse struct {
+	Tokens [][]Token `json:"-"`
+}
+
+// TokenCount is the token count of one input. Truncated reports whether
+// the input, with the special tokens TEI adds, exceeds the model's maximum
+// input length, so embedding it with truncation enabled would drop tokens;
+// it is nil when that length is not known.
+type TokenCount struct {
+	Tokens    int   `json:"tokens"`
+	Truncated *bool `json:"truncated,omitempty"`
+}
+
+type TokenCountResponse struct {
+	Counts []TokenCount `json:"counts"`
+	Total  int          `json:"total"`
+
+	// MaxInputTokens is the model's maximum input length, or zero when
+	// it is not known
+	MaxInputTokens int `json:"max_input_tokens"`
+}
@@ -0,0 +1,23 @@
+package entities
+
+// EmbedTokensRequest embeds inputs that were already tokenized, sending
+// their token ids to TEI's /embed in place of text. Prompts, role prefixes
+// and the cache do not apply since there is no text to work on.
+type EmbedTokensRequest struct {
+	Inputs              [][]uint32          `json:"inputs"`
+	Normalize           *bool               `json:"normalize,omitempty"`
+	Truncate            *bool               `json:"truncate,omitempty"`
+	TruncationDirection TruncationDirection `json:"truncation_direction,omitempty"`
+}
+
+func (r *EmbedTokensRequest) SetDefaults() {
+	if r.Normalize == nil {
+		r.Normalize = BoolPtr(DefaultNormalize)
+	}
+	if r.Truncate == nil {
+		r.Truncate = BoolPtr(DefaultTruncate)
+	}
+	if r.TruncationDirection == "" {
+		r.TruncationDirection = TruncationRight
+	}
+}
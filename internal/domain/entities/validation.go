@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"unicode/utf8"
@@ -13,6 +14,20 @@ type ValidationConfig struct {
 	MaxBatchSize      int
 	MaxSentencesCount int
 	AllowEmptyStrings bool
+
+	// FailFast stops request validation at the first failing check instead
+	// of reporting every violation. FailFastTexts likewise stops checking
+	// a list of texts at the first invalid one. The Validate RPC always
+	// reports everything.
+	FailFast      bool
+	FailFastTexts bool
+
+	// MaxImageBytes bounds the encoded size of image inputs; 0 is unlimited
+	MaxImageBytes int
+
+	// Token limits reported by the model; zero when unknown
+	MaxInputTokens int
+	MaxBatchTokens int
 }
 
 func DefaultValidationConfig() *ValidationConfig {
@@ -21,6 +36,8 @@ func DefaultValidationConfig() *ValidationConfig {
 		MaxBatchSize:      32,
 		MaxSentencesCount: 100,
 		AllowEmptyStrings: false,
+		FailFastTexts:     true,
+		MaxImageBytes:     10 << 20,
 	}
 }
 
@@ -55,7 +72,108 @@ func (v *Validator) ValidateText(text string, fieldName string) *errors.Validati
 	return nil
 }
 
+func (v *Validator) Config() *ValidationConfig {
+	return v.config
+}
+
+// ApplyModelInfo tightens the limits to what the deployed model reports.
+// Configured limits that are already stricter are kept. It must be called
+// before the validator is used concurrently.
+func (v *Validator) ApplyModelInfo(info *ModelInfo) {
+	if info.MaxClientBatchSize > 0 && info.MaxClientBatchSize < v.config.MaxBatchSize {
+		v.config.MaxBatchSize = info.MaxClientBatchSize
+	}
+	if info.MaxInputLength > 0 {
+		v.config.MaxInputTokens = info.MaxInputLength
+	}
+	if info.MaxBatchTokens > 0 {
+		v.config.MaxBatchTokens = info.MaxBatchTokens
+	}
+}
+
+// ValidateTokenCounts checks per-input and total token counts against the
+// model's limits
+func (v *Validator) ValidateTokenCounts(counts []int, fieldName string) *errors.MultiValidationError {
+	validationErr := &errors.MultiValidationError{}
+
+	total := 0
+	for i, count := range counts {
+		total += count
+		if v.config.MaxInputTokens > 0 && count > v.config.MaxInputTokens {
+			validationErr.Add(fmt.Sprintf("%s[%d]", fieldName, i),
+				"exceeds the model's maximum input length", map[string]any{
+					"tokens":     count,
+					"max_tokens": v.config.MaxInputTokens,
+				})
+		}
+	}
+
+	if v.config.MaxBatchTokens > 0 && total > v.config.MaxBatchTokens {
+		validationErr.Add(fieldName, "exceeds the model's maximum batch tokens", map[string]any{
+			"tokens":     total,
+			"max_tokens": v.config.MaxBatchTokens,
+		})
+	}
+
+	if validationErr.HasErrors() {
+		return validationErr
+	}
+	return nil
+}
+
+// ValidateTokenIDs checks pre-tokenized inputs: the batch and every input
+// must be non-empty and the batch within the maximum batch size. Unless
+// TEI is allowed to truncate, inputs must also fit the model's maximum
+// input length, or the maximum input length in characters while that is
+// not known.
+func (v *Validator) ValidateTokenIDs(inputs [][]uint32, fieldName string, truncate bool) *errors.MultiValidationError {
+	validationErr := &errors.MultiValidationError{}
+
+	if len(inputs) == 0 {
+		validationErr.Add(fieldName, "cannot be empty", len(inputs))
+		return validationErr
+	}
+
+	if len(inputs) > v.config.MaxBatchSize {
+		validationErr.Add(fieldName, "exceeds maximum batch size", map[string]any{
+			"size":     len(inputs),
+			"max_size": v.config.MaxBatchSize,
+		})
+	}
+
+	counts := make([]int, len(inputs))
+	for i, ids := range inputs {
+		counts[i] = len(ids)
+		field := fmt.Sprintf("%s[%d]", fieldName, i)
+		if len(ids) == 0 {
+			validationErr.Add(field, "cannot be empty", 0)
+			continue
+		}
+		if !truncate && v.config.MaxInputTokens == 0 && len(ids) > v.config.MaxInputLength {
+			validationErr.Add(field, "exceeds maximum length", map[string]any{
+				"length":     len(ids),
+				"max_length": v.config.MaxInputLength,
+			})
+		}
+	}
+
+	if !truncate {
+		if countErr := v.ValidateTokenCounts(counts, fieldName); countErr != nil {
+			validationErr.Errors = append(validationErr.Errors, countErr.Errors...)
+		}
+	}
+
+	if validationErr.HasErrors() {
+		return validationErr
+	}
+	return nil
+}
+
 func (v *Validator) ValidateTexts(texts []string, fieldName string) *errors.MultiValidationError {
+	return v.validateTexts(texts, fieldName, true, v.config.FailFastTexts)
+}
+
+func (v *Validator) validateTexts(texts []string, fieldName string, checkBatchSize, failFast bool) *errors.MultiValidationError {
 	validationErr := &errors.MultiValidationError{}
 
 	if len(texts) == 0 {
@@ -63,16 +181,19 @@ func (v *Validator) ValidateTexts(texts []string, fieldName string) *errors.Mult
 		return validationErr
 	}
 
-	if len(texts) > v.config.MaxBatchSize {
-		validationErr.Add(fieldName, "exceeds maximum batch size", map[string]interface{}{
+	if checkBatchSize && len(texts) > v.config.MaxBatchSize {
+		validationErr.Add(fieldName, "exceeds maximum batch size", map[string]any{
 			"size":     len(texts),
 			"max_size": v.config.MaxBatchSize,
 		})
 	}
 
 	for i, text := range texts {
-		if err := v.ValidateText(text, fieldName+"["+string(rune(i))+"]"); err != nil {
+		if err := v.ValidateText(text, fmt.Sprintf("%s[%d]", fieldName, i)); err != nil {
 			validationErr.Add(err.Field, err.Message, err.Value)
+			if failFast {
+				break
+			}
 		}
 	}
 
@@ -106,11 +227,11 @@ func (v *Validator) ValidateEncodingFormat(format EncodingFormat) *errors.Valida
 	}
 
 	switch format {
-	case EncodingFloat, EncodingBase64:
+	case EncodingFloat, EncodingBase64, EncodingInt8:
 		return nil
 	default:
 		return errors.NewValidationError("encoding_format",
-			"must be 'float' or 'base64'", string(format))
+			"must be 'float', 'base64' or 'int8'", string(format))
 	}
 }
 
@@ -128,7 +249,135 @@ func (v *Validator) ValidateTruncationDirection(direction TruncationDirection) *
 	}
 }
 
+func (v *Validator) ValidateSimilarityMetric(metric SimilarityMetric) *errors.ValidationError {
+	if metric == "" {
+		return nil
+	}
+
+	switch metric {
+	case SimilarityCosine, SimilarityDot, SimilarityEuclidean:
+		return nil
+	default:
+		return errors.NewValidationError("metric",
+			"must be 'cosine', 'dot' or 'euclidean'", string(metric))
+	}
+}
+
+func (v *Validator) ValidateInputRole(role InputRole) *errors.ValidationError {
+	switch role {
+	case "", InputRoleQuery, InputRoleDocument:
+		return nil
+	default:
+		return errors.NewValidationError("input_role",
+			"must be 'query' or 'document'", string(role))
+	}
+}
+
+func (v *Validator) ValidateDimensions(dimensions *int) *errors.ValidationError {
+	if dimensions == nil {
+		return nil
+	}
+
+	if *dimensions <= 0 {
+		return errors.NewValidationError("dimensions", "must be positive", *dimensions)
+	}
+
+	return nil
+}
+
+// ValidateEmbedRequest checks req and returns the first violation, or all
+// of them unless FailFast is set
 func (v *Validator) ValidateEmbedRequest(req *EmbedRequest) error {
+	if !v.config.FailFast {
+		if validationErr := v.collectEmbedRequestErrors(req, v.config.FailFastTexts); validationErr != nil {
+			return validationErr
+		}
+		return nil
+	}
+
+	autoBatch := req.AutoBatch != nil && *req.AutoBatch
+	if err := v.validateTexts(req.Inputs.Data, "inputs", !autoBatch, v.config.FailFastTexts); err != nil {
+		return err
+	}
+
+	if err := v.ValidatePromptName(req.PromptName); err != nil {
+		return err
+	}
+
+	if err := v.ValidateTruncationDirection(req.TruncationDirection); err != nil {
+		return err
+	}
+
+	if err := v.ValidateDimensions(req.Dimensions); err != nil {
+		return err
+	}
+
+	if err := v.ValidateInputRole(req.InputRole); err != nil {
+		return err
+	}
+
+	if err := v.ValidateEncodingFormat(req.EncodingFormat); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateEmbedAllRequest checks req and returns the first violation, or
+// all of them unless FailFast is set
+func (v *Validator) ValidateEmbedAllRequest(req *EmbedAllRequest) error {
+	if !v.config.FailFast {
+		if validationErr := v.collectEmbedAllRequestErrors(req); validationErr != nil {
+			return validationErr
+		}
+		return nil
+	}
+
+	autoBatch := req.AutoBatch != nil && *req.AutoBatch
+	if err := v.validateTexts(req.Inputs.Data, "inputs", !autoBatch, v.config.FailFastTexts); err != nil {
+		return err
+	}
+
+	if err := v.ValidatePromptName(req.PromptName); err != nil {
+		return err
+	}
+
+	if err := v.ValidateTruncationDirection(req.TruncationDirection); err != nil {
+		return err
+	}
+
+	if err := validatePositive(req.MaxTokensPerInput, "max_tokens_per_input"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (v *Validator) collectEmbedAllRequestErrors(req *EmbedAllRequest) *errors.MultiValidationError {
+	validationErr := &errors.MultiValidationError{}
+
+	autoBatch := req.AutoBatch != nil && *req.AutoBatch
+	validationErr.Merge(v.validateTexts(req.Inputs.Data, "inputs", !autoBatch, v.config.FailFastTexts))
+	validationErr.AddError(v.ValidatePromptName(req.PromptName))
+	validationErr.AddError(v.ValidateTruncationDirection(req.TruncationDirection))
+	validationErr.AddError(validatePositive(req.MaxTokensPerInput, "max_tokens_per_input"))
+
+	if validationErr.HasErrors() {
+		return validationErr
+	}
+	return nil
+}
+
+// ValidateEmbedSparseRequest checks req and returns the first violation, or
+// all of them unless FailFast is set
+func (v *Validator) ValidateEmbedSparseRequest(req *EmbedSparseRequest) error {
+	if !v.config.FailFast {
+		if validationErr := v.collectEmbedSparseRequestErrors(req); validationErr != nil {
+			return validationErr
+		}
+		return nil
+	}
+
 	if err := v.ValidateTexts(req.Inputs.Data, "inputs"); err != nil {
 		return err
 	}
@@ -141,10 +390,45 @@ func (v *Validator) ValidateEmbedRequest(req *EmbedRequest) error {
 		return err
 	}
 
+	if err := validatePositive(req.TopK, "top_k"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (v *Validator) collectEmbedSparseRequestErrors(req *EmbedSparseRequest) *errors.MultiValidationError {
+	validationErr := &errors.MultiValidationError{}
+
+	validationErr.Merge(v.ValidateTexts(req.Inputs.Data, "inputs"))
+	validationErr.AddError(v.ValidatePromptName(req.PromptName))
+	validationErr.AddError(v.ValidateTruncationDirection(req.TruncationDirection))
+	validationErr.AddError(validatePositive(req.TopK, "top_k"))
+
+	if validationErr.HasErrors() {
+		return validationErr
+	}
+	return nil
+}
+
+// validatePositive checks an optional count that must be positive when set
+func validatePositive(value *int, fieldName string) *errors.ValidationError {
+	if value != nil && *value <= 0 {
+		return errors.NewValidationError(fieldName, "must be positive", *value)
+	}
 	return nil
 }
 
+// ValidateSimilarityRequest checks req and returns the first violation, or
+// all of them unless FailFast is set
 func (v *Validator) ValidateSimilarityRequest(req *SimilarityRequest) error {
+	if !v.config.FailFast {
+		if validationErr := v.collectSimilarityRequestErrors(req, v.config.FailFastTexts); validationErr != nil {
+			return validationErr
+		}
+		return nil
+	}
+
 	if err := v.ValidateText(req.Inputs.SourceSentence, "source_sentence"); err != nil {
 		return err
 	}
@@ -169,7 +453,67 @@ func (v *Validator) ValidateSimilarityRequest(req *SimilarityRequest) error {
 		if err := v.ValidateTruncationDirection(req.Parameters.TruncationDirection); err != nil {
 			return err
 		}
+
+		if err := v.ValidateSimilarityMetric(req.Parameters.Metric); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CollectEmbedRequestErrors runs every embed request check and returns all
+// violations rather than stopping at the first, or nil if there are none
+func (v *Validator) CollectEmbedRequestErrors(req *EmbedRequest) *errors.MultiValidationError {
+	return v.collectEmbedRequestErrors(req, false)
+}
+
+func (v *Validator) collectEmbedRequestErrors(req *EmbedRequest, failFastTexts bool) *errors.MultiValidationError {
+	validationErr := &errors.MultiValidationError{}
+
+	autoBatch := req.AutoBatch != nil && *req.AutoBatch
+	validationErr.Merge(v.validateTexts(req.Inputs.Data, "inputs", !autoBatch, failFastTexts))
+	validationErr.AddError(v.ValidatePromptName(req.PromptName))
+	validationErr.AddError(v.ValidateTruncationDirection(req.TruncationDirection))
+	validationErr.AddError(v.ValidateDimensions(req.Dimensions))
+	validationErr.AddError(v.ValidateInputRole(req.InputRole))
+	validationErr.AddError(v.ValidateEncodingFormat(req.EncodingFormat))
+
+	if validationErr.HasErrors() {
+		return validationErr
 	}
+	return nil
+}
+
+// CollectSimilarityRequestErrors runs every similarity request check and
+// returns all violations rather than stopping at the first, or nil if there
+// are none
+func (v *Validator) CollectSimilarityRequestErrors(req *SimilarityRequest) *errors.MultiValidationError {
+	return v.collectSimilarityRequestErrors(req, false)
+}
+
+func (v *Validator) collectSimilarityRequestErrors(req *SimilarityRequest, failFastTexts bool) *errors.MultiValidationError {
+	validationErr := &errors.MultiValidationError{}
+
+	validationErr.AddError(v.ValidateText(req.Inputs.SourceSentence, "source_sentence"))
 
+	if len(req.Inputs.Sentences) > v.config.MaxSentencesCount {
+		validationErr.Add("sentences", "exceeds maximum sentences count", map[string]any{
+			"count":     len(req.Inputs.Sentences),
+			"max_count": v.config.MaxSentencesCount,
+		})
+	}
+
+	validationErr.Merge(v.validateTexts(req.Inputs.Sentences, "sentences", true, failFastTexts))
+
+	if req.Parameters != nil {
+		validationErr.AddError(v.ValidatePromptName(req.Parameters.PromptName))
+		validationErr.AddError(v.ValidateTruncationDirection(req.Parameters.TruncationDirection))
+		validationErr.AddError(v.ValidateSimilarityMetric(req.Parameters.Metric))
+	}
+
+	if validationErr.HasErrors() {
+		return validationErr
+	}
 	return nil
 }
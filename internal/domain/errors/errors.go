@@ -13,10 +13,19 @@ const (
 	ErrorTypeValidation ErrorType = "validation"
 	ErrorTypeTokenizer  ErrorType = "tokenizer"
 	ErrorTypeBackend    ErrorType = "backend"
+
+	// ErrorTypeResponseMalformed and ErrorTypeResponseMismatch are backend
+	// errors found in a successful TEI response: a body that cannot be
+	// parsed, or one that parses but does not match the request, such as
+	// holding the wrong number of embeddings
+	ErrorTypeResponseMalformed ErrorType = "response_malformed"
+	ErrorTypeResponseMismatch  ErrorType = "response_mismatch"
+
 	ErrorTypeOverloaded ErrorType = "overloaded"
 	ErrorTypeUnhealthy  ErrorType = "unhealthy"
 	ErrorTypeNetwork    ErrorType = "network"
 	ErrorTypeTimeout    ErrorType = "timeout"
+	ErrorTypeCanceled   ErrorType = "canceled"
 	ErrorTypeUnknown    ErrorType = "unknown"
 )
 
@@ -49,6 +58,12 @@ func (e *TEIError) IsRetryable() bool {
 	}
 }
 
+// IsOutage returns true if the error indicates the backend is unreachable
+// or reporting itself unhealthy, as opposed to rejecting a specific request
+func (e *TEIError) IsOutage() bool {
+	return e.Type == ErrorTypeNetwork || e.Type == ErrorTypeUnhealthy
+}
+
 // ValidationError represents input validation errors
 type ValidationError struct {
 	Field   string `json:"field"`
@@ -88,6 +103,83 @@ func (m *MultiValidationError) HasErrors() bool {
 	return len(m.Errors) > 0
 }
 
+// Merge appends the violations of other, which may be nil
+func (m *MultiValidationError) Merge(other *MultiValidationError) {
+	if other != nil {
+		m.Errors = append(m.Errors, other.Errors...)
+	}
+}
+
+// AddError appends err, which may be nil
+func (m *MultiValidationError) AddError(err *ValidationError) {
+	if err != nil {
+		m.Errors = append(m.Errors, *err)
+	}
+}
+
+// BatchFailure records the error returned for a single sub-batch
+type BatchFailure struct {
+	Start int
+	End   int
+	Err   error
+}
+
+// BatchError aggregates the failures of an automatically split request
+type BatchError struct {
+	Failures []BatchFailure
+}
+
+// Error implements the error interface
+func (b *BatchError) Error() string {
+	if len(b.Failures) == 1 {
+		f := b.Failures[0]
+		return fmt.Sprintf("batch [%d:%d] failed: %v", f.Start, f.End, f.Err)
+	}
+	return fmt.Sprintf("%d batches failed, first [%d:%d]: %v",
+		len(b.Failures), b.Failures[0].Start, b.Failures[0].End, b.Failures[0].Err)
+}
+
+// Unwrap returns the underlying sub-batch errors
+func (b *BatchError) Unwrap() []error {
+	errs := make([]error, len(b.Failures))
+	for i, f := range b.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// Add appends a sub-batch failure
+func (b *BatchError) Add(start, end int, err error) {
+	b.Failures = append(b.Failures, BatchFailure{
+		Start: start,
+		End:   end,
+		Err:   err,
+	})
+}
+
+// HasErrors returns true if any sub-batch failed
+func (b *BatchError) HasErrors() bool {
+	return len(b.Failures) > 0
+}
+
+// StreamBatchError is the error of a streamed batch that ended the
+// stream, identified by the client ids of its first and last input
+type StreamBatchError struct {
+	FirstID string
+	LastID  string
+	Err     error
+}
+
+// Error implements the error interface
+func (s *StreamBatchError) Error() string {
+	return fmt.Sprintf("stream batch of ids %q to %q failed: %v", s.FirstID, s.LastID, s.Err)
+}
+
+// Unwrap returns the error of the batch
+func (s *StreamBatchError) Unwrap() error {
+	return s.Err
+}
+
 // NewTEIError creates a new TEI error
 func NewTEIError(message string, errorType ErrorType) *TEIError {
 	return &TEIError{
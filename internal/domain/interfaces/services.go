@@ -5,16 +5,26 @@ import (
 	"time"
 
 	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
 )
 
 type EmbeddingService interface {
 	Embed(ctx context.Context, req *entities.EmbedRequest) (*entities.EmbedResponse, error)
 	EmbedAll(ctx context.Context, req *entities.EmbedAllRequest) (*entities.EmbedAllResponse, error)
 	EmbedSparse(ctx context.Context, req *entities.EmbedSparseRequest) (*entities.EmbedSparseResponse, error)
+	EmbedPlainText(ctx context.Context, text string) ([]float32, error)
+	EmbedTokens(ctx context.Context, req *entities.EmbedTokensRequest) (*entities.EmbedResponse, error)
+	EmbedImage(ctx context.Context, req *entities.EmbedImageRequest) (*entities.EmbedResponse, error)
+	EmbedHybrid(ctx context.Context, req *entities.EmbedHybridRequest) (*entities.EmbedHybridResponse, error)
+	EmbedStream(ctx context.Context, req *entities.EmbedRequest, recv func() (*entities.StreamInput, error), send func([]entities.StreamResult) error) error
+	ValidateEmbed(req *entities.EmbedRequest) *errors.MultiValidationError
 }
 
 type SimilarityService interface {
 	CalculateSimilarity(ctx context.Context, req *entities.SimilarityRequest) (*entities.SimilarityResponse, error)
+	StreamSimilarity(ctx context.Context, req *entities.SimilarityRequest, topK, chunkSize int, send func(*entities.SimilarityProgress) error) error
+	NearestNeighbors(ctx context.Context, query string, corpus [][]float32, topK int) ([]entities.Neighbor, error)
+	ValidateSimilarity(req *entities.SimilarityRequest) *errors.MultiValidationError
 }
 
 type ClientService interface {
@@ -26,6 +36,7 @@ type HTTPClient interface {
 	Get(ctx context.Context, endpoint string) ([]byte, error)
 	Post(ctx context.Context, endpoint string, body any) ([]byte, error)
 	PostRaw(ctx context.Context, endpoint string, body []byte, contentType string) ([]byte, error)
+	// Deprecated: set a deadline on the request context instead.
 	SetTimeout(timeout time.Duration)
 	Close() error
 }
@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"container/list"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+)
+
+// Cache is a size-bounded LRU cache of embedding vectors. Every entry gets
+// its own expiry of TTL ± a random fraction of TTL so that entries written
+// together do not all expire at the same instant.
+type Cache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	maxEntries int
+	ttl        time.Duration
+	jitter     float64
+	rand       *rand.Rand
+	stop       chan struct{}
+	done       chan struct{}
+
+	hits        atomic.Int64
+	misses      atomic.Int64
+	evictions   atomic.Int64
+	expirations atomic.Int64
+}
+
+// Stats is a point-in-time snapshot of the cache counters. Evictions counts
+// live entries dropped to make room, so a steadily growing value means the
+// cache is too small; Expirations counts entries that outlived their TTL.
+type Stats struct {
+	Entries     int     `json:"entries"`
+	Hits        int64   `json:"hits"`
+	Misses      int64   `json:"misses"`
+	HitRate     float64 `json:"hit_rate"`
+	Evictions   int64   `json:"evictions"`
+	Expirations int64   `json:"expirations"`
+}
+
+type entry struct {
+	key       string
+	value     []float32
+	expiresAt time.Time
+}
+
+func New(cfg *config.CacheConfig) *Cache {
+	c := &Cache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: cfg.MaxEntries,
+		ttl:        cfg.TTL,
+		jitter:     cfg.TTLJitter,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	if cfg.SweepInterval > 0 {
+		c.stop = make(chan struct{})
+		c.done = make(chan struct{})
+		go c.sweepLoop(cfg.SweepInterval)
+	}
+
+	return c
+}
+
+// Get returns a copy of the cached vector for key. Expired entries are
+// removed on access.
+func (c *Cache) Get(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.recordMiss()
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	if c.expired(e) {
+		c.removeElement(elem)
+		c.recordExpirations(1)
+		c.recordMiss()
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	metrics.CacheHits.Inc()
+	return append([]float32(nil), e.value...), true
+}
+
+// Set stores a copy of value under key, evicting the least recently used
+// entry when the cache is full.
+func (c *Cache) Set(key string, value []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value = append([]float32(nil), value...)
+	expiresAt := c.expiry()
+
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+		c.evictions.Add(1)
+		metrics.CacheEvictions.Inc()
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet swept
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Sweep removes every expired entry and returns how many were removed
+func (c *Cache) Sweep() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if c.expired(elem.Value.(*entry)) {
+			c.removeElement(elem)
+			removed++
+		}
+		elem = prev
+	}
+
+	c.recordExpirations(removed)
+	return removed
+}
+
+// Stats returns the hits, misses, evictions and expirations counted since
+// the cache was created. HitRate is zero before the first lookup.
+func (c *Cache) Stats() Stats {
+	stats := Stats{
+		Entries:     c.Len(),
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+// Close stops the background sweeper
+func (c *Cache) Close() {
+	if c.stop == nil {
+		return
+	}
+
+	close(c.stop)
+	<-c.done
+	c.stop = nil
+}
+
+func (c *Cache) sweepLoop(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.Sweep()
+		}
+	}
+}
+
+func (c *Cache) expiry() time.Time {
+	if c.ttl <= 0 {
+		return time.Time{}
+	}
+
+	ttl := c.ttl
+	if c.jitter > 0 {
+		offset := (c.rand.Float64()*2 - 1) * c.jitter
+		ttl += time.Duration(float64(c.ttl) * offset)
+	}
+
+	return time.Now().Add(ttl)
+}
+
+func (c *Cache) expired(e *entry) bool {
+	return !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt)
+}
+
+func (c *Cache) recordMiss() {
+	c.misses.Add(1)
+	metrics.CacheMisses.Inc()
+}
+
+func (c *Cache) recordExpirations(n int) {
+	c.expirations.Add(int64(n))
+	metrics.CacheExpirations.Add(float64(n))
+}
+
+func (c *Cache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*entry).key)
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+)
+
+func TestExpiryWithinJitteredWindow(t *testing.T) {
+	const ttl = time.Hour
+	const jitter = 0.2
+	c := New(&config.CacheConfig{TTL: ttl, TTLJitter: jitter})
+
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		c.Set(fmt.Sprint(i), []float32{float32(i)})
+	}
+	end := time.Now()
+
+	earliest := start.Add(time.Duration(float64(ttl) * (1 - jitter)))
+	latest := end.Add(time.Duration(float64(ttl) * (1 + jitter)))
+	distinct := make(map[time.Time]bool)
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		expiresAt := elem.Value.(*entry).expiresAt
+		if expiresAt.Before(earliest) || expiresAt.After(latest) {
+			t.Fatalf("expiry %v is outside the jittered window [%v, %v]", expiresAt, earliest, latest)
+		}
+		distinct[expiresAt] = true
+	}
+	if len(distinct) < 100 {
+		t.Errorf("only %d distinct expiries among 200 entries, want them spread out", len(distinct))
+	}
+}
+
+func TestExpiredEntryRemovedOnAccess(t *testing.T) {
+	c := New(&config.CacheConfig{TTL: 10 * time.Millisecond})
+
+	c.Set("key", []float32{1, 2})
+	if _, ok := c.Get("key"); !ok {
+		t.Fatal("fresh entry missing")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("key"); ok {
+		t.Error("expired entry returned")
+	}
+	if got := c.Len(); got != 0 {
+		t.Errorf("Len() = %d after reading an expired entry, want 0", got)
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Expirations != 1 {
+		t.Errorf("stats = %+v, want 1 hit, 1 miss and 1 expiration", stats)
+	}
+}
+
+func TestSweeperReclaimsExpiredEntries(t *testing.T) {
+	c := New(&config.CacheConfig{TTL: 10 * time.Millisecond, SweepInterval: 5 * time.Millisecond})
+	defer c.Close()
+
+	for i := 0; i < 50; i++ {
+		c.Set(fmt.Sprint(i), []float32{float32(i)})
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for c.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("sweeper left %d expired entries", c.Len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := c.Stats().Expirations; got != 50 {
+		t.Errorf("expirations = %d, want 50", got)
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(&config.CacheConfig{MaxEntries: 2})
+
+	c.Set("a", []float32{1})
+	c.Set("b", []float32{2})
+	c.Get("a")
+	c.Set("c", []float32{3})
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("least recently used entry was kept")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("recently read entry was evicted")
+	}
+	if got := c.Stats().Evictions; got != 1 {
+		t.Errorf("evictions = %d, want 1", got)
+	}
+}
+
+func TestGetReturnsCopy(t *testing.T) {
+	c := New(&config.CacheConfig{})
+
+	c.Set("key", []float32{1, 2})
+	got, _ := c.Get("key")
+	got[0] = 99
+
+	if again, _ := c.Get("key"); again[0] != 1 {
+		t.Errorf("cached vector changed to %v through a returned copy", again)
+	}
+}
@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx carrying fields in addition to any it
+// already carries. Loggers obtained with FromContext include them, so every
+// line logged for a request can be correlated.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	existing, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	combined := make([]zap.Field, 0, len(existing)+len(fields))
+	combined = append(combined, existing...)
+	combined = append(combined, fields...)
+	return context.WithValue(ctx, fieldsKey{}, combined)
+}
+
+// FromContext returns base with the fields carried by ctx attached
+func FromContext(ctx context.Context, base *zap.Logger) *zap.Logger {
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	if len(fields) == 0 {
+		return base
+	}
+	return base.With(fields...)
+}
@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// encodeLine encodes an entry with message msg and fields, returning the
+// line without its ending
+func encodeLine(t *testing.T, enc zapcore.Encoder, msg string, fields ...zap.Field) string {
+	t.Helper()
+
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: msg}, fields)
+	if err != nil {
+		t.Fatalf("EncodeEntry: %v", err)
+	}
+	defer buf.Free()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func testEncoder() *logfmtEncoder {
+	return newLogfmtEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+}
+
+func TestLogfmtQuoting(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "ready", `v=ready`},
+		{"space", "two words", `v="two words"`},
+		{"equals", "a=b", `v="a=b"`},
+		{"quote", `say "hi"`, `v="say \"hi\""`},
+		{"empty", "", `v=""`},
+		{"newline", "a\nb", `v="a\nb"`},
+	} {
+		if got := encodeLine(t, testEncoder(), "m", zap.String("v", tt.value)); got != "msg=m "+tt.want {
+			t.Errorf("%s: line = %s, want msg=m %s", tt.name, got, tt.want)
+		}
+	}
+
+	if got := encodeLine(t, testEncoder(), "hello world"); got != `msg="hello world"` {
+		t.Errorf("line = %s, want the message quoted", got)
+	}
+}
+
+func TestLogfmtSanitizesKeys(t *testing.T) {
+	got := encodeLine(t, testEncoder(), "m",
+		zap.String("bad key", "1"),
+		zap.String("a=b", "2"),
+		zap.String(`"q"`, "3"),
+		zap.String("", "4"),
+	)
+	if want := `msg=m bad_key=1 a_b=2 _q_=3 _=4`; got != want {
+		t.Errorf("line = %s, want %s", got, want)
+	}
+}
+
+func TestLogfmtNamespaces(t *testing.T) {
+	got := encodeLine(t, testEncoder(), "m",
+		zap.Int("top", 1),
+		zap.Namespace("req"),
+		zap.Int("id", 2),
+		zap.Namespace("my ns"),
+		zap.Bool("ok", true),
+	)
+	if want := `msg=m top=1 req.id=2 req.my_ns.ok=true`; got != want {
+		t.Errorf("line = %s, want %s", got, want)
+	}
+}
+
+func TestLogfmtContextFields(t *testing.T) {
+	var out bytes.Buffer
+	core := zapcore.NewCore(testEncoder(), zapcore.AddSync(&out), zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	scoped := logger.With(zap.String("service", "tei client"), zap.Namespace("req"), zap.Int("id", 7))
+	scoped.Info("first", zap.Int("n", 1))
+	logger.Info("second", zap.Int("n", 2))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		`msg=first service="tei client" req.id=7 req.n=1`,
+		`msg=second n=2`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), out.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %s, want %s", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestLogfmtCloneIsIndependent(t *testing.T) {
+	enc := testEncoder()
+	enc.AddString("shared", "1")
+
+	clone := enc.Clone()
+	clone.AddString("extra", "2")
+
+	if got, want := encodeLine(t, enc, "m"), `msg=m shared=1`; got != want {
+		t.Errorf("original line = %s, want %s", got, want)
+	}
+	if got, want := encodeLine(t, clone, "m"), `msg=m shared=1 extra=2`; got != want {
+		t.Errorf("clone line = %s, want %s", got, want)
+	}
+}
+
+func TestLogfmtNestedValuesAsJSON(t *testing.T) {
+	obj := zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddInt("n", 1)
+		enc.AddString("s", "x y")
+		return nil
+	})
+
+	got := encodeLine(t, testEncoder(), "m",
+		zap.Object("obj", obj),
+		zap.Ints("ints", []int{1, 2}),
+		zap.Any("map", map[string]int{"a": 1}),
+	)
+	want := `msg=m obj="{\"n\":1,\"s\":\"x y\"}" ints=[1,2] map="{\"a\":1}"`
+	if got != want {
+		t.Errorf("line = %s, want %s", got, want)
+	}
+}
+
+func TestLogfmtSpecialFloats(t *testing.T) {
+	got := encodeLine(t, testEncoder(), "m",
+		zap.Float64("nan", math.NaN()),
+		zap.Float64("pos", math.Inf(1)),
+		zap.Float32("neg", float32(math.Inf(-1))),
+		zap.Float64("x", 1.5),
+	)
+	if want := `msg=m nan=NaN pos=+Inf neg=-Inf x=1.5`; got != want {
+		t.Errorf("line = %s, want %s", got, want)
+	}
+}
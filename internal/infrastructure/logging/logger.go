@@ -7,6 +7,7 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type LogLevel string
@@ -23,6 +24,7 @@ type LogFormat string
 const (
 	JSONFormat    LogFormat = "json"
 	ConsoleFormat LogFormat = "console"
+	LogfmtFormat  LogFormat = "logfmt"
 )
 
 type Logger struct {
@@ -48,10 +50,15 @@ func NewLogger(cfg *config.LogConfig) (*Logger, error) {
 	}
 
 	var config zap.Config
-	if format == ConsoleFormat {
+	switch format {
+	case ConsoleFormat:
 		config = zap.NewDevelopmentConfig()
 		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	} else {
+	case LogfmtFormat:
+		config = zap.NewProductionConfig()
+		config.Encoding = string(LogfmtFormat)
+		config.EncoderConfig.TimeKey = "ts"
+	default:
 		config = zap.NewProductionConfig()
 		config.EncoderConfig.TimeKey = "timestamp"
 		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
@@ -59,7 +66,23 @@ func NewLogger(cfg *config.LogConfig) (*Logger, error) {
 
 	config.Level = zap.NewAtomicLevelAt(zapLevel)
 
-	logger, err := config.Build()
+	// Sampling is applied below so that errors can bypass it
+	config.Sampling = nil
+
+	var opts []zap.Option
+	if cfg.OutputPath != "" {
+		fileCore := newFileCore(cfg, config)
+		opts = append(opts, zap.WrapCore(func(zapcore.Core) zapcore.Core {
+			return fileCore
+		}))
+	}
+	if cfg.SamplingInitial > 0 {
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newSampledCore(core, cfg.SamplingInitial, cfg.SamplingThereafter)
+		}))
+	}
+
+	logger, err := config.Build(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build logger: %w", err)
 	}
@@ -67,6 +90,33 @@ func NewLogger(cfg *config.LogConfig) (*Logger, error) {
 	return &Logger{Logger: logger}, nil
 }
 
+// newFileCore writes entries to cfg.OutputPath, rotating the file by size
+// and pruning old files by age and count
+func newFileCore(cfg *config.LogConfig, zapConfig zap.Config) zapcore.Core {
+	writer := &lumberjack.Logger{
+		Filename:   cfg.OutputPath,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     cfg.MaxAgeDays,
+		MaxBackups: cfg.MaxBackups,
+		Compress:   cfg.Compress,
+	}
+
+	encoderConfig := zapConfig.EncoderConfig
+	var encoder zapcore.Encoder
+	switch LogFormat(cfg.Format) {
+	case ConsoleFormat:
+		// Color codes only make sense on a terminal
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	case LogfmtFormat:
+		encoder = newLogfmtEncoder(encoderConfig)
+	default:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	}
+
+	return zapcore.NewCore(encoder, zapcore.AddSync(writer), zapConfig.Level)
+}
+
 func (l *Logger) WithField(key string, value any) *Logger {
 	return &Logger{Logger: l.Logger.With(zap.Any(key, value))}
 }
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// samplingTick is the interval over which sampling counts are kept
+const samplingTick = time.Second
+
+// errorBypassCore samples entries below error level and passes errors and
+// above straight through, so failures are never dropped
+type errorBypassCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+}
+
+// newSampledCore logs the first initial entries with the same level and
+// message each second, then every thereafter-th one. Errors are not sampled.
+func newSampledCore(core zapcore.Core, initial, thereafter int) zapcore.Core {
+	return &errorBypassCore{
+		Core:    core,
+		sampled: zapcore.NewSamplerWithOptions(core, samplingTick, initial, thereafter),
+	}
+}
+
+func (c *errorBypassCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorBypassCore{
+		Core:    c.Core.With(fields),
+		sampled: c.sampled.With(fields),
+	}
+}
+
+func (c *errorBypassCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= zapcore.ErrorLevel {
+		return c.Core.Check(ent, ce)
+	}
+	return c.sampled.Check(ent, ce)
+}
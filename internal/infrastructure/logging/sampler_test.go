@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampledCoreKeepsEveryError(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newSampledCore(observed, 5, 100)).With(zap.String("component", "test"))
+
+	// Both floods fall within one sampling tick
+	for i := 0; i < 200; i++ {
+		logger.Info("repeated info")
+		logger.Error("repeated error")
+	}
+
+	infos := logs.FilterMessage("repeated info").Len()
+	if infos < 5 || infos > 20 {
+		t.Errorf("kept %d of 200 info entries, want the first 5 and then every 100th", infos)
+	}
+	if errors := logs.FilterMessage("repeated error").Len(); errors != 200 {
+		t.Errorf("kept %d of 200 error entries, want all of them", errors)
+	}
+	for _, entry := range logs.FilterMessage("repeated error").All() {
+		if entry.ContextMap()["component"] != "test" {
+			t.Fatalf("error entry lost its context fields: %v", entry.ContextMap())
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const namespace = "tei_client"
+
+// Registry holds every collector exported by the service
+var Registry = prometheus.NewRegistry()
+
+var (
+	RPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC requests handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC request latency, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	APIKeyRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "api_key_requests_total",
+		Help:      "Authenticated gRPC requests, by API key name or hash prefix and whether the key's rate limit admitted them.",
+	}, []string{"key", "outcome"})
+
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tei",
+		Name:      "requests_total",
+		Help:      "Requests to TEI, counting retries once, by endpoint.",
+	}, []string{"endpoint"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tei",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of individual request attempts to TEI until the response headers arrive, excluding the wait for a tei.max_in_flight slot, by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	HTTPPhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tei",
+		Name:      "request_phase_duration_seconds",
+		Help:      "Duration of DNS lookup, connect, TLS handshake and time to first byte of TEI request attempts, by endpoint and phase. Only recorded when tei.trace_phases is enabled.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint", "phase"})
+
+	HTTPRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tei",
+		Name:      "retries_total",
+		Help:      "Retried requests to TEI, by endpoint.",
+	}, []string{"endpoint"})
+
+	HTTPFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tei",
+		Name:      "failures_total",
+		Help:      "Requests to TEI that failed after all retries, by endpoint and error type.",
+	}, []string{"endpoint", "error_type"})
+
+	BatchBacklog = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "embedding",
+		Name:      "batch_backlog",
+		Help:      "Number of sub-batches waiting to be sent to TEI.",
+	})
+
+	BatchWorkersActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "embedding",
+		Name:      "batch_workers_active",
+		Help:      "Number of sub-batch requests currently in flight to TEI.",
+	})
+
+	CoalescedPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "embedding",
+		Name:      "coalesced_pending",
+		Help:      "Number of embed requests waiting on a coalesced call to TEI, including the one that started it.",
+	})
+
+	NormViolations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "embedding",
+		Name:      "norm_violations_total",
+		Help:      "Vectors returned for normalized requests whose L2 norm was not 1.",
+	})
+
+	TruncatedInputs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "embedding",
+		Name:      "truncated_inputs_total",
+		Help:      "Inputs longer than the model's maximum input length that TEI truncated.",
+	})
+
+	CacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "hits_total",
+		Help:      "Embedding cache lookups that found a live entry.",
+	})
+
+	CacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "misses_total",
+		Help:      "Embedding cache lookups that found no entry or an expired one.",
+	})
+
+	CacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "evictions_total",
+		Help:      "Live embedding cache entries evicted because the cache was full.",
+	})
+
+	CacheExpirations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "expirations_total",
+		Help:      "Embedding cache entries removed after their TTL.",
+	})
+
+	DegradedResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "embedding",
+		Name:      "degraded_responses_total",
+		Help:      "Embeddings served in degraded mode during a TEI outage, by source.",
+	}, []string{"source"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RPCRequests,
+		RPCDuration,
+		APIKeyRequests,
+		HTTPRequests,
+		HTTPDuration,
+		HTTPPhaseDuration,
+		HTTPRetries,
+		HTTPFailures,
+		BatchBacklog,
+		BatchWorkersActive,
+		CoalescedPending,
+		NormViolations,
+		TruncatedInputs,
+		CacheHits,
+		CacheMisses,
+		CacheEvictions,
+		CacheExpirations,
+		DegradedResponses,
+	)
+}
+
+// RegisterQueueDepth exports the number of RPCs waiting for a concurrency
+// slot, as reported by depth
+func RegisterQueueDepth(depth func() float64) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "queued_requests",
+		Help:      "RPCs waiting for a concurrency slot.",
+	}, depth))
+}
+
+// EmbeddingStats is a snapshot of the embedding batch and coalescing gauges
+type EmbeddingStats struct {
+	BatchBacklog       int64 `json:"batch_backlog"`
+	BatchWorkersActive int64 `json:"batch_workers_active"`
+	CoalescedPending   int64 `json:"coalesced_pending"`
+}
+
+// CurrentEmbeddingStats reads the embedding gauges
+func CurrentEmbeddingStats() EmbeddingStats {
+	return EmbeddingStats{
+		BatchBacklog:       gaugeValue(BatchBacklog),
+		BatchWorkersActive: gaugeValue(BatchWorkersActive),
+		CoalescedPending:   gaugeValue(CoalescedPending),
+	}
+}
+
+func gaugeValue(gauge prometheus.Gauge) int64 {
+	var m dto.Metric
+	if err := gauge.Write(&m); err != nil {
+		return 0
+	}
+	return int64(m.GetGauge().GetValue())
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	stderrors "errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrLimitReached is returned when every slot is taken and there is no
+	// queue to wait in
+	ErrLimitReached = stderrors.New("concurrency limit reached")
+
+	// ErrQueueFull is returned when every slot is taken and the queue is
+	// full
+	ErrQueueFull = stderrors.New("request queue is full")
+
+	// ErrQueueTimeout is returned when no slot freed up within the queue
+	// timeout
+	ErrQueueTimeout = stderrors.New("timed out waiting in the request queue")
+)
+
+// Limiter bounds the calls in progress at once. Calls over the limit wait
+// in a queue of bounded depth for up to a timeout, or fail straight away
+// when the queue depth is zero.
+type Limiter struct {
+	slots   chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+	queued  atomic.Int64
+}
+
+// NewLimiter allows limit calls at once, with up to queueDepth more waiting
+// at most timeout for a slot. timeout must be positive when queueDepth is.
+func NewLimiter(limit, queueDepth int, timeout time.Duration) *Limiter {
+	l := &Limiter{
+		slots:   make(chan struct{}, limit),
+		timeout: timeout,
+	}
+	if queueDepth > 0 {
+		l.queue = make(chan struct{}, queueDepth)
+	}
+	return l
+}
+
+// Acquire takes a slot, queueing for one if necessary. It returns
+// ErrLimitReached, ErrQueueFull, ErrQueueTimeout or the context's error
+// when no slot was taken. Every successful Acquire must be paired with
+// Release.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if l.queue == nil {
+		return ErrLimitReached
+	}
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return ErrQueueFull
+	}
+	l.queued.Add(1)
+	defer func() {
+		<-l.queue
+		l.queued.Add(-1)
+	}()
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return ErrQueueTimeout
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (l *Limiter) Release() {
+	<-l.slots
+}
+
+// Queued returns how many calls are waiting for a slot
+func (l *Limiter) Queued() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.queued.Load()
+}
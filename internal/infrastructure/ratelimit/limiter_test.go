@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+)
+
+// waitForQueued waits until n calls are queued on l
+func waitForQueued(t *testing.T, l *Limiter, n int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for l.Queued() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued = %d, want %d", l.Queued(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimiterWithoutQueueRejects(t *testing.T) {
+	l := NewLimiter(1, 0, time.Second)
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	if err := l.Acquire(context.Background()); !stderrors.Is(err, ErrLimitReached) {
+		t.Errorf("err = %v, want ErrLimitReached", err)
+	}
+}
+
+func TestLimiterQueuedCallGetsReleasedSlot(t *testing.T) {
+	l := NewLimiter(1, 1, time.Second)
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.Acquire(context.Background()) }()
+	waitForQueued(t, l, 1)
+	l.Release()
+
+	if err := <-done; err != nil {
+		t.Errorf("queued Acquire: %v", err)
+	}
+	if got := l.Queued(); got != 0 {
+		t.Errorf("queued = %d after the slot was taken, want 0", got)
+	}
+}
+
+func TestLimiterQueueFull(t *testing.T) {
+	l := NewLimiter(1, 1, time.Second)
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Acquire(ctx) }()
+	waitForQueued(t, l, 1)
+
+	if err := l.Acquire(context.Background()); !stderrors.Is(err, ErrQueueFull) {
+		t.Errorf("err = %v, want ErrQueueFull", err)
+	}
+
+	cancel()
+	<-done
+	if got := l.Queued(); got != 0 {
+		t.Errorf("queued = %d, want 0", got)
+	}
+}
+
+func TestLimiterQueueTimeout(t *testing.T) {
+	l := NewLimiter(1, 1, 20*time.Millisecond)
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	if err := l.Acquire(context.Background()); !stderrors.Is(err, ErrQueueTimeout) {
+		t.Errorf("err = %v, want ErrQueueTimeout", err)
+	}
+	if got := l.Queued(); got != 0 {
+		t.Errorf("queued = %d after the timeout, want 0", got)
+	}
+}
+
+func TestLimiterCanceledWhileQueued(t *testing.T) {
+	l := NewLimiter(1, 2, time.Minute)
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Acquire(ctx) }()
+	waitForQueued(t, l, 1)
+	cancel()
+
+	if err := <-done; !stderrors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if got := l.Queued(); got != 0 {
+		t.Errorf("queued = %d after cancellation, want 0", got)
+	}
+
+	// The canceled call neither took the slot nor kept its queue place
+	l.Release()
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Errorf("Acquire after release: %v", err)
+	}
+}
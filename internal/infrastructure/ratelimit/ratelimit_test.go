@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketAllowsBurstThenWaits(t *testing.T) {
+	b := New(10, 3)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := b.Allow(); !ok {
+			t.Fatalf("call %d rejected within the burst", i+1)
+		}
+	}
+
+	ok, wait := b.Allow()
+	if ok {
+		t.Fatal("call past the burst allowed")
+	}
+	if wait <= 0 || wait > 100*time.Millisecond {
+		t.Errorf("wait = %v, want up to one token interval of 100ms", wait)
+	}
+}
+
+func TestBucketRefillsUpToBurst(t *testing.T) {
+	b := New(10, 3)
+	for i := 0; i < 3; i++ {
+		b.Allow()
+	}
+
+	// 150ms at 10 tokens per second refills one and a half tokens
+	b.last = b.last.Add(-150 * time.Millisecond)
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("call rejected after a token was refilled")
+	}
+	if ok, wait := b.Allow(); ok || wait <= 0 || wait > 50*time.Millisecond {
+		t.Errorf("Allow = %v, %v; want a rejection due within 50ms", ok, wait)
+	}
+
+	// A long idle spell refills no more than the burst
+	b.last = b.last.Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := b.Allow(); !ok {
+			t.Fatalf("call %d rejected after the bucket refilled", i+1)
+		}
+	}
+	if ok, _ := b.Allow(); ok {
+		t.Error("bucket refilled past its burst")
+	}
+}
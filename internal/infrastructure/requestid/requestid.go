@@ -0,0 +1,28 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// MetadataKey is the gRPC metadata key carrying the request ID
+const MetadataKey = "x-request-id"
+
+type contextKey struct{}
+
+// New generates a new random request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or "" if there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,133 @@
+package wrapper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type LoadBalancing string
+
+const (
+	RoundRobin   LoadBalancing = "round_robin"
+	LeastPending LoadBalancing = "least_pending"
+)
+
+// backend is a single TEI replica
+type backend struct {
+	baseURL *url.URL
+
+	// pending counts in-flight attempts for least_pending balancing
+	pending atomic.Int64
+
+	// downUntil is the UnixNano time until which the replica is skipped
+	// after an outage error
+	downUntil atomic.Int64
+}
+
+// resolve returns the URL of endpoint on this replica, preserving any path
+// prefix of the base URL
+func (b *backend) resolve(endpoint string) *url.URL {
+	target := *b.baseURL
+	target.Path = b.baseURL.Path + endpoint
+	return &target
+}
+
+func (b *backend) available(now time.Time) bool {
+	return b.downUntil.Load() <= now.UnixNano()
+}
+
+// balancer spreads attempts across TEI replicas, passing over replicas that
+// recently failed with a network or unhealthy error
+type balancer struct {
+	backends []*backend
+	strategy LoadBalancing
+	cooldown time.Duration
+	next     atomic.Uint64
+}
+
+func newBalancer(baseURLs []string, strategy LoadBalancing, cooldown time.Duration) (*balancer, error) {
+	backends := make([]*backend, 0, len(baseURLs))
+	for _, baseURL := range baseURLs {
+		parsedURL, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+		}
+		backends = append(backends, &backend{baseURL: parsedURL})
+	}
+
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("at least one base URL is required")
+	}
+
+	if strategy == "" {
+		strategy = RoundRobin
+	}
+
+	return &balancer{
+		backends: backends,
+		strategy: strategy,
+		cooldown: cooldown,
+	}, nil
+}
+
+// pick chooses the replica for the next attempt, avoiding previous (the
+// replica that served the failed attempt, if any) whenever another
+// available replica exists. When every replica is cooling down it falls back
+// to ignoring their state so requests still go somewhere.
+func (b *balancer) pick(previous *backend) *backend {
+	now := time.Now()
+
+	candidates := make([]*backend, 0, len(b.backends))
+	for _, backend := range b.backends {
+		if backend != previous && backend.available(now) {
+			candidates = append(candidates, backend)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.backends
+	}
+
+	if b.strategy == LeastPending {
+		best := candidates[0]
+		for _, backend := range candidates[1:] {
+			if backend.pending.Load() < best.pending.Load() {
+				best = backend
+			}
+		}
+		return best
+	}
+
+	return candidates[b.next.Add(1)%uint64(len(candidates))]
+}
+
+// canFailOver reports whether a replica other than from is available
+func (b *balancer) canFailOver(from *backend) bool {
+	now := time.Now()
+	for _, backend := range b.backends {
+		if backend != from && backend.available(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// markDown takes a replica out of rotation for the cooldown period. With a
+// single replica there is nothing to fail over to, so it is left alone.
+func (b *balancer) markDown(backend *backend) {
+	if len(b.backends) < 2 || b.cooldown <= 0 {
+		return
+	}
+	backend.downUntil.Store(time.Now().Add(b.cooldown).UnixNano())
+}
+
+func (b *balancer) hasScheme(scheme string) bool {
+	for _, backend := range b.backends {
+		if backend.baseURL.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+)
+
+func TestFailoverOnUnhealthyReplica(t *testing.T) {
+	down, downRequests := countingServer(t, http.StatusServiceUnavailable, `{"error":"unhealthy","error_type":"unhealthy"}`)
+	up, upRequests := countingServer(t, http.StatusOK, `[[0.1,0.2]]`)
+
+	client := newTestClient(t, config.TEIConfig{
+		MaxRetries:       1,
+		FailoverCooldown: time.Minute,
+	}, down.URL, up.URL)
+
+	// Whichever replica round robin starts on, every call must succeed and
+	// the unhealthy replica must be tried at most once before its cooldown
+	for i := 0; i < 4; i++ {
+		if _, err := client.Post(context.Background(), entities.EndpointEmbed, &entities.EmbedRequest{
+			Inputs: entities.Input{Data: []string{"hello"}},
+		}); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+
+	if got := downRequests.Load(); got > 1 {
+		t.Errorf("unhealthy replica got %d requests, want at most 1", got)
+	}
+	if got := upRequests.Load(); got != 4 {
+		t.Errorf("healthy replica got %d requests, want 4", got)
+	}
+}
+
+func TestNoRetryOnUnhealthySingleReplica(t *testing.T) {
+	down, requests := countingServer(t, http.StatusServiceUnavailable, `{"error":"unhealthy","error_type":"unhealthy"}`)
+
+	client := newTestClient(t, config.TEIConfig{
+		MaxRetries:       3,
+		FailoverCooldown: time.Minute,
+	}, down.URL)
+
+	if _, err := client.Post(context.Background(), entities.EndpointEmbed, &entities.EmbedRequest{
+		Inputs: entities.Input{Data: []string{"hello"}},
+	}); err == nil {
+		t.Fatal("expected an error from an unhealthy replica")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1: there is no other replica to fail over to", got)
+	}
+}
@@ -2,90 +2,223 @@ package wrapper
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
-	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/blackprince001/embedding-inference/internal/config"
 	"github.com/blackprince001/embedding-inference/internal/domain/entities"
 	"github.com/blackprince001/embedding-inference/internal/domain/errors"
 	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
-
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/requestid"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/tlsutil"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type Client struct {
 	httpClient *http.Client
-	baseURL    string
-	timeout    time.Duration
+	transport  *http.Transport
+	balancer   *balancer
 	maxRetries int
 	retryDelay time.Duration
 	logger     *logging.Logger
 	userAgent  string
+
+	// timeout holds the default time.Duration bounding each attempt; it is
+	// atomic because SetTimeout may race with requests
+	timeout atomic.Int64
+
+	// endpointTimeouts overrides timeout per endpoint path
+	endpointTimeouts map[string]time.Duration
+
+	// apiVersion is the TEI release requests are adapted to; nil targets
+	// the latest API
+	apiVersion atomic.Pointer[apiVersion]
+
+	counters counters
+
+	compressRequests     bool
+	compressionThreshold int
+
+	maxResponseBytes int64
+	maxRequestBytes  int
+
+	tracePhases bool
+
+	// inFlight holds a token per outstanding TEI request when
+	// tei.max_in_flight is set; nil means unlimited
+	inFlight chan struct{}
+
+	// fake answers every request locally when tei.mode is fake
+	fake *fakeBackend
 }
 
-func NewHTTPClient(cfg *config.TEIConfig, logger *logging.Logger) (*Client, error) {
-	parsedURL, err := url.Parse(cfg.BaseURL)
+// Option customizes a Client built by NewHTTPClient
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	wrapTransport func(http.RoundTripper) http.RoundTripper
+}
+
+// WithRoundTripper wraps the default transport, e.g. to add tracing,
+// record/replay requests in tests or inject headers. wrap receives the
+// configured transport, already wrapped to recycle connections older than
+// tei.max_connection_age when that is set, and returns the RoundTripper the
+// client should use.
+func WithRoundTripper(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(o *clientOptions) {
+		o.wrapTransport = wrap
+	}
+}
+
+func NewHTTPClient(cfg *config.TEIConfig, clientCfg *config.ClientConfig, logger *logging.Logger, opts ...Option) (*Client, error) {
+	var options clientOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	balancer, err := newBalancer(cfg.Endpoints(), LoadBalancing(cfg.LoadBalancing), cfg.FailoverCooldown)
 	if err != nil {
-		return nil, fmt.Errorf("invalid base URL: %w", err)
+		return nil, err
+	}
+
+	tlsConfig, err := tlsutil.ClientConfig(cfg.CAFile, cfg.ClientCertFile, cfg.ClientKeyFile, cfg.InsecureSkipVerify)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TEI TLS configuration: %w", err)
+	}
+	if cfg.InsecureSkipVerify {
+		logger.Warn("TLS certificate verification for TEI is disabled")
+	}
+
+	maxIdleConnsPerHost := cfg.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost == 0 {
+		maxIdleConnsPerHost = cfg.MaxConnections
 	}
 
 	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
 		DialContext: (&net.Dialer{
 			Timeout:   10 * time.Second,
 			KeepAlive: 30 * time.Second,
 		}).DialContext,
 		MaxIdleConns:        cfg.MaxConnections,
-		MaxIdleConnsPerHost: cfg.MaxConnections,
-		IdleConnTimeout:     90 * time.Second,
-		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+		TLSHandshakeTimeout: cfg.TLSHandshakeTimeout,
 		DisableKeepAlives:   false,
 		DisableCompression:  false,
 	}
 
+	if cfg.HTTP2 {
+		// HTTP/2 multiplexes requests over a single connection per host, so
+		// MaxConnections effectively bounds connections, not concurrency
+		transport.Protocols = new(http.Protocols)
+		if balancer.hasScheme("http") {
+			transport.Protocols.SetUnencryptedHTTP2(true)
+		}
+		if balancer.hasScheme("https") {
+			transport.Protocols.SetHTTP1(true)
+			transport.Protocols.SetHTTP2(true)
+			transport.ForceAttemptHTTP2 = true
+		}
+	}
+
+	// No client-wide Timeout: each request is bounded by its context
+	// deadline, or by the configured timeout when the context has none
+	var roundTripper http.RoundTripper = transport
+	if cfg.MaxConnectionAge > 0 {
+		transport.DialContext = dialWithAge(transport.DialContext)
+		roundTripper = &connAgeRoundTripper{
+			next:   transport,
+			maxAge: cfg.MaxConnectionAge,
+			h2c:    cfg.HTTP2 && balancer.hasScheme("http"),
+		}
+	}
+	if options.wrapTransport != nil {
+		roundTripper = options.wrapTransport(roundTripper)
+	}
+
 	httpClient := &http.Client{
-		Transport: transport,
-		Timeout:   cfg.Timeout,
+		Transport: roundTripper,
 	}
 
-	return &Client{
+	client := &Client{
 		httpClient: httpClient,
-		baseURL:    strings.TrimSuffix(parsedURL.String(), "/"),
-		timeout:    cfg.Timeout,
+		transport:  transport,
+		balancer:   balancer,
 		maxRetries: cfg.MaxRetries,
 		retryDelay: cfg.RetryDelay,
 		logger:     logger,
-	}, nil
+		userAgent:  userAgent(clientCfg),
+
+		compressRequests:     cfg.CompressRequests,
+		compressionThreshold: cfg.CompressionThreshold,
+		maxResponseBytes:     cfg.MaxResponseBytes,
+		maxRequestBytes:      cfg.MaxRequestBytes,
+		tracePhases:          cfg.TracePhases,
+	}
+	client.timeout.Store(int64(cfg.Timeout))
+	client.endpointTimeouts = make(map[string]time.Duration, len(cfg.EndpointTimeouts))
+	for endpoint, timeout := range cfg.EndpointTimeouts {
+		client.endpointTimeouts["/"+strings.TrimPrefix(endpoint, "/")] = timeout
+	}
+	if cfg.MaxInFlight > 0 {
+		client.inFlight = make(chan struct{}, cfg.MaxInFlight)
+	}
+	if cfg.Mode == ModeFake {
+		client.fake = &fakeBackend{dimension: cfg.FakeDimension}
+		logger.Warn("TEI client is in fake mode: embeddings are generated locally and are not real",
+			zap.Int("dimension", cfg.FakeDimension),
+		)
+	}
+	if cfg.APIVersion != APIVersionAuto {
+		if err := client.SetAPIVersion(cfg.APIVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	return client, nil
 }
 
 func (c *Client) Get(ctx context.Context, endpoint string) ([]byte, error) {
-	url := c.baseURL + endpoint
-
 	c.logger.Debug("GET request",
-		zap.String("url", url),
+		zap.String("endpoint", endpoint),
 	)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if c.fake != nil {
+		return c.fake.respond(endpoint, nil, "")
+	}
+
+	// The request targets endpoint alone; the replica is chosen per attempt
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	c.setDefaultHeaders(req)
 
-	return c.executeWithRetry(ctx, req)
+	return c.executeWithRetry(ctx, req, 0)
 }
 
 func (c *Client) Post(ctx context.Context, endpoint string, body any) ([]byte, error) {
-	url := c.baseURL + endpoint
-
 	c.logger.Debug("POST request",
-		zap.String("url", url),
+		zap.String("endpoint", endpoint),
 		zap.String("body_type", fmt.Sprintf("%T", body)),
 	)
 
@@ -93,117 +226,279 @@ func (c *Client) Post(ctx context.Context, endpoint string, body any) ([]byte, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
+	jsonBody = c.adaptBody(endpoint, jsonBody)
+	if err := c.checkRequestSize(jsonBody); err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if c.fake != nil {
+		return c.fake.respond(endpoint, jsonBody, entities.ContentTypeJSON)
 	}
 
-	c.setDefaultHeaders(req)
-	req.Header.Set(entities.HeaderContentType, entities.ContentTypeJSON)
+	req, err := c.newPostRequest(ctx, endpoint, jsonBody, entities.ContentTypeJSON)
+	if err != nil {
+		return nil, err
+	}
 
-	return c.executeWithRetry(ctx, req)
+	return c.executeWithRetry(ctx, req, batchSize(body))
 }
 
 func (c *Client) PostRaw(ctx context.Context, endpoint string, body []byte, contentType string) ([]byte, error) {
-	url := c.baseURL + endpoint
-
 	c.logger.Debug("POST raw request",
-		zap.String("url", url),
+		zap.String("endpoint", endpoint),
 		zap.String("content_type", contentType),
 		zap.Int("body_size", len(body)),
 	)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err := c.checkRequestSize(body); err != nil {
+		return nil, err
+	}
+
+	if c.fake != nil {
+		return c.fake.respond(endpoint, body, contentType)
+	}
+
+	req, err := c.newPostRequest(ctx, endpoint, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	// A text/plain body is a single input
+	size := 0
+	if contentType == entities.ContentTypeTextPlain {
+		size = 1
+	}
+
+	return c.executeWithRetry(ctx, req, size)
+}
+
+// checkRequestSize rejects a body over tei.max_request_bytes before it is
+// sent, rather than letting TEI answer 413
+func (c *Client) checkRequestSize(body []byte) error {
+	if c.maxRequestBytes > 0 && len(body) > c.maxRequestBytes {
+		return errors.NewValidationError("inputs", "request body exceeds maximum size", map[string]any{
+			"bytes":     len(body),
+			"max_bytes": c.maxRequestBytes,
+		})
+	}
+	return nil
+}
+
+// batchSize returns the number of inputs in a request body, or zero when
+// the body is not an input-carrying request
+func batchSize(body any) int {
+	switch req := body.(type) {
+	case *entities.EmbedRequest:
+		return len(req.Inputs.Data)
+	case *entities.EmbedAllRequest:
+		return len(req.Inputs.Data)
+	case *entities.EmbedSparseRequest:
+		return len(req.Inputs.Data)
+	case *entities.EmbedTokensRequest:
+		return len(req.Inputs)
+	case *entities.EmbedImageRequest:
+		return len(req.Images)
+	case *entities.TokenizeRequest:
+		return len(req.Inputs.Data)
+	case *entities.SimilarityRequest:
+		return len(req.Inputs.Sentences)
+	default:
+		return 0
+	}
+}
+
+// newPostRequest builds a POST request, gzipping the body when compression
+// is enabled and the body exceeds the configured threshold
+func (c *Client) newPostRequest(ctx context.Context, endpoint string, body []byte, contentType string) (*http.Request, error) {
+	compressed := false
+	if c.compressRequests && len(body) > c.compressionThreshold {
+		gzipped, err := gzipBody(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress request body: %w", err)
+		}
+		c.logger.Debug("Compressed request body",
+			zap.Int("original_size", len(body)),
+			zap.Int("compressed_size", len(gzipped)),
+		)
+		body = gzipped
+		compressed = true
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	c.setDefaultHeaders(req)
 	req.Header.Set(entities.HeaderContentType, contentType)
+	if compressed {
+		req.Header.Set(entities.HeaderEncoding, entities.EncodingGzip)
+	}
 
-	return c.executeWithRetry(ctx, req)
+	return req, nil
 }
 
+// userAgent identifies this client to TEI as name/version
+func userAgent(cfg *config.ClientConfig) string {
+	if cfg.Version == "" {
+		return cfg.Name
+	}
+	return cfg.Name + "/" + cfg.Version
+}
+
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SetTimeout changes the default timeout bounding each request attempt.
+//
+// Deprecated: it affects every caller of the client. Set a deadline on the
+// request context instead.
 func (c *Client) SetTimeout(timeout time.Duration) {
-	c.timeout = timeout
-	c.httpClient.Timeout = timeout
+	c.timeout.Store(int64(timeout))
 	c.logger.Debug("HTTP client timeout updated", zap.Duration("timeout", timeout))
 }
 
 func (c *Client) Close() error {
 	c.logger.Debug("Closing HTTP client")
-	c.httpClient.CloseIdleConnections()
+	// Close through the transport directly: a wrapping RoundTripper need
+	// not forward CloseIdleConnections
+	c.transport.CloseIdleConnections()
 
 	return nil
 }
 
 func (c *Client) setDefaultHeaders(req *http.Request) {
-	req.Header.Set(entities.HeaderUserAgent, c.userAgent)
+	if c.userAgent != "" {
+		req.Header.Set(entities.HeaderUserAgent, c.userAgent)
+	}
 	req.Header.Set(entities.HeaderAccept, entities.ContentTypeJSON)
+	if id := requestid.FromContext(req.Context()); id != "" {
+		req.Header.Set(entities.HeaderRequestID, id)
+	}
 }
 
-func (c *Client) executeWithRetry(ctx context.Context, req *http.Request) ([]byte, error) {
+// executeWithRetry sends req, retrying retryable failures. size is the
+// number of inputs the request carries, used for logging only.
+func (c *Client) executeWithRetry(ctx context.Context, req *http.Request, size int) ([]byte, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TEI "+req.Method+" "+req.URL.Path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("tei.endpoint", req.URL.Path),
+		),
+	)
+	defer span.End()
+	logger := logging.FromContext(ctx, c.logger.Logger)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	// A request whose context is already done is never sent
+	if err := ctx.Err(); err != nil {
+		return nil, c.wrapNetworkError(err)
+	}
+
+	c.counters.requests.Add(1)
+	metrics.HTTPRequests.WithLabelValues(req.URL.Path).Inc()
+
+	maxRetries := c.maxRetriesFor(ctx, req)
+
 	var lastErr error
+	var backend *backend
+	retries := 0
 
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
+			delay, ok := retryDelayWithin(ctx, c.calculateRetryDelay(attempt))
+			if !ok {
+				logger.Debug("Skipping retry, context deadline too close",
+					zap.Int("attempt", attempt),
+				)
+				break
+			}
+
 			select {
 			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(c.calculateRetryDelay(attempt)):
+				return nil, c.wrapNetworkError(ctx.Err())
+			case <-time.After(delay):
 			}
+		}
+
+		span.SetAttributes(attribute.Int("tei.retry_count", attempt))
+		retries = attempt
+
+		// Retries prefer a different replica than the one that just failed
+		backend = c.balancer.pick(backend)
 
-			c.logger.Debug("Retrying request",
+		if attempt > 0 {
+			logger.Debug("Retrying request",
 				zap.Int("attempt", attempt),
-				zap.String("url", req.URL.String()),
+				zap.String("url", backend.resolve(req.URL.Path).String()),
+				zap.String("request_id", req.Header.Get(entities.HeaderRequestID)),
 			)
+			c.counters.retries.Add(1)
+			metrics.HTTPRetries.WithLabelValues(req.URL.Path).Inc()
 		}
 
-		if req.Body != nil {
-			body, err := io.ReadAll(req.Body)
+		// The previous attempt consumed the body; rewind it from the
+		// buffered (possibly compressed) bytes
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
 			if err != nil {
-				return nil, fmt.Errorf("failed to read request body: %w", err)
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
 			}
-			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.Body = body
 		}
 
-		resp, err := c.httpClient.Do(req)
+		responseBody, resp, latency, err := c.do(ctx, req, backend)
+		if latency > 0 {
+			metrics.HTTPDuration.WithLabelValues(req.URL.Path).Observe(latency.Seconds())
+		}
 		if err != nil {
 			lastErr = c.wrapNetworkError(err)
+			c.markIfOutage(backend, lastErr)
 
-			if teiErr, ok := lastErr.(*errors.TEIError); ok && teiErr.IsRetryable() {
-				c.logger.Warn("Request failed, will retry",
+			if c.shouldRetry(backend, lastErr) {
+				logger.Warn("Request failed, will retry",
 					zap.Error(err),
 					zap.Int("attempt", attempt),
 				)
 				continue
 			}
 
+			c.recordFailure(ctx, req, lastErr)
 			return nil, lastErr
 		}
 
-		responseBody, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if err != nil {
-			lastErr = fmt.Errorf("failed to read response body: %w", err)
-			continue
-		}
+		span.SetAttributes(
+			attribute.Int("http.response.status_code", resp.StatusCode),
+			attribute.String("server.address", backend.baseURL.Host),
+		)
 
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			c.logger.Debug("Request completed successfully",
-				zap.String("url", req.URL.String()),
+			logger.Info("Request completed successfully",
+				zap.String("endpoint", req.URL.Path),
+				zap.String("url", resp.Request.URL.String()),
+				zap.Float64("tei_latency_ms", float64(latency)/float64(time.Millisecond)),
+				zap.Int("batch_size", size),
 				zap.Int("status_code", resp.StatusCode),
 				zap.Int("response_size", len(responseBody)),
 				zap.Int("attempt", attempt+1),
 			)
 			return responseBody, nil
 		}
-		lastErr = c.handleErrorResponse(resp.StatusCode, responseBody)
+		lastErr = c.handleErrorResponse(resp, responseBody)
+		c.markIfOutage(backend, lastErr)
 
-		if teiErr, ok := lastErr.(*errors.TEIError); ok && teiErr.IsRetryable() {
-			c.logger.Warn("Request failed with retryable error",
+		if c.shouldRetry(backend, lastErr) {
+			logger.Warn("Request failed with retryable error",
 				zap.Error(lastErr),
 				zap.Int("status_code", resp.StatusCode),
 				zap.Int("attempt", attempt),
@@ -211,19 +506,125 @@ func (c *Client) executeWithRetry(ctx context.Context, req *http.Request) ([]byt
 			continue
 		}
 
+		c.recordFailure(ctx, req, lastErr)
 		return nil, lastErr
 	}
 
-	c.logger.Error("Request failed after all retries",
+	c.recordFailure(ctx, req, lastErr)
+	logger.Error("Request failed after all retries",
 		zap.Error(lastErr),
-		zap.String("url", req.URL.String()),
-		zap.Int("max_retries", c.maxRetries),
+		zap.String("url", backend.resolve(req.URL.Path).String()),
+		zap.String("request_id", req.Header.Get(entities.HeaderRequestID)),
+		zap.Int("retries", retries),
 	)
 
-	return nil, fmt.Errorf("request failed after %d retries: %w", c.maxRetries, lastErr)
+	return nil, fmt.Errorf("request failed after %d retries: %w", retries, lastErr)
 }
 
-func (c *Client) handleErrorResponse(statusCode int, body []byte) error {
+// do performs a single attempt against backend and reads the response body.
+// The attempt is bounded by the endpoint's timeout, or the client's default
+// one, so that a hung attempt leaves time for a retry; a shorter context
+// deadline still wins. The returned latency covers only the round trip to
+// TEI, not the wait for an in-flight slot or the body read, and is zero
+// when the request was never sent.
+func (c *Client) do(ctx context.Context, req *http.Request, backend *backend) ([]byte, *http.Response, time.Duration, error) {
+	timeout := time.Duration(c.timeout.Load())
+	if endpointTimeout, ok := c.endpointTimeouts[req.URL.Path]; ok {
+		timeout = endpointTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if c.inFlight != nil {
+		select {
+		case c.inFlight <- struct{}{}:
+			defer func() { <-c.inFlight }()
+		case <-ctx.Done():
+			return nil, nil, 0, ctx.Err()
+		}
+	}
+	c.counters.inFlight.Add(1)
+	defer c.counters.inFlight.Add(-1)
+
+	var phases *requestPhases
+	if c.tracePhases {
+		ctx, phases = withPhaseTrace(ctx)
+	}
+
+	req = req.WithContext(ctx)
+	req.URL = backend.resolve(req.URL.Path)
+
+	backend.pending.Add(1)
+	defer backend.pending.Add(-1)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return nil, nil, latency, err
+	}
+	if phases != nil {
+		phases.report(ctx, c.logger.Logger, req.URL.Path)
+	}
+	defer resp.Body.Close()
+
+	reader := resp.Body
+	if c.maxResponseBytes > 0 {
+		// Read one byte past the limit to tell a full body from a cut one
+		reader = io.NopCloser(io.LimitReader(resp.Body, c.maxResponseBytes+1))
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, latency, err
+	}
+
+	if c.maxResponseBytes > 0 && int64(len(body)) > c.maxResponseBytes {
+		return nil, nil, latency, errors.NewTEIError(
+			fmt.Sprintf("response body exceeds %d bytes", c.maxResponseBytes),
+			errors.ErrorTypeBackend)
+	}
+
+	return body, resp, latency, nil
+}
+
+// markIfOutage takes backend out of rotation when err shows the replica is
+// down rather than the request being bad
+func (c *Client) markIfOutage(backend *backend, err error) {
+	if teiErr, ok := err.(*errors.TEIError); ok && teiErr.IsOutage() {
+		c.balancer.markDown(backend)
+	}
+}
+
+// shouldRetry reports whether err is worth another attempt: a retryable
+// error, or an outage of backend when another replica can take the retry
+func (c *Client) shouldRetry(backend *backend, err error) bool {
+	teiErr, ok := err.(*errors.TEIError)
+	if !ok {
+		return false
+	}
+	return teiErr.IsRetryable() || (teiErr.IsOutage() && c.balancer.canFailOver(backend))
+}
+
+func (c *Client) recordFailure(ctx context.Context, req *http.Request, err error) {
+	errorType := errors.ErrorTypeUnknown
+	if teiErr, ok := err.(*errors.TEIError); ok {
+		errorType = teiErr.Type
+	}
+	c.counters.addFailure(errorType)
+	metrics.HTTPFailures.WithLabelValues(req.URL.Path, string(errorType)).Inc()
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, string(errorType))
+}
+
+func (c *Client) handleErrorResponse(resp *http.Response, body []byte) error {
+	statusCode := resp.StatusCode
+
 	c.logger.Debug("Handling error response",
 		zap.Int("status_code", statusCode),
 		zap.Int("body_size", len(body)),
@@ -253,7 +654,15 @@ func (c *Client) handleErrorResponse(statusCode int, body []byte) error {
 		}
 	}
 
-	return errors.NewTEIErrorFromHTTP(statusCode, message)
+	teiErr := errors.NewTEIErrorFromHTTP(statusCode, message)
+
+	// Prefer the ID echoed by TEI, falling back to the one we sent
+	teiErr.RequestID = resp.Header.Get(entities.HeaderRequestID)
+	if teiErr.RequestID == "" {
+		teiErr.RequestID = resp.Request.Header.Get(entities.HeaderRequestID)
+	}
+
+	return teiErr
 }
 
 func (c *Client) wrapNetworkError(err error) error {
@@ -261,20 +670,26 @@ func (c *Client) wrapNetworkError(err error) error {
 		return nil
 	}
 
-	if netErr, ok := err.(net.Error); ok {
-		if netErr.Timeout() {
-			return errors.NewTEIError(err.Error(), errors.ErrorTypeTimeout)
-		}
+	if teiErr, ok := err.(*errors.TEIError); ok {
+		return teiErr
 	}
 
-	if err == context.Canceled {
-		return errors.NewTEIError("request canceled", errors.ErrorTypeTimeout)
+	// Check the context errors first: the client reports both wrapped in a
+	// *url.Error, which also claims to be a net.Error timeout
+	if stderrors.Is(err, context.Canceled) {
+		return errors.NewTEIError("request canceled", errors.ErrorTypeCanceled)
 	}
 
-	if err == context.DeadlineExceeded {
+	if stderrors.Is(err, context.DeadlineExceeded) {
 		return errors.NewTEIError("request timeout", errors.ErrorTypeTimeout)
 	}
 
+	if netErr, ok := err.(net.Error); ok {
+		if netErr.Timeout() {
+			return errors.NewTEIError(err.Error(), errors.ErrorTypeTimeout)
+		}
+	}
+
 	if strings.Contains(err.Error(), "connection refused") ||
 		strings.Contains(err.Error(), "no such host") ||
 		strings.Contains(err.Error(), "network is unreachable") {
@@ -284,6 +699,27 @@ func (c *Client) wrapNetworkError(err error) error {
 	return errors.NewTEIError(err.Error(), errors.ErrorTypeNetwork)
 }
 
+// minAttemptTime is the least time left before the context deadline for a
+// retry to be worth sending
+const minAttemptTime = 50 * time.Millisecond
+
+// retryDelayWithin clamps delay so that a retry started after it still has
+// minAttemptTime before the context deadline. It returns false when there
+// is not enough time left for another attempt.
+func retryDelayWithin(ctx context.Context, delay time.Duration) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return delay, true
+	}
+
+	available := time.Until(deadline) - minAttemptTime
+	if available <= 0 {
+		return 0, false
+	}
+
+	return min(delay, available), true
+}
+
 func (c *Client) calculateRetryDelay(attempt int) time.Duration {
 	baseDelay := c.retryDelay
 	exponentialDelay := time.Duration(1<<uint(attempt-1)) * baseDelay
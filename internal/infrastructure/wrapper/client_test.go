@@ -0,0 +1,273 @@
+package wrapper
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// newTestClient builds a Client against the given TEI base URLs with fast
+// retries
+func newTestClient(t testing.TB, cfg config.TEIConfig, baseURLs ...string) *Client {
+	t.Helper()
+
+	cfg.BaseURL = baseURLs[0]
+	if len(baseURLs) > 1 {
+		cfg.BaseURLs = baseURLs
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = time.Millisecond
+	}
+	if cfg.MaxConnections == 0 {
+		cfg.MaxConnections = 10
+	}
+
+	client, err := NewHTTPClient(&cfg, &config.ClientConfig{Name: "test", Version: "0.0.0"},
+		&logging.Logger{Logger: zap.NewNop()})
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	return client
+}
+
+// countingServer answers every request with status and body, counting the
+// requests it receives
+func countingServer(t *testing.T, status int, body string) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func embedBody() *entities.EmbedRequest {
+	return &entities.EmbedRequest{Inputs: entities.Input{Data: []string{"hello"}}}
+}
+
+// errorType returns the TEIError type of err, or "" if it is not one
+func errorType(err error) errors.ErrorType {
+	var teiErr *errors.TEIError
+	if stderrors.As(err, &teiErr) {
+		return teiErr.Type
+	}
+	return ""
+}
+
+func TestPreCanceledContextSendsNothing(t *testing.T) {
+	server, requests := countingServer(t, http.StatusOK, `[[0.1,0.2]]`)
+	client := newTestClient(t, config.TEIConfig{MaxRetries: 3}, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	_, err := client.Post(ctx, entities.EndpointEmbed, embedBody())
+
+	if got := errorType(err); got != errors.ErrorTypeCanceled {
+		t.Errorf("error type = %q, want %q (err: %v)", got, errors.ErrorTypeCanceled, err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("TEI received %d requests for a canceled context, want 0", got)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("canceled request took %v, want an immediate return", elapsed)
+	}
+}
+
+func TestCancelDuringRetryBackoff(t *testing.T) {
+	server, requests := countingServer(t, http.StatusTooManyRequests, `{"error":"overloaded","error_type":"overloaded"}`)
+	client := newTestClient(t, config.TEIConfig{MaxRetries: 3, RetryDelay: time.Minute}, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.Post(ctx, entities.EndpointEmbed, embedBody())
+
+	if got := errorType(err); got != errors.ErrorTypeCanceled {
+		t.Errorf("error type = %q, want %q (err: %v)", got, errors.ErrorTypeCanceled, err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("TEI received %d requests, want 1 before the backoff was canceled", got)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("canceled backoff took %v, want it cut short", elapsed)
+	}
+}
+
+func TestRetryableErrorIsRetried(t *testing.T) {
+	server, requests := countingServer(t, http.StatusTooManyRequests, `{"error":"overloaded","error_type":"overloaded"}`)
+	client := newTestClient(t, config.TEIConfig{MaxRetries: 2}, server.URL)
+
+	_, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody())
+
+	if got := errorType(err); got != errors.ErrorTypeOverloaded {
+		t.Errorf("error type = %q, want %q (err: %v)", got, errors.ErrorTypeOverloaded, err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("TEI received %d requests, want 3: the first and two retries", got)
+	}
+}
+
+func TestNonRetryableErrorIsNotRetried(t *testing.T) {
+	server, requests := countingServer(t, http.StatusUnprocessableEntity, `{"error":"too long","error_type":"tokenizer"}`)
+	client := newTestClient(t, config.TEIConfig{MaxRetries: 3}, server.URL)
+
+	_, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody())
+
+	if got := errorType(err); got != errors.ErrorTypeTokenizer {
+		t.Errorf("error type = %q, want %q (err: %v)", got, errors.ErrorTypeTokenizer, err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("TEI received %d requests, want 1", got)
+	}
+}
+
+func TestSetTimeoutConcurrentWithRequests(t *testing.T) {
+	server, _ := countingServer(t, http.StatusOK, `[[0.1,0.2]]`)
+	client := newTestClient(t, config.TEIConfig{}, server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody()); err != nil {
+				t.Errorf("Post: %v", err)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			client.SetTimeout(time.Duration(i+1) * time.Second)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestEndpointTimeoutCutsAttemptWithinCallerDeadline(t *testing.T) {
+	// The first attempt hangs until it is cut; the retry is answered
+	var requests atomic.Int64
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			<-release
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[[0.1,0.2]]`))
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := newTestClient(t, config.TEIConfig{
+		MaxRetries:       1,
+		EndpointTimeouts: map[string]time.Duration{entities.EndpointEmbed: 50 * time.Millisecond},
+	}, server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.Post(ctx, entities.EndpointEmbed, embedBody()); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %v, want the hung attempt cut at the 50ms endpoint timeout", elapsed)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server received %d requests, want the hung attempt and its retry", got)
+	}
+}
+
+func TestShorterCallerDeadlineWins(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := newTestClient(t, config.TEIConfig{Timeout: time.Minute}, server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Post(ctx, entities.EndpointEmbed, embedBody())
+	if got := errorType(err); got != errors.ErrorTypeTimeout {
+		t.Errorf("error type = %q, want %q (err: %v)", got, errors.ErrorTypeTimeout, err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %v, want it cut at the 50ms caller deadline", elapsed)
+	}
+}
+
+func TestLatencyExcludesInFlightWait(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[[0.1,0.2]]`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := newTestClient(t, config.TEIConfig{MaxInFlight: 1}, server.URL)
+
+	// The slow request holds the only in-flight slot for 200ms, so the
+	// queued one waits for it before being sent
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Post(context.Background(), "/slow", embedBody())
+		done <- err
+	}()
+	<-started
+	time.AfterFunc(200*time.Millisecond, func() { close(release) })
+
+	start := time.Now()
+	if _, err := client.Post(context.Background(), "/queued", embedBody()); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	elapsed := time.Since(start)
+	if err := <-done; err != nil {
+		t.Fatalf("slow Post: %v", err)
+	}
+
+	var m dto.Metric
+	if err := metrics.HTTPDuration.WithLabelValues("/queued").(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := m.GetHistogram().GetSampleCount(); got != 1 {
+		t.Fatalf("recorded %d latencies, want 1", got)
+	}
+	if got := time.Duration(m.GetHistogram().GetSampleSum() * float64(time.Second)); got >= elapsed/2 {
+		t.Errorf("recorded latency %v of a %v request, want the wait for the in-flight slot left out", got, elapsed)
+	}
+}
@@ -0,0 +1,112 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// APIVersionAuto asks for the TEI version to be detected from /info
+const APIVersionAuto = "auto"
+
+// apiVersion is a TEI release, compared by major and minor version
+type apiVersion struct {
+	major, minor int
+}
+
+func (v apiVersion) before(other apiVersion) bool {
+	return v.major < other.major || (v.major == other.major && v.minor < other.minor)
+}
+
+func (v apiVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// parseAPIVersion reads versions such as "1.2", "v1.5.0" or "1.7.1-dev"
+func parseAPIVersion(s string) (apiVersion, error) {
+	parts := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".", 3)
+	if len(parts) < 2 {
+		return apiVersion{}, fmt.Errorf("invalid TEI version %q", s)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return apiVersion{}, fmt.Errorf("invalid TEI version %q", s)
+	}
+	minor, err := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return apiVersion{}, fmt.Errorf("invalid TEI version %q", s)
+	}
+
+	return apiVersion{major: major, minor: minor}, nil
+}
+
+// requestFieldVersions lists optional request fields by the TEI release
+// that introduced them. Older servers reject them with a 422.
+var requestFieldVersions = []struct {
+	field   string
+	version apiVersion
+}{
+	{field: "prompt_name", version: apiVersion{1, 3}},
+	{field: "truncation_direction", version: apiVersion{1, 3}},
+	{field: "dimensions", version: apiVersion{1, 7}},
+}
+
+// SetAPIVersion adapts request payloads to the given TEI release, dropping
+// fields it does not support. An empty version targets the latest API. It
+// should be called before the client handles requests.
+func (c *Client) SetAPIVersion(version string) error {
+	if version == "" {
+		c.apiVersion.Store(nil)
+		return nil
+	}
+
+	parsed, err := parseAPIVersion(version)
+	if err != nil {
+		return err
+	}
+	c.apiVersion.Store(&parsed)
+	c.logger.Info("Targeting TEI API version", zap.String("version", parsed.String()))
+	return nil
+}
+
+// adaptBody removes the fields of a JSON object body that the targeted TEI
+// release does not support. Bodies that are not objects, or that hold no
+// such fields, are returned unchanged.
+func (c *Client) adaptBody(endpoint string, body []byte) []byte {
+	version := c.apiVersion.Load()
+	if version == nil {
+		return body
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+
+	var stripped []string
+	for _, field := range requestFieldVersions {
+		if _, ok := fields[field.field]; ok && version.before(field.version) {
+			delete(fields, field.field)
+			stripped = append(stripped, field.field)
+		}
+	}
+	if len(stripped) == 0 {
+		return body
+	}
+
+	adapted, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+
+	c.logger.Debug("Removed fields unsupported by the TEI version",
+		zap.String("endpoint", endpoint),
+		zap.String("version", version.String()),
+		zap.Strings("fields", stripped),
+	)
+	return adapted
+}
@@ -0,0 +1,71 @@
+package wrapper
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// agedConn records when a connection was dialed
+type agedConn struct {
+	net.Conn
+	created time.Time
+}
+
+// dialWithAge wraps dial so that every connection records its creation time
+func dialWithAge(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &agedConn{Conn: conn, created: time.Now()}, nil
+	}
+}
+
+// connAgeRoundTripper retires connections older than maxAge before they are
+// reused, so the request dials again and re-resolves the TEI host name. This
+// keeps pooled connections from pinning to an address a rescheduled backend
+// no longer uses. An expired connection is closed as soon as the transport
+// hands it out and before anything is written to it, which the transport
+// treats as a dead idle connection: it retries the request on a fresh one,
+// so the caller never sees an error. Only HTTP/1.1 connections are recycled:
+// an HTTP/2 connection is shared by concurrent streams, which closing it
+// would fail.
+type connAgeRoundTripper struct {
+	next   http.RoundTripper
+	maxAge time.Duration
+
+	// h2c is set when plaintext connections speak HTTP/2, which leaves
+	// only TLS connections that negotiated HTTP/1.1 to recycle
+	h2c bool
+}
+
+func (t *connAgeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused && t.expired(info.Conn) {
+				info.Conn.Close()
+			}
+		},
+	}
+
+	ctx := httptrace.WithClientTrace(req.Context(), trace)
+	return t.next.RoundTrip(req.WithContext(ctx))
+}
+
+func (t *connAgeRoundTripper) expired(conn net.Conn) bool {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if tlsConn.ConnectionState().NegotiatedProtocol == "h2" {
+			return false
+		}
+		conn = tlsConn.NetConn()
+	} else if t.h2c {
+		return false
+	}
+	aged, ok := conn.(*agedConn)
+	return ok && time.Since(aged.created) > t.maxAge
+}
@@ -0,0 +1,157 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+)
+
+// dialCountingServer answers /embed and counts the connections dialed to it
+func dialCountingServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var dials atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[[0.1,0.2]]`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, &dials
+}
+
+func TestExpiredConnectionIsRedialedBeforeReuse(t *testing.T) {
+	server, dials := dialCountingServer(t)
+	client := newTestClient(t, config.TEIConfig{MaxConnectionAge: 20 * time.Millisecond}, server.URL)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody()); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		time.Sleep(40 * time.Millisecond)
+	}
+
+	if got := dials.Load(); got != 3 {
+		t.Errorf("server saw %d connections, want a fresh one per request past the maximum age", got)
+	}
+}
+
+func TestConnectionAgeNeverFailsRequests(t *testing.T) {
+	server, _ := dialCountingServer(t)
+	// No retries: an expired connection handed to a request must not
+	// surface as an error, and so can never mark the replica down
+	client := newTestClient(t, config.TEIConfig{MaxConnectionAge: time.Millisecond}, server.URL)
+	client.maxRetries = 0
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody()); err != nil {
+					t.Errorf("Post: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConnectionAgeLeavesHTTP2ConnectionsOpen(t *testing.T) {
+	tests := []struct {
+		name string
+		tls  bool
+	}{
+		{"h2c", false},
+		{"ALPN h2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dials atomic.Int64
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.ProtoMajor != 2 {
+					http.Error(w, "want HTTP/2", http.StatusHTTPVersionNotSupported)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`[[0.1,0.2]]`))
+			}))
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					dials.Add(1)
+				}
+			}
+			cfg := config.TEIConfig{HTTP2: true, MaxConnectionAge: time.Millisecond}
+			if tt.tls {
+				server.EnableHTTP2 = true
+				server.StartTLS()
+				cfg.CAFile = writeServerCA(t, server)
+			} else {
+				server.Config.Protocols = new(http.Protocols)
+				server.Config.Protocols.SetUnencryptedHTTP2(true)
+				server.Start()
+			}
+			t.Cleanup(server.Close)
+
+			client := newTestClient(t, cfg, server.URL)
+			client.maxRetries = 0
+
+			// One request first, so the concurrent ones share its connection
+			if _, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody()); err != nil {
+				t.Fatalf("Post: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			for worker := 0; worker < 8; worker++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 25; i++ {
+						if _, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody()); err != nil {
+							t.Errorf("Post: %v", err)
+							return
+						}
+						time.Sleep(time.Millisecond)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := dials.Load(); got != 1 {
+				t.Errorf("server saw %d connections, want the one multiplexed HTTP/2 connection kept", got)
+			}
+		})
+	}
+}
+
+// writeServerCA writes the certificate of a TLS test server to a PEM file
+// for use as the client's CA
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write CA: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,241 @@
+package wrapper
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+)
+
+const (
+	// ModeLive sends requests to TEI
+	ModeLive = "live"
+
+	// ModeFake answers requests locally with deterministic fake embeddings
+	ModeFake = "fake"
+)
+
+// fakeBackend stands in for TEI in fake mode. Every input embeds to a unit
+// vector seeded by a hash of its text, so the same input always gets the
+// same vector and similarity scores are reproducible. Inputs are split into
+// one token per whitespace-separated word: /embed_all returns the vector of
+// each word and /embed_sparse weighs each word's token id by its count.
+type fakeBackend struct {
+	dimension int
+}
+
+func (f *fakeBackend) respond(endpoint string, body []byte, contentType string) ([]byte, error) {
+	switch endpoint {
+	case entities.EndpointHealth:
+		return nil, nil
+	case entities.EndpointInfo:
+		return json.Marshal(entities.ModelInfo{
+			ModelID:               "fake",
+			ModelDType:            "float32",
+			MaxConcurrentRequests: 512,
+			MaxInputLength:        512,
+			MaxBatchTokens:        16384,
+			MaxClientBatchSize:    32,
+		})
+	case entities.EndpointEmbed:
+		if contentType == entities.ContentTypeTextPlain {
+			return json.Marshal([][]float32{f.embed(string(body), f.dimension)})
+		}
+		return f.embedJSON(body)
+	case entities.EndpointEmbedAll:
+		return f.embedAll(body)
+	case entities.EndpointEmbedSparse:
+		return f.embedSparse(body)
+	case entities.EndpointSimilarity:
+		return f.similarity(body)
+	case entities.EndpointTokenize:
+		return f.tokenize(body)
+	default:
+		return nil, errors.NewTEIError(fmt.Sprintf("%s is not supported in fake mode", endpoint), errors.ErrorTypeBackend)
+	}
+}
+
+func (f *fakeBackend) embedJSON(body []byte) ([]byte, error) {
+	var req struct {
+		Dimensions *int `json:"dimensions"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, errors.NewTEIError(fmt.Sprintf("invalid request body: %v", err), errors.ErrorTypeValidation)
+	}
+	texts, err := fakeInputs(body)
+	if err != nil {
+		return nil, err
+	}
+
+	dimension := f.dimension
+	if req.Dimensions != nil && *req.Dimensions > 0 && *req.Dimensions < dimension {
+		dimension = *req.Dimensions
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = f.embed(text, dimension)
+	}
+	return json.Marshal(embeddings)
+}
+
+// embedAll returns the vector of every word of each input
+func (f *fakeBackend) embedAll(body []byte) ([]byte, error) {
+	texts, err := fakeInputs(body)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][][]float32, len(texts))
+	for i, text := range texts {
+		tokens := fakeTokens(text)
+		embeddings[i] = make([][]float32, len(tokens))
+		for j, token := range tokens {
+			embeddings[i][j] = f.embed(token.Text, f.dimension)
+		}
+	}
+	return json.Marshal(embeddings)
+}
+
+// embedSparse weighs the token id of every word of each input by how often
+// the word occurs, ordered by index
+func (f *fakeBackend) embedSparse(body []byte) ([]byte, error) {
+	texts, err := fakeInputs(body)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]entities.SparseValue, len(texts))
+	for i, text := range texts {
+		weights := make(map[int]float32)
+		for _, token := range fakeTokens(text) {
+			weights[token.ID]++
+		}
+		embeddings[i] = make([]entities.SparseValue, 0, len(weights))
+		for index, value := range weights {
+			embeddings[i] = append(embeddings[i], entities.SparseValue{Index: index, Value: value})
+		}
+		sort.Slice(embeddings[i], func(a, b int) bool { return embeddings[i][a].Index < embeddings[i][b].Index })
+	}
+	return json.Marshal(embeddings)
+}
+
+// fakeInputs returns the inputs of an embed request body, which are a
+// string, a list of strings or a list of token id lists
+func fakeInputs(body []byte) ([]string, error) {
+	var req struct {
+		Inputs json.RawMessage `json:"inputs"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, errors.NewTEIError(fmt.Sprintf("invalid request body: %v", err), errors.ErrorTypeValidation)
+	}
+
+	items := []json.RawMessage{req.Inputs}
+	if err := json.Unmarshal(req.Inputs, &items); err != nil {
+		items = []json.RawMessage{req.Inputs}
+	}
+
+	texts := make([]string, len(items))
+	for i, item := range items {
+		if err := json.Unmarshal(item, &texts[i]); err != nil {
+			texts[i] = string(item)
+		}
+	}
+	return texts, nil
+}
+
+func (f *fakeBackend) similarity(body []byte) ([]byte, error) {
+	var req entities.SimilarityRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, errors.NewTEIError(fmt.Sprintf("invalid request body: %v", err), errors.ErrorTypeValidation)
+	}
+
+	source := f.embed(req.Inputs.SourceSentence, f.dimension)
+	scores := make([]float32, len(req.Inputs.Sentences))
+	for i, sentence := range req.Inputs.Sentences {
+		var dot float32
+		for j, value := range f.embed(sentence, f.dimension) {
+			dot += value * source[j]
+		}
+		scores[i] = dot
+	}
+	return json.Marshal(scores)
+}
+
+// tokenize splits each input at whitespace, one token per word
+func (f *fakeBackend) tokenize(body []byte) ([]byte, error) {
+	var req struct {
+		Inputs entities.Input `json:"inputs"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, errors.NewTEIError(fmt.Sprintf("invalid request body: %v", err), errors.ErrorTypeValidation)
+	}
+
+	tokens := make([][]entities.Token, len(req.Inputs.Data))
+	for i, text := range req.Inputs.Data {
+		tokens[i] = fakeTokens(text)
+	}
+	return json.Marshal(tokens)
+}
+
+// fakeTokens splits text at whitespace, one token per word
+func fakeTokens(text string) []entities.Token {
+	tokens := []entities.Token{}
+	start := -1
+	for pos := 0; pos <= len(text); {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if pos == len(text) || unicode.IsSpace(r) {
+			if start >= 0 {
+				tokens = append(tokens, fakeToken(text, start, pos))
+				start = -1
+			}
+			if pos == len(text) {
+				break
+			}
+		} else if start < 0 {
+			start = pos
+		}
+		pos += size
+	}
+	return tokens
+}
+
+func fakeToken(text string, start, stop int) entities.Token {
+	hash := fnv.New32a()
+	hash.Write([]byte(text[start:stop]))
+	return entities.Token{
+		ID:    int(hash.Sum32() % 30000),
+		Text:  text[start:stop],
+		Start: &start,
+		Stop:  &stop,
+	}
+}
+
+// embed returns the unit vector of text
+func (f *fakeBackend) embed(text string, dimension int) []float32 {
+	sum := sha256.Sum256([]byte(text))
+	rng := rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(sum[:8]))))
+
+	vector := make([]float32, dimension)
+	var norm float64
+	for i := range vector {
+		value := rng.NormFloat64()
+		vector[i] = float32(value)
+		norm += value * value
+	}
+
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+	return vector
+}
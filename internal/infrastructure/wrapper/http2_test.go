@@ -0,0 +1,85 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+)
+
+// protocolServer speaks HTTP/1.1 and h2c, answering each /embed request
+// with the HTTP major version it arrived on
+func protocolServer(t testing.TB) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.ProtoMajor == 2 {
+			_, _ = w.Write([]byte(`[[2]]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[[1]]`))
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTP2Protocol(t *testing.T) {
+	tests := []struct {
+		name  string
+		http2 bool
+		want  string
+	}{
+		{"default HTTP/1.1", false, "[[1]]"},
+		{"h2c with http2 enabled", true, "[[2]]"},
+	}
+
+	server := protocolServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, config.TEIConfig{HTTP2: tt.http2}, server.URL)
+
+			data, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody())
+			if err != nil {
+				t.Fatalf("Post: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("served as %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+// These compare the two over loopback only, where the HTTP/1.1 pool is
+// faster since new connections cost next to nothing. They say nothing
+// about HTTP/2 over a real network.
+func BenchmarkPostHTTP1(b *testing.B) {
+	benchmarkPost(b, false)
+}
+
+func BenchmarkPostHTTP2(b *testing.B) {
+	benchmarkPost(b, true)
+}
+
+func benchmarkPost(b *testing.B, http2 bool) {
+	server := protocolServer(b)
+	client := newTestClient(b, config.TEIConfig{HTTP2: http2}, server.URL)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := client.Post(context.Background(), entities.EndpointEmbed, embedBody()); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
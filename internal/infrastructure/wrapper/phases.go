@@ -0,0 +1,85 @@
+package wrapper
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+
+	"go.uber.org/zap"
+)
+
+// requestPhases records when each stage of a TEI request attempt finished,
+// as observed through httptrace
+type requestPhases struct {
+	start time.Time
+
+	// mu guards the fields below, since httptrace hooks may run on other
+	// goroutines and, for a dial that lost to a reused connection, after
+	// the request has completed
+	mu                      sync.Mutex
+	dnsStart, dnsDone       time.Time
+	connectStart, connected time.Time
+	tlsStart, tlsDone       time.Time
+	firstByte               time.Time
+	reused                  bool
+}
+
+// withPhaseTrace returns ctx instrumented to fill in the returned phases
+func withPhaseTrace(ctx context.Context) (context.Context, *requestPhases) {
+	phases := &requestPhases{start: time.Now()}
+	mark := func(t *time.Time) {
+		phases.mu.Lock()
+		*t = time.Now()
+		phases.mu.Unlock()
+	}
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			phases.mu.Lock()
+			phases.reused = info.Reused
+			phases.mu.Unlock()
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&phases.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { mark(&phases.dnsDone) },
+		ConnectStart:         func(string, string) { mark(&phases.connectStart) },
+		ConnectDone:          func(string, string, error) { mark(&phases.connected) },
+		TLSHandshakeStart:    func() { mark(&phases.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { mark(&phases.tlsDone) },
+		GotFirstResponseByte: func() { mark(&phases.firstByte) },
+	}
+	return httptrace.WithClientTrace(ctx, trace), phases
+}
+
+// report logs the phase durations at debug level and records them in the
+// phase histogram. Phases that did not happen, such as DNS and connect on
+// a reused connection, are left out.
+func (p *requestPhases) report(ctx context.Context, logger *zap.Logger, endpoint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fields := []zap.Field{
+		zap.String("endpoint", endpoint),
+		zap.Bool("conn_reused", p.reused),
+	}
+
+	observe := func(phase string, start, end time.Time) {
+		if start.IsZero() || end.IsZero() {
+			return
+		}
+		duration := end.Sub(start)
+		metrics.HTTPPhaseDuration.WithLabelValues(endpoint, phase).Observe(duration.Seconds())
+		fields = append(fields, zap.Float64(phase+"_ms", float64(duration.Microseconds())/1000))
+	}
+
+	observe("dns", p.dnsStart, p.dnsDone)
+	observe("connect", p.connectStart, p.connected)
+	observe("tls", p.tlsStart, p.tlsDone)
+	observe("first_byte", p.start, p.firstByte)
+
+	logging.FromContext(ctx, logger).Debug("TEI request phases", fields...)
+}
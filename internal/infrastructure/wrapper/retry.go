@@ -0,0 +1,78 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+)
+
+// RetryPolicy overrides the client's retry behaviour for a single call
+type RetryPolicy struct {
+	// Disabled sends the request exactly once, even on retryable errors
+	Disabled bool
+
+	// MaxRetries replaces the configured retry count when non-nil
+	MaxRetries *int
+}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a copy of ctx whose TEI requests follow policy
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// WithoutRetries returns a copy of ctx whose TEI requests are never retried
+func WithoutRetries(ctx context.Context) context.Context {
+	return WithRetryPolicy(ctx, RetryPolicy{Disabled: true})
+}
+
+// WithMaxRetries returns a copy of ctx whose TEI requests are retried at
+// most n times
+func WithMaxRetries(ctx context.Context, n int) context.Context {
+	return WithRetryPolicy(ctx, RetryPolicy{MaxRetries: &n})
+}
+
+// retryableEndpoints are POST endpoints without side effects on TEI, so
+// repeating them is safe
+var retryableEndpoints = map[string]bool{
+	entities.EndpointEmbed:       true,
+	entities.EndpointEmbedAll:    true,
+	entities.EndpointEmbedSparse: true,
+	entities.EndpointEmbedOpenAI: true,
+	entities.EndpointSimilarity:  true,
+	entities.EndpointTokenize:    true,
+	entities.EndpointDecode:      true,
+}
+
+// maxRetriesFor returns how many times req may be retried. Idempotent
+// methods and the known read-only endpoints use the configured count;
+// anything else is sent once unless the context policy says otherwise.
+func (c *Client) maxRetriesFor(ctx context.Context, req *http.Request) int {
+	maxRetries := 0
+	if isIdempotent(req.Method) || retryableEndpoints[req.URL.Path] {
+		maxRetries = c.maxRetries
+	}
+
+	if policy, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		if policy.Disabled {
+			return 0
+		}
+		if policy.MaxRetries != nil {
+			maxRetries = max(*policy.MaxRetries, 0)
+		}
+	}
+
+	return maxRetries
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
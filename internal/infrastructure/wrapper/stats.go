@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+)
+
+// Stats is a point-in-time snapshot of the client's request counters and
+// the state of each TEI replica
+type Stats struct {
+	Requests int64                      `json:"requests"`
+	Retries  int64                      `json:"retries"`
+	InFlight int64                      `json:"in_flight"`
+	Failures map[errors.ErrorType]int64 `json:"failures"`
+	Backends []BackendStats             `json:"backends"`
+}
+
+// BackendStats describes one TEI replica. A replica that is not Available
+// is being skipped after an outage until DownUntil.
+type BackendStats struct {
+	URL       string     `json:"url"`
+	Pending   int64      `json:"pending"`
+	Available bool       `json:"available"`
+	DownUntil *time.Time `json:"down_until,omitempty"`
+}
+
+type counters struct {
+	requests atomic.Int64
+	retries  atomic.Int64
+	inFlight atomic.Int64
+
+	// failures maps errors.ErrorType to *atomic.Int64
+	failures sync.Map
+}
+
+func (c *counters) addFailure(errorType errors.ErrorType) {
+	counter, _ := c.failures.LoadOrStore(errorType, new(atomic.Int64))
+	counter.(*atomic.Int64).Add(1)
+}
+
+// Stats returns the requests, retries and failures counted since the client
+// was created, along with the current state of each replica
+func (c *Client) Stats() Stats {
+	stats := Stats{
+		Requests: c.counters.requests.Load(),
+		Retries:  c.counters.retries.Load(),
+		InFlight: c.counters.inFlight.Load(),
+		Failures: make(map[errors.ErrorType]int64),
+		Backends: make([]BackendStats, 0, len(c.balancer.backends)),
+	}
+
+	c.counters.failures.Range(func(key, value any) bool {
+		stats.Failures[key.(errors.ErrorType)] = value.(*atomic.Int64).Load()
+		return true
+	})
+
+	now := time.Now()
+	for _, backend := range c.balancer.backends {
+		backendStats := BackendStats{
+			URL:       backend.baseURL.String(),
+			Pending:   backend.pending.Load(),
+			Available: backend.available(now),
+		}
+		if !backendStats.Available {
+			downUntil := time.Unix(0, backend.downUntil.Load())
+			backendStats.DownUntil = &downUntil
+		}
+		stats.Backends = append(stats.Backends, backendStats)
+	}
+
+	return stats
+}
@@ -0,0 +1,66 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+
+	"go.uber.org/zap"
+)
+
+// Warmup sends a health check to every TEI replica, opening a pooled
+// connection to each so that the first real request does not pay for DNS,
+// TCP and TLS setup. Replicas that fail are logged and taken out of rotation
+// for the failover cooldown. It returns an error only when no replica is
+// reachable.
+func (c *Client) Warmup(ctx context.Context) error {
+	if c.fake != nil {
+		return nil
+	}
+
+	var lastErr error
+	healthy := 0
+
+	for _, backend := range c.balancer.backends {
+		if err := c.checkHealth(ctx, backend); err != nil {
+			c.logger.Warn("TEI replica failed warmup",
+				zap.String("url", backend.baseURL.String()),
+				zap.Error(err),
+			)
+			c.balancer.markDown(backend)
+			lastErr = err
+			continue
+		}
+		healthy++
+	}
+
+	if healthy == 0 {
+		return fmt.Errorf("no TEI replica is reachable: %w", lastErr)
+	}
+
+	c.logger.Info("TEI connections warmed up",
+		zap.Int("healthy", healthy),
+		zap.Int("replicas", len(c.balancer.backends)),
+	)
+	return nil
+}
+
+func (c *Client) checkHealth(ctx context.Context, backend *backend) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, entities.EndpointHealth, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setDefaultHeaders(req)
+
+	_, resp, _, err := c.do(ctx, req, backend)
+	if err != nil {
+		return c.wrapNetworkError(err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("health check returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,12 +1,19 @@
 package server
 
 import (
+	"context"
+	stderrors "errors"
+	"strconv"
+	"strings"
+
 	"github.com/blackprince001/embedding-inference/internal/domain/entities"
 	"github.com/blackprince001/embedding-inference/internal/domain/errors"
 	pb "github.com/blackprince001/embedding-inference/protos/gen/v1"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
 )
 
 func (s *Server) convertEmbedRequest(req *pb.EmbedRequest) (*entities.EmbedRequest, error) {
@@ -26,10 +33,67 @@ func (s *Server) convertEmbedRequest(req *pb.EmbedRequest) (*entities.EmbedReque
 	if req.TruncationDirection != nil {
 		domainReq.TruncationDirection = convertTruncationDirection(*req.TruncationDirection)
 	}
+	if req.AutoBatch != nil {
+		domainReq.AutoBatch = req.AutoBatch
+	}
+	if req.EchoRequest != nil {
+		domainReq.EchoRequest = req.EchoRequest
+	}
+	if req.AllowDegraded != nil {
+		domainReq.AllowDegraded = req.AllowDegraded
+	}
+	if req.Dimensions != nil {
+		dimensions := int(*req.Dimensions)
+		domainReq.Dimensions = &dimensions
+	}
+	if req.InputRole != nil {
+		domainReq.InputRole = convertInputRole(*req.InputRole)
+	}
+	if req.Deduplicate != nil {
+		domainReq.Deduplicate = req.Deduplicate
+	}
+	if req.PartialResults != nil {
+		domainReq.PartialResults = req.PartialResults
+	}
+	if req.EncodingFormat != nil {
+		domainReq.EncodingFormat = convertEncodingFormat(*req.EncodingFormat)
+	}
 
 	return domainReq, nil
 }
 
+func (s *Server) convertEmbedImageRequest(req *pb.EmbedImageRequest) *entities.EmbedImageRequest {
+	domainReq := &entities.EmbedImageRequest{
+		Images:    make([]entities.ImageInput, len(req.Images)),
+		Normalize: req.Normalize,
+	}
+	for i, image := range req.Images {
+		domainReq.Images[i] = entities.ImageInput{
+			URL:         image.GetUrl(),
+			Data:        image.GetData(),
+			ContentType: image.GetContentType(),
+		}
+	}
+
+	return domainReq
+}
+
+func (s *Server) convertEmbedTokensRequest(req *pb.EmbedTokensRequest) *entities.EmbedTokensRequest {
+	domainReq := &entities.EmbedTokensRequest{
+		Inputs:    make([][]uint32, len(req.Inputs)),
+		Normalize: req.Normalize,
+		Truncate:  req.Truncate,
+	}
+	for i, input := range req.Inputs {
+		domainReq.Inputs[i] = input.Ids
+	}
+	if req.TruncationDirection != nil {
+		domainReq.TruncationDirection = convertTruncationDirection(*req.TruncationDirection)
+	}
+
+	return domainReq
+}
+
 func (s *Server) convertEmbedAllRequest(req *pb.EmbedAllRequest) (*entities.EmbedAllRequest, error) {
 	domainReq := &entities.EmbedAllRequest{
 		Inputs: entities.Input{Data: req.Inputs},
@@ -44,6 +108,13 @@ func (s *Server) convertEmbedAllRequest(req *pb.EmbedAllRequest) (*entities.Embe
 	if req.TruncationDirection != nil {
 		domainReq.TruncationDirection = convertTruncationDirection(*req.TruncationDirection)
 	}
+	if req.AutoBatch != nil {
+		domainReq.AutoBatch = req.AutoBatch
+	}
+	if req.MaxTokensPerInput != nil {
+		maxTokens := int(*req.MaxTokensPerInput)
+		domainReq.MaxTokensPerInput = &maxTokens
+	}
 
 	return domainReq, nil
 }
@@ -62,6 +133,29 @@ func (s *Server) convertEmbedSparseRequest(req *pb.EmbedSparseRequest) (*entitie
 	if req.TruncationDirection != nil {
 		domainReq.TruncationDirection = convertTruncationDirection(*req.TruncationDirection)
 	}
+	if req.TopK != nil {
+		topK := int(*req.TopK)
+		domainReq.TopK = &topK
+	}
+
+	return domainReq, nil
+}
+
+func (s *Server) convertEmbedHybridRequest(req *pb.EmbedHybridRequest) (*entities.EmbedHybridRequest, error) {
+	domainReq := &entities.EmbedHybridRequest{
+		Inputs:     entities.Input{Data: req.Inputs},
+		Normalize:  req.Normalize,
+		PromptName: req.PromptName,
+		Truncate:   req.Truncate,
+	}
+
+	if req.TruncationDirection != nil {
+		domainReq.TruncationDirection = convertTruncationDirection(*req.TruncationDirection)
+	}
+	if req.SparseTopK != nil {
+		topK := int(*req.SparseTopK)
+		domainReq.SparseTopK = &topK
+	}
 
 	return domainReq, nil
 }
@@ -85,6 +179,12 @@ func (s *Server) convertSimilarityRequest(req *pb.SimilarityRequest) (*entities.
 		if req.Parameters.TruncationDirection != nil {
 			domainReq.Parameters.TruncationDirection = convertTruncationDirection(*req.Parameters.TruncationDirection)
 		}
+		if req.Parameters.Metric != nil {
+			domainReq.Parameters.Metric = convertSimilarityMetric(*req.Parameters.Metric)
+		}
+		if req.Parameters.Ranked != nil {
+			domainReq.Parameters.Ranked = req.Parameters.Ranked
+		}
 	}
 
 	return domainReq, nil
@@ -92,12 +192,55 @@ func (s *Server) convertSimilarityRequest(req *pb.SimilarityRequest) (*entities.
 
 // Convert domain responses to protobuf responses
 
+func (s *Server) convertSimilarityResponse(resp *entities.SimilarityResponse) *pb.SimilarityResponse {
+	pbResp := &pb.SimilarityResponse{Similarities: resp.Similarities}
+	for _, sentence := range resp.Ranked {
+		pbResp.Ranked = append(pbResp.Ranked, &pb.RankedSentence{
+			Index:      uint32(sentence.Index),
+			Sentence:   sentence.Sentence,
+			Similarity: sentence.Similarity,
+		})
+	}
+	return pbResp
+}
+
 func (s *Server) convertEmbedResponse(resp *entities.EmbedResponse) *pb.EmbedResponse {
 	embeddings := make([]*pb.Embedding, len(resp.Embeddings))
 	for i, embedding := range resp.Embeddings {
 		embeddings[i] = &pb.Embedding{Values: embedding}
 	}
-	return &pb.EmbedResponse{Embeddings: embeddings}
+
+	pbResp := &pb.EmbedResponse{
+		Embeddings: embeddings,
+		Degraded:   resp.Degraded,
+		Truncated:  resp.Truncated,
+	}
+	for _, quantized := range resp.Quantized {
+		values := make([]byte, len(quantized.Values))
+		for i, value := range quantized.Values {
+			values[i] = byte(value)
+		}
+		pbResp.QuantizedEmbeddings = append(pbResp.QuantizedEmbeddings, &pb.QuantizedEmbedding{
+			Values: values,
+			Scale:  quantized.Scale,
+		})
+	}
+	for _, inputErr := range resp.Errors {
+		st := status.Convert(s.convertError(inputErr.Err))
+		pbResp.Errors = append(pbResp.Errors, &pb.InputError{
+			Index:   uint32(inputErr.Index),
+			Code:    int32(st.Code()),
+			Message: st.Message(),
+		})
+	}
+	if resp.Echo != nil {
+		pbResp.Echo = &pb.RequestEcho{
+			RequestId:  resp.Echo.RequestID,
+			InputCount: uint32(resp.Echo.InputCount),
+			InputHash:  resp.Echo.InputHash,
+		}
+	}
+	return pbResp
 }
 
 func (s *Server) convertEmbedAllResponse(resp *entities.EmbedAllResponse) *pb.EmbedAllResponse {
@@ -113,8 +256,38 @@ func (s *Server) convertEmbedAllResponse(resp *entities.EmbedAllResponse) *pb.Em
 }
 
 func (s *Server) convertEmbedSparseResponse(resp *entities.EmbedSparseResponse) *pb.EmbedSparseResponse {
-	sparseEmbeddings := make([]*pb.SparseEmbedding, len(resp.Embeddings))
-	for i, embedding := range resp.Embeddings {
+	return &pb.EmbedSparseResponse{SparseEmbeddings: convertSparseEmbeddings(resp.Embeddings)}
+}
+
+func (s *Server) convertEmbedHybridResponse(resp *entities.EmbedHybridResponse) *pb.EmbedHybridResponse {
+	dense := make([]*pb.Embedding, len(resp.Dense))
+	for i, embedding := range resp.Dense {
+		dense[i] = &pb.Embedding{Values: embedding}
+	}
+
+	return &pb.EmbedHybridResponse{
+		DenseEmbeddings:  dense,
+		SparseEmbeddings: convertSparseEmbeddings(resp.Sparse),
+	}
+}
+
+func convertSimilarityProgress(progress *entities.SimilarityProgress) *pb.StreamSimilarityResponse {
+	matches := make([]*pb.SimilarityMatch, len(progress.TopMatches))
+	for i, match := range progress.TopMatches {
+		matches[i] = &pb.SimilarityMatch{Index: uint32(match.Index), Score: match.Score}
+	}
+
+	return &pb.StreamSimilarityResponse{
+		TopMatches: matches,
+		Scored:     uint32(progress.Scored),
+		Total:      uint32(progress.Total),
+		Done:       progress.Done,
+	}
+}
+
+func convertSparseEmbeddings(embeddings [][]entities.SparseValue) []*pb.SparseEmbedding {
+	sparseEmbeddings := make([]*pb.SparseEmbedding, len(embeddings))
+	for i, embedding := range embeddings {
 		values := make([]*pb.SparseValue, len(embedding))
 		for j, val := range embedding {
 			values[j] = &pb.SparseValue{
@@ -124,7 +297,7 @@ func (s *Server) convertEmbedSparseResponse(resp *entities.EmbedSparseResponse)
 		}
 		sparseEmbeddings[i] = &pb.SparseEmbedding{Values: values}
 	}
-	return &pb.EmbedSparseResponse{SparseEmbeddings: sparseEmbeddings}
+	return sparseEmbeddings
 }
 
 // Helper conversion functions
@@ -140,30 +313,137 @@ func convertTruncationDirection(dir pb.TruncationDirection) entities.TruncationD
 	}
 }
 
-// func convertEncodingFormat(format pb.EncodingFormat) entities.EncodingFormat {
-// 	switch format {
-// 	case pb.EncodingFormat_ENCODING_FORMAT_FLOAT:
-// 		return entities.EncodingFloat
-// 	case pb.EncodingFormat_ENCODING_FORMAT_BASE64:
-// 		return entities.EncodingBase64
-// 	default:
-// 		return entities.EncodingFloat
-// 	}
-// }
+func convertSimilarityMetric(metric pb.SimilarityMetric) entities.SimilarityMetric {
+	switch metric {
+	case pb.SimilarityMetric_SIMILARITY_METRIC_COSINE:
+		return entities.SimilarityCosine
+	case pb.SimilarityMetric_SIMILARITY_METRIC_DOT:
+		return entities.SimilarityDot
+	case pb.SimilarityMetric_SIMILARITY_METRIC_EUCLIDEAN:
+		return entities.SimilarityEuclidean
+	default:
+		return ""
+	}
+}
+
+func convertValidateResponse(violations *errors.MultiValidationError) *pb.ValidateResponse {
+	if violations == nil {
+		return &pb.ValidateResponse{Valid: true}
+	}
+
+	resp := &pb.ValidateResponse{
+		Violations: make([]*pb.FieldViolation, len(violations.Errors)),
+	}
+	for i, violation := range violations.Errors {
+		resp.Violations[i] = &pb.FieldViolation{
+			Field:   violation.Field,
+			Message: violation.Message,
+		}
+	}
+	return resp
+}
+
+func convertTokenCountResponse(resp *entities.TokenCountResponse) *pb.CountTokensResponse {
+	counts := make([]*pb.TokenCount, len(resp.Counts))
+	for i, count := range resp.Counts {
+		counts[i] = &pb.TokenCount{
+			Tokens:    uint32(count.Tokens),
+			Truncated: count.Truncated,
+		}
+	}
+
+	return &pb.CountTokensResponse{
+		Counts:         counts,
+		Total:          uint32(resp.Total),
+		MaxInputTokens: uint32(resp.MaxInputTokens),
+	}
+}
+
+func convertInputRole(role pb.InputRole) entities.InputRole {
+	switch role {
+	case pb.InputRole_INPUT_ROLE_QUERY:
+		return entities.InputRoleQuery
+	case pb.InputRole_INPUT_ROLE_DOCUMENT:
+		return entities.InputRoleDocument
+	default:
+		return ""
+	}
+}
+
+func convertEncodingFormat(format pb.EncodingFormat) entities.EncodingFormat {
+	switch format {
+	case pb.EncodingFormat_ENCODING_FORMAT_FLOAT:
+		return entities.EncodingFloat
+	case pb.EncodingFormat_ENCODING_FORMAT_BASE64:
+		return entities.EncodingBase64
+	case pb.EncodingFormat_ENCODING_FORMAT_INT8:
+		return entities.EncodingInt8
+	default:
+		return entities.EncodingFloat
+	}
+}
+
+func (s *Server) convertStreamResults(results []entities.StreamResult) *pb.EmbedStreamResponse {
+	pbResp := &pb.EmbedStreamResponse{Results: make([]*pb.EmbedStreamResult, len(results))}
+	for i, result := range results {
+		pbResult := &pb.EmbedStreamResult{Id: result.ID}
+		if result.Err != nil {
+			st := status.Convert(s.convertError(result.Err))
+			pbResult.ErrorCode = int32(st.Code())
+			pbResult.ErrorMessage = st.Message()
+		} else {
+			pbResult.Embedding = &pb.Embedding{Values: result.Embedding}
+		}
+		pbResp.Results[i] = pbResult
+	}
+	return pbResp
+}
 
 // Error conversion
 
+// errorDomain identifies this service in google.rpc.ErrorInfo details
+const errorDomain = "embedding-inference"
+
 func (s *Server) convertError(err error) error {
-	if teiErr, ok := err.(*errors.TEIError); ok {
+	// Name the ids of a failed stream batch, keeping the code and details
+	// of its error
+	var streamErr *errors.StreamBatchError
+	if stderrors.As(err, &streamErr) {
+		st := status.Convert(s.convertError(streamErr.Err)).Proto()
+		st.Message = streamErr.Error()
+		return status.FromProto(st).Err()
+	}
+
+	// BatchError unwraps to its failures, so match it before their types
+	var batchErr *errors.BatchError
+	if stderrors.As(err, &batchErr) {
+		// Keep the code and details of the first failure under the
+		// message describing every failed sub-batch
+		first := batchErr.Failures[0]
+		st := status.Convert(s.convertError(first.Err)).Proto()
+		st.Message = batchErr.Error()
+		return status.FromProto(st).Err()
+	}
+
+	var teiErr *errors.TEIError
+	if stderrors.As(err, &teiErr) {
 		return s.convertTEIError(teiErr)
 	}
 
-	if validationErr, ok := err.(*errors.ValidationError); ok {
-		return status.Errorf(codes.InvalidArgument, "validation error: %s", validationErr.Message)
+	var validationErr *errors.ValidationError
+	if stderrors.As(err, &validationErr) {
+		st := status.Newf(codes.InvalidArgument, "validation error: %s", validationErr.Message)
+		return withDetails(st, badRequest(*validationErr))
+	}
+
+	var multiValidationErr *errors.MultiValidationError
+	if stderrors.As(err, &multiValidationErr) {
+		st := status.Newf(codes.InvalidArgument, "validation errors: %s", multiValidationErr.Error())
+		return withDetails(st, badRequest(multiValidationErr.Errors...))
 	}
 
-	if multiValidationErr, ok := err.(*errors.MultiValidationError); ok {
-		return status.Errorf(codes.InvalidArgument, "validation errors: %s", multiValidationErr.Error())
+	if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
 	}
 
 	// Generic error
@@ -178,7 +458,7 @@ func (s *Server) convertTEIError(teiErr *errors.TEIError) error {
 		code = codes.InvalidArgument
 	case errors.ErrorTypeTokenizer:
 		code = codes.InvalidArgument
-	case errors.ErrorTypeBackend:
+	case errors.ErrorTypeBackend, errors.ErrorTypeResponseMalformed, errors.ErrorTypeResponseMismatch:
 		code = codes.Internal
 	case errors.ErrorTypeOverloaded:
 		code = codes.ResourceExhausted
@@ -188,9 +468,47 @@ func (s *Server) convertTEIError(teiErr *errors.TEIError) error {
 		code = codes.Unavailable
 	case errors.ErrorTypeTimeout:
 		code = codes.DeadlineExceeded
+	case errors.ErrorTypeCanceled:
+		code = codes.Canceled
 	default:
 		code = codes.Internal
 	}
 
-	return status.Errorf(code, "[%s] %s", teiErr.Type, teiErr.Message)
+	metadata := map[string]string{
+		"error_type": string(teiErr.Type),
+	}
+	if teiErr.Code != 0 {
+		metadata["http_code"] = strconv.Itoa(teiErr.Code)
+	}
+	if teiErr.RequestID != "" {
+		metadata["request_id"] = teiErr.RequestID
+	}
+
+	st := status.Newf(code, "[%s] %s", teiErr.Type, teiErr.Message)
+	return withDetails(st, &errdetails.ErrorInfo{
+		Reason:   strings.ToUpper(string(teiErr.Type)),
+		Domain:   errorDomain,
+		Metadata: metadata,
+	})
+}
+
+func badRequest(validationErrs ...errors.ValidationError) *errdetails.BadRequest {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(validationErrs))
+	for _, validationErr := range validationErrs {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       validationErr.Field,
+			Description: validationErr.Message,
+		})
+	}
+	return &errdetails.BadRequest{FieldViolations: violations}
+}
+
+// withDetails attaches details to st, falling back to the bare status if
+// they cannot be marshaled
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
 }
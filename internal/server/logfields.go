@@ -0,0 +1,84 @@
+package server
+
+import (
+	pb "github.com/blackprince001/embedding-inference/protos/gen/v1"
+
+	"go.uber.org/zap"
+)
+
+// RequestLogFields summarises a request by counts and lengths so it can be
+// logged without the caller's text
+func RequestLogFields(req any) []zap.Field {
+	switch r := req.(type) {
+	case *pb.EmbedRequest:
+		return inputFields(r.Inputs)
+	case *pb.EmbedTokensRequest:
+		return []zap.Field{zap.Int("inputs_count", len(r.Inputs))}
+	case *pb.EmbedImageRequest:
+		return []zap.Field{zap.Int("images_count", len(r.Images))}
+	case *pb.EmbedAllRequest:
+		return inputFields(r.Inputs)
+	case *pb.EmbedSparseRequest:
+		return inputFields(r.Inputs)
+	case *pb.EmbedHybridRequest:
+		return inputFields(r.Inputs)
+	case *pb.SimilarityRequest:
+		return append(inputFields(r.Sentences), zap.Int("source_chars", len(r.SourceSentence)))
+	case *pb.CountTokensRequest:
+		return inputFields(r.Inputs)
+	case *pb.ValidateRequest:
+		if embed := r.GetEmbed(); embed != nil {
+			return RequestLogFields(embed)
+		}
+		if similarity := r.GetSimilarity(); similarity != nil {
+			return RequestLogFields(similarity)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// ResponseLogFields summarises a response by counts and dimensions so it
+// can be logged without the embedding vectors
+func ResponseLogFields(resp any) []zap.Field {
+	switch r := resp.(type) {
+	case *pb.EmbedResponse:
+		fields := []zap.Field{zap.Int("embeddings_count", len(r.Embeddings))}
+		if len(r.Embeddings) > 0 {
+			fields = append(fields, zap.Int("dimension", len(r.Embeddings[0].Values)))
+		}
+		if len(r.QuantizedEmbeddings) > 0 {
+			fields = append(fields, zap.Int("quantized_count", len(r.QuantizedEmbeddings)))
+		}
+		return fields
+	case *pb.EmbedAllResponse:
+		return []zap.Field{zap.Int("embeddings_count", len(r.TokenEmbeddings))}
+	case *pb.EmbedSparseResponse:
+		return []zap.Field{zap.Int("embeddings_count", len(r.SparseEmbeddings))}
+	case *pb.EmbedHybridResponse:
+		return []zap.Field{
+			zap.Int("embeddings_count", len(r.DenseEmbeddings)),
+			zap.Int("sparse_embeddings_count", len(r.SparseEmbeddings)),
+		}
+	case *pb.SimilarityResponse:
+		return []zap.Field{zap.Int("similarities_count", len(r.Similarities))}
+	case *pb.CountTokensResponse:
+		return []zap.Field{zap.Int("counts_count", len(r.Counts)), zap.Uint32("total_tokens", r.Total)}
+	case *pb.ValidateResponse:
+		return []zap.Field{zap.Bool("valid", r.Valid), zap.Int("violations_count", len(r.Violations))}
+	default:
+		return nil
+	}
+}
+
+func inputFields(inputs []string) []zap.Field {
+	chars := 0
+	for _, input := range inputs {
+		chars += len(input)
+	}
+	return []zap.Field{
+		zap.Int("inputs_count", len(inputs)),
+		zap.Int("input_chars", chars),
+	}
+}
@@ -2,13 +2,19 @@ package server
 
 import (
 	"context"
+	stderrors "errors"
+	"io"
 
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/requestid"
 	"github.com/blackprince001/embedding-inference/pkg/client"
 	pb "github.com/blackprince001/embedding-inference/protos/gen/v1"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // Server implements the TextEmbeddingsService gRPC service
@@ -16,13 +22,18 @@ type Server struct {
 	pb.UnimplementedTextEmbeddingsServiceServer
 	client *client.Client
 	logger *zap.Logger
+
+	// maxSendMsgSize is the largest response gRPC will send, in bytes
+	maxSendMsgSize int
 }
 
-// NewServer creates a new gRPC server
-func NewServer(client *client.Client, logger *zap.Logger) *Server {
+// NewServer creates a new gRPC server. maxSendMsgSize must match the
+// grpc.MaxSendMsgSize the server is created with.
+func NewServer(client *client.Client, logger *zap.Logger, maxSendMsgSize int) *Server {
 	return &Server{
-		client: client,
-		logger: logger.Named("grpc-server"),
+		client:         client,
+		logger:         logger.Named("grpc-server"),
+		maxSendMsgSize: maxSendMsgSize,
 	}
 }
 
@@ -44,6 +55,10 @@ func (s *Server) Embed(ctx context.Context, req *pb.EmbedRequest) (*pb.EmbedResp
 		return nil, s.convertError(err)
 	}
 
+	if domainResp.Echo != nil {
+		domainResp.Echo.RequestID = requestid.FromContext(ctx)
+	}
+
 	// Convert domain response to protobuf response
 	pbResp := s.convertEmbedResponse(domainResp)
 
@@ -51,6 +66,32 @@ func (s *Server) Embed(ctx context.Context, req *pb.EmbedRequest) (*pb.EmbedResp
 	return pbResp, nil
 }
 
+// EmbedTokens implements the EmbedTokens RPC
+func (s *Server) EmbedTokens(ctx context.Context, req *pb.EmbedTokensRequest) (*pb.EmbedResponse, error) {
+	s.logger.Debug("EmbedTokens RPC called", zap.Int("inputs_count", len(req.Inputs)))
+
+	domainResp, err := s.client.EmbedTokens(ctx, s.convertEmbedTokensRequest(req))
+	if err != nil {
+		s.logger.Error("EmbedTokens operation failed", zap.Error(err))
+		return nil, s.convertError(err)
+	}
+
+	return s.convertEmbedResponse(domainResp), nil
+}
+
+// EmbedImage implements the EmbedImage RPC
+func (s *Server) EmbedImage(ctx context.Context, req *pb.EmbedImageRequest) (*pb.EmbedResponse, error) {
+	s.logger.Debug("EmbedImage RPC called", zap.Int("images_count", len(req.Images)))
+
+	domainResp, err := s.client.EmbedImage(ctx, s.convertEmbedImageRequest(req))
+	if err != nil {
+		s.logger.Error("EmbedImage operation failed", zap.Error(err))
+		return nil, s.convertError(err)
+	}
+
+	return s.convertEmbedResponse(domainResp), nil
+}
+
 // EmbedAll implements the EmbedAll RPC
 func (s *Server) EmbedAll(ctx context.Context, req *pb.EmbedAllRequest) (*pb.EmbedAllResponse, error) {
 	s.logger.Debug("EmbedAll RPC called", zap.Int("inputs_count", len(req.Inputs)))
@@ -67,6 +108,11 @@ func (s *Server) EmbedAll(ctx context.Context, req *pb.EmbedAllRequest) (*pb.Emb
 	}
 
 	pbResp := s.convertEmbedAllResponse(domainResp)
+	if size := proto.Size(pbResp); s.maxSendMsgSize > 0 && size > s.maxSendMsgSize {
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"EmbedAll response of %d bytes exceeds the %d byte message limit: send fewer or shorter inputs, set max_tokens_per_input, or raise grpc.max_send_msg_size",
+			size, s.maxSendMsgSize)
+	}
 	return pbResp, nil
 }
 
@@ -89,10 +135,28 @@ func (s *Server) EmbedSparse(ctx context.Context, req *pb.EmbedSparseRequest) (*
 	return pbResp, nil
 }
 
+// EmbedHybrid implements the EmbedHybrid RPC
+func (s *Server) EmbedHybrid(ctx context.Context, req *pb.EmbedHybridRequest) (*pb.EmbedHybridResponse, error) {
+	s.logger.Debug("EmbedHybrid RPC called", zap.Int("inputs_count", len(req.Inputs)))
+
+	domainReq, err := s.convertEmbedHybridRequest(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	domainResp, err := s.client.EmbedHybrid(ctx, domainReq)
+	if err != nil {
+		s.logger.Error("EmbedHybrid operation failed", zap.Error(err))
+		return nil, s.convertError(err)
+	}
+
+	return s.convertEmbedHybridResponse(domainResp), nil
+}
+
 // CalculateSimilarity implements the CalculateSimilarity RPC
 func (s *Server) CalculateSimilarity(ctx context.Context, req *pb.SimilarityRequest) (*pb.SimilarityResponse, error) {
 	s.logger.Debug("CalculateSimilarity RPC called",
-		zap.String("source", req.SourceSentence[:min(50, len(req.SourceSentence))]),
+		zap.Int("source_chars", len(req.SourceSentence)),
 		zap.Int("sentences_count", len(req.Sentences)),
 	)
 
@@ -107,17 +171,122 @@ func (s *Server) CalculateSimilarity(ctx context.Context, req *pb.SimilarityRequ
 		return nil, s.convertError(err)
 	}
 
-	pbResp := &pb.SimilarityResponse{
-		Similarities: domainResp.Similarities,
+	pbResp := s.convertSimilarityResponse(domainResp)
+	return pbResp, nil
+}
+
+// StreamSimilarity implements the StreamSimilarity RPC, sending the best
+// matches so far after each chunk of sentences is scored
+func (s *Server) StreamSimilarity(req *pb.StreamSimilarityRequest, stream pb.TextEmbeddingsService_StreamSimilarityServer) error {
+	s.logger.Debug("StreamSimilarity RPC called",
+		zap.Int("source_chars", len(req.SourceSentence)),
+		zap.Int("sentences_count", len(req.Sentences)),
+	)
+
+	domainReq, err := s.convertSimilarityRequest(&pb.SimilarityRequest{
+		SourceSentence: req.SourceSentence,
+		Sentences:      req.Sentences,
+		Parameters:     req.Parameters,
+	})
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
 	}
 
-	return pbResp, nil
+	err = s.client.StreamSimilarity(stream.Context(), domainReq, int(req.TopK), int(req.GetChunkSize()),
+		func(progress *entities.SimilarityProgress) error {
+			return stream.Send(convertSimilarityProgress(progress))
+		})
+	if err != nil {
+		s.logger.Error("StreamSimilarity operation failed", zap.Error(err))
+		return s.convertError(err)
+	}
+
+	return nil
 }
 
-// Helper function for minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
+// EmbedStream implements the EmbedStream RPC
+func (s *Server) EmbedStream(stream pb.TextEmbeddingsService_EmbedStreamServer) error {
+	s.logger.Debug("EmbedStream RPC called")
+
+	first, err := stream.Recv()
+	if stderrors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	domainReq := &entities.EmbedRequest{}
+	if first.Options != nil {
+		if domainReq, err = s.convertEmbedRequest(first.Options); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+	}
+
+	recv := func() (*entities.StreamInput, error) {
+		if first != nil {
+			input := &entities.StreamInput{ID: first.Id, Text: first.Text}
+			first = nil
+			return input, nil
+		}
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return &entities.StreamInput{ID: msg.Id, Text: msg.Text}, nil
 	}
-	return b
+
+	err = s.client.EmbedStream(stream.Context(), domainReq, recv,
+		func(results []entities.StreamResult) error {
+			return stream.Send(s.convertStreamResults(results))
+		})
+	if err != nil {
+		s.logger.Error("EmbedStream operation failed", zap.Error(err))
+		return s.convertError(err)
+	}
+
+	return nil
+}
+
+// Validate implements the Validate RPC. It checks an embed or similarity
+// request without calling TEI and reports every violation; an invalid
+// request is a successful RPC with valid set to false.
+func (s *Server) Validate(ctx context.Context, req *pb.ValidateRequest) (*pb.ValidateResponse, error) {
+	var violations *errors.MultiValidationError
+
+	switch r := req.Request.(type) {
+	case *pb.ValidateRequest_Embed:
+		domainReq, err := s.convertEmbedRequest(r.Embed)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		violations = s.client.ValidateEmbed(domainReq)
+	case *pb.ValidateRequest_Similarity:
+		domainReq, err := s.convertSimilarityRequest(r.Similarity)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		violations = s.client.ValidateSimilarity(domainReq)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "request must be an embed or similarity request")
+	}
+
+	return convertValidateResponse(violations), nil
+}
+
+// CountTokens implements the CountTokens RPC
+func (s *Server) CountTokens(ctx context.Context, req *pb.CountTokensRequest) (*pb.CountTokensResponse, error) {
+	s.logger.Debug("CountTokens RPC called", zap.Int("inputs_count", len(req.Inputs)))
+
+	domainResp, err := s.client.CountTokens(ctx, &entities.TokenizeRequest{
+		Inputs:           entities.Input{Data: req.Inputs},
+		AddSpecialTokens: req.AddSpecialTokens,
+		PromptName:       req.PromptName,
+	})
+	if err != nil {
+		s.logger.Error("CountTokens operation failed", zap.Error(err))
+		return nil, s.convertError(err)
+	}
+
+	return convertTokenCountResponse(domainResp), nil
 }
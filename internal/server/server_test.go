@@ -0,0 +1,415 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/wrapper"
+	"github.com/blackprince001/embedding-inference/internal/server"
+	"github.com/blackprince001/embedding-inference/pkg/client"
+	pb "github.com/blackprince001/embedding-inference/protos/gen/v1"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// cannedResponse is one response of the mock TEI server
+type cannedResponse struct {
+	status int
+	body   string
+}
+
+// mockTEI is an httptest TEI server. Each request to a path is answered
+// with the next response queued for it, the last one repeating, and its
+// body is recorded.
+type mockTEI struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string][]cannedResponse
+	requests  map[string][][]byte
+}
+
+func newMockTEI(t *testing.T) *mockTEI {
+	t.Helper()
+
+	m := &mockTEI{
+		responses: map[string][]cannedResponse{
+			"/info":   {{http.StatusOK, `{"model_id":"mock","model_dtype":"float32","max_concurrent_requests":512,"max_input_length":512,"max_batch_tokens":16384,"max_client_batch_size":32}`}},
+			"/health": {{http.StatusOK, ``}},
+		},
+		requests: make(map[string][][]byte),
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// on queues responses for path, replacing any queued before
+func (m *mockTEI) on(path string, responses ...cannedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses[path] = responses
+}
+
+// bodies returns the request bodies received on path, in order
+func (m *mockTEI) bodies(path string) [][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.requests[path]
+}
+
+func (m *mockTEI) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	m.mu.Lock()
+	m.requests[r.URL.Path] = append(m.requests[r.URL.Path], body)
+	queued := m.responses[r.URL.Path]
+	var response cannedResponse
+	switch len(queued) {
+	case 0:
+		response = cannedResponse{http.StatusNotFound, `{"error":"not found","error_type":"validation"}`}
+	case 1:
+		response = queued[0]
+	default:
+		response, m.responses[r.URL.Path] = queued[0], queued[1:]
+	}
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.status)
+	_, _ = io.WriteString(w, response.body)
+}
+
+// newTestServer runs the full stack, gRPC server -> client -> wrapper,
+// against tei over an in-memory connection. configure may adjust the
+// default configuration first.
+func newTestServer(t *testing.T, tei *mockTEI, configure func(*config.Config)) pb.TextEmbeddingsServiceClient {
+	t.Helper()
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.TEI.BaseURL = tei.URL
+	cfg.TEI.BaseURLs = nil
+	cfg.TEI.RetryDelay = time.Millisecond
+	if configure != nil {
+		configure(cfg)
+	}
+
+	logger := &logging.Logger{Logger: zap.NewNop()}
+	httpClient, err := wrapper.NewHTTPClient(&cfg.TEI, &cfg.Client, logger)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	embeddingClient := client.NewClient(cfg, httpClient, logger)
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	pb.RegisterTextEmbeddingsServiceServer(grpcServer, server.NewServer(embeddingClient, logger.Logger, cfg.GRPC.MaxSendMsgSize))
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return pb.NewTextEmbeddingsServiceClient(conn)
+}
+
+// errorInfo returns the ErrorInfo detail of a gRPC error, if any
+func errorInfo(err error) *errdetails.ErrorInfo {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
+
+func TestEmbedRetriesAfterTooManyRequests(t *testing.T) {
+	tei := newMockTEI(t)
+	tei.on("/embed",
+		cannedResponse{http.StatusTooManyRequests, `{"error":"model is overloaded","error_type":"overloaded"}`},
+		cannedResponse{http.StatusOK, `[[0.6,0.8],[1.0,0.0]]`},
+	)
+	grpcClient := newTestServer(t, tei, nil)
+
+	resp, err := grpcClient.Embed(context.Background(), &pb.EmbedRequest{Inputs: []string{"first", "second"}})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	if got := len(tei.bodies("/embed")); got != 2 {
+		t.Errorf("TEI received %d /embed requests, want 2", got)
+	}
+	if len(resp.Embeddings) != 2 {
+		t.Fatalf("got %d embeddings, want 2", len(resp.Embeddings))
+	}
+	if got := resp.Embeddings[0].Values; len(got) != 2 || got[0] != 0.6 || got[1] != 0.8 {
+		t.Errorf("first embedding = %v, want [0.6 0.8]", got)
+	}
+}
+
+func TestEmbedTokenizerErrorIsInvalidArgument(t *testing.T) {
+	tei := newMockTEI(t)
+	tei.on("/embed",
+		cannedResponse{http.StatusUnprocessableEntity, `{"error":"Input validation error: inputs must have less than 512 tokens","error_type":"tokenizer"}`},
+	)
+	grpcClient := newTestServer(t, tei, nil)
+
+	_, err := grpcClient.Embed(context.Background(), &pb.EmbedRequest{Inputs: []string{"too long"}})
+
+	if got := status.Code(err); got != codes.InvalidArgument {
+		t.Fatalf("code = %v, want %v (err: %v)", got, codes.InvalidArgument, err)
+	}
+	if got := len(tei.bodies("/embed")); got != 1 {
+		t.Errorf("TEI received %d /embed requests, want 1: tokenizer errors are not retried", got)
+	}
+	if info := errorInfo(err); info == nil || info.Reason != "TOKENIZER" {
+		t.Errorf("ErrorInfo = %v, want reason TOKENIZER", info)
+	}
+}
+
+func TestEmbedCountMismatchIsInternal(t *testing.T) {
+	tei := newMockTEI(t)
+	tei.on("/embed", cannedResponse{http.StatusOK, `[[0.6,0.8]]`})
+	grpcClient := newTestServer(t, tei, nil)
+
+	_, err := grpcClient.Embed(context.Background(), &pb.EmbedRequest{Inputs: []string{"first", "second"}})
+
+	if got := status.Code(err); got != codes.Internal {
+		t.Fatalf("code = %v, want %v (err: %v)", got, codes.Internal, err)
+	}
+	if info := errorInfo(err); info == nil || info.Reason != "RESPONSE_MISMATCH" {
+		t.Errorf("ErrorInfo = %v, want reason RESPONSE_MISMATCH", info)
+	}
+}
+
+func TestSimilarityAndTokenize(t *testing.T) {
+	tei := newMockTEI(t)
+	tei.on("/similarity", cannedResponse{http.StatusOK, `[0.9,0.1]`})
+	tei.on("/tokenize", cannedResponse{http.StatusOK, `[[{"id":101,"text":"[CLS]","special":true,"start":null,"stop":null},{"id":7592,"text":"hello","special":false,"start":0,"stop":5},{"id":102,"text":"[SEP]","special":true,"start":null,"stop":null}]]`})
+	grpcClient := newTestServer(t, tei, nil)
+
+	similarity, err := grpcClient.CalculateSimilarity(context.Background(), &pb.SimilarityRequest{
+		SourceSentence: "hello",
+		Sentences:      []string{"hi", "bye"},
+	})
+	if err != nil {
+		t.Fatalf("CalculateSimilarity: %v", err)
+	}
+	if got := similarity.Similarities; len(got) != 2 || got[0] != 0.9 || got[1] != 0.1 {
+		t.Errorf("similarities = %v, want [0.9 0.1]", got)
+	}
+
+	counts, err := grpcClient.CountTokens(context.Background(), &pb.CountTokensRequest{Inputs: []string{"hello"}})
+	if err != nil {
+		t.Fatalf("CountTokens: %v", err)
+	}
+	if counts.Total == 0 {
+		t.Errorf("total tokens = 0, want the tokens of %q", "hello")
+	}
+}
+
+// embedPayload is the /embed body as TEI reads it
+type embedPayload struct {
+	Inputs              teiInputs `json:"inputs"`
+	Normalize           *bool     `json:"normalize"`
+	Truncate            *bool     `json:"truncate"`
+	TruncationDirection string    `json:"truncation_direction"`
+	PromptName          *string   `json:"prompt_name"`
+}
+
+// teiInputs reads TEI's inputs field, a single string or a list of them
+type teiInputs []string
+
+func (i *teiInputs) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*i = teiInputs{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(i))
+}
+
+// lastEmbedPayload decodes the last /embed body tei received
+func lastEmbedPayload(t *testing.T, tei *mockTEI) embedPayload {
+	t.Helper()
+
+	bodies := tei.bodies("/embed")
+	if len(bodies) == 0 {
+		t.Fatal("TEI received no /embed request")
+	}
+	var payload embedPayload
+	if err := json.Unmarshal(bodies[len(bodies)-1], &payload); err != nil {
+		t.Fatalf("decoding /embed body %s: %v", bodies[len(bodies)-1], err)
+	}
+	return payload
+}
+
+func TestEmbedPayloadAndBareArrayResponse(t *testing.T) {
+	tei := newMockTEI(t)
+	// TEI's /embed answers with a bare array of vectors, not an object
+	tei.on("/embed", cannedResponse{http.StatusOK, `[[-0.0427729,0.0193618,0.0183264,-0.0328493],[-0.0311437,-0.0102391,0.0472583,0.0025171]]`})
+	grpcClient := newTestServer(t, tei, nil)
+
+	truncate := true
+	direction := pb.TruncationDirection_TRUNCATION_DIRECTION_LEFT
+	promptName := "query"
+	resp, err := grpcClient.Embed(context.Background(), &pb.EmbedRequest{
+		Inputs:              []string{"What is Deep Learning?", "Deep Learning is not..."},
+		Truncate:            &truncate,
+		TruncationDirection: &direction,
+		PromptName:          &promptName,
+	})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	payload := lastEmbedPayload(t, tei)
+	if len(payload.Inputs) != 2 || payload.Inputs[0] != "What is Deep Learning?" || payload.Inputs[1] != "Deep Learning is not..." {
+		t.Errorf("inputs = %q, want the request inputs in order", payload.Inputs)
+	}
+	if payload.Normalize == nil || !*payload.Normalize {
+		t.Errorf("normalize = %v, want the default true", payload.Normalize)
+	}
+	if payload.Truncate == nil || !*payload.Truncate {
+		t.Errorf("truncate = %v, want true", payload.Truncate)
+	}
+	if payload.TruncationDirection != "Left" {
+		t.Errorf("truncation_direction = %q, want %q", payload.TruncationDirection, "Left")
+	}
+	if payload.PromptName == nil || *payload.PromptName != "query" {
+		t.Errorf("prompt_name = %v, want %q", payload.PromptName, "query")
+	}
+
+	if len(resp.Embeddings) != 2 {
+		t.Fatalf("got %d embeddings, want 2", len(resp.Embeddings))
+	}
+	if got := resp.Embeddings[1].Values; len(got) != 4 || got[0] != -0.0311437 || got[3] != 0.0025171 {
+		t.Errorf("second embedding = %v, want the values TEI returned", got)
+	}
+}
+
+func TestEmbedNormalizeFalseReachesTEI(t *testing.T) {
+	tei := newMockTEI(t)
+	tei.on("/embed", cannedResponse{http.StatusOK, `[[3.0,4.0]]`})
+	grpcClient := newTestServer(t, tei, nil)
+
+	normalize := false
+	resp, err := grpcClient.Embed(context.Background(), &pb.EmbedRequest{
+		Inputs:    []string{"unnormalized"},
+		Normalize: &normalize,
+	})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	if payload := lastEmbedPayload(t, tei); payload.Normalize == nil || *payload.Normalize {
+		t.Errorf("normalize = %v, want an explicit false", payload.Normalize)
+	}
+	if got := resp.Embeddings[0].Values; len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("embedding = %v, want the unnormalized [3 4]", got)
+	}
+}
+
+func TestEmbedValidationErrorsCarryFieldViolations(t *testing.T) {
+	tei := newMockTEI(t)
+	grpcClient := newTestServer(t, tei, nil)
+
+	dimensions := uint32(0)
+	_, err := grpcClient.Embed(context.Background(), &pb.EmbedRequest{
+		Inputs:     []string{"fine", "", " "},
+		Dimensions: &dimensions,
+	})
+
+	if got := status.Code(err); got != codes.InvalidArgument {
+		t.Fatalf("code = %v, want %v (err: %v)", got, codes.InvalidArgument, err)
+	}
+	if got := len(tei.bodies("/embed")); got != 0 {
+		t.Errorf("TEI received %d /embed requests, want none for an invalid request", got)
+	}
+
+	var badRequest *errdetails.BadRequest
+	for _, detail := range status.Convert(err).Details() {
+		if detail, ok := detail.(*errdetails.BadRequest); ok {
+			badRequest = detail
+		}
+	}
+	if badRequest == nil {
+		t.Fatalf("status details %v carry no BadRequest", status.Convert(err).Details())
+	}
+
+	// The texts stop at their first error by default; other fields are
+	// all reported
+	want := map[string]string{
+		"inputs[1]":  "cannot be empty",
+		"dimensions": "must be positive",
+	}
+	got := make(map[string]string, len(badRequest.FieldViolations))
+	for _, violation := range badRequest.FieldViolations {
+		got[violation.Field] = violation.Description
+	}
+	if len(got) != len(want) {
+		t.Errorf("field violations = %v, want %v", got, want)
+	}
+	for field, description := range want {
+		if got[field] != description {
+			t.Errorf("violation of %s = %q, want %q", field, got[field], description)
+		}
+	}
+}
+
+func TestEmbedStreamAbortNamesFailingIDs(t *testing.T) {
+	tei := newMockTEI(t)
+	tei.on("/embed", cannedResponse{http.StatusUnprocessableEntity, `{"error":"Input validation error: inputs must have less than 512 tokens","error_type":"tokenizer"}`})
+	grpcClient := newTestServer(t, tei, nil)
+
+	stream, err := grpcClient.EmbedStream(context.Background())
+	if err != nil {
+		t.Fatalf("EmbedStream: %v", err)
+	}
+	for _, id := range []string{"row-7", "row-8", "row-9"} {
+		if err := stream.Send(&pb.EmbedStreamRequest{Id: id, Text: "text of " + id}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend: %v", err)
+	}
+	_, err = stream.Recv()
+
+	if got := status.Code(err); got != codes.InvalidArgument {
+		t.Fatalf("code = %v, want %v of the batch error (err: %v)", got, codes.InvalidArgument, err)
+	}
+	if message := status.Convert(err).Message(); !strings.Contains(message, `"row-7"`) || !strings.Contains(message, `"row-9"`) {
+		t.Errorf("message = %q, want the first and last ids of the failed batch", message)
+	}
+	if info := errorInfo(err); info == nil || info.Reason != "TOKENIZER" {
+		t.Errorf("ErrorInfo = %v, want reason TOKENIZER", info)
+	}
+}
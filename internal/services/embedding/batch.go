@@ -0,0 +1,101 @@
+package embedding
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// runBatches splits n inputs into batches of at most batchSize and calls fn
+// for each batch with at most concurrency batches in flight. Results are
+// reassembled in input order; every failed batch is reported in a
+// BatchError, returned along with the results of the batches that
+// succeeded.
+func runBatches[T any](ctx context.Context, n, batchSize, concurrency int,
+	fn func(ctx context.Context, start, end int) ([]T, error)) ([]T, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]T, n)
+	batchErr := &errors.BatchError{}
+
+	pending := (n + batchSize - 1) / batchSize
+	metrics.BatchBacklog.Add(float64(pending))
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		dispatched int
+	)
+	sem := make(chan struct{}, concurrency)
+
+dispatch:
+	for start := 0; start < n; start += batchSize {
+		end := min(start+batchSize, n)
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+
+		pending--
+		metrics.BatchBacklog.Dec()
+		dispatched = end
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ctx, span := tracing.Tracer().Start(ctx, "embedding batch", trace.WithAttributes(
+				attribute.Int("embedding.batch_start", start),
+				attribute.Int("embedding.batch_size", end-start),
+			))
+			defer span.End()
+
+			metrics.BatchWorkersActive.Inc()
+			batch, err := fn(ctx, start, end)
+			metrics.BatchWorkersActive.Dec()
+
+			if err == nil && len(batch) != end-start {
+				err = errors.NewTEIError("sub-batch embedding count mismatch", errors.ErrorTypeResponseMismatch)
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "batch failed")
+				mu.Lock()
+				batchErr.Add(start, end, err)
+				mu.Unlock()
+				return
+			}
+
+			copy(results[start:end], batch)
+		}(start, end)
+	}
+
+	wg.Wait()
+	metrics.BatchBacklog.Sub(float64(pending))
+
+	if dispatched < n {
+		batchErr.Add(dispatched, n, ctx.Err())
+	}
+
+	if batchErr.HasErrors() {
+		sort.Slice(batchErr.Failures, func(i, j int) bool {
+			return batchErr.Failures[i].Start < batchErr.Failures[j].Start
+		})
+		return results, batchErr
+	}
+
+	return results, nil
+}
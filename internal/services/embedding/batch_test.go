@@ -0,0 +1,150 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEmbedBatchedGaugesReflectBacklog(t *testing.T) {
+	baseBacklog := testutil.ToFloat64(metrics.BatchBacklog)
+	baseWorkers := testutil.ToFloat64(metrics.BatchWorkersActive)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	tei := &fakeTEI{fail: func([]string) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}}
+	s := newTestService(tei, nil, nil)
+
+	done := make(chan error)
+	go func() {
+		_, err := embedNumbers(s, 100, func(req *entities.EmbedRequest) {
+			req.AutoBatch = entities.BoolPtr(true)
+		})
+		done <- err
+	}()
+
+	// The first of four sub-batches is in flight and the other three wait
+	<-started
+	if got := testutil.ToFloat64(metrics.BatchBacklog) - baseBacklog; got != 3 {
+		t.Errorf("backlog = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(metrics.BatchWorkersActive) - baseWorkers; got != 1 {
+		t.Errorf("active workers = %v, want 1", got)
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.BatchBacklog) - baseBacklog; got != 0 {
+		t.Errorf("backlog after completion = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(metrics.BatchWorkersActive) - baseWorkers; got != 0 {
+		t.Errorf("active workers after completion = %v, want 0", got)
+	}
+}
+
+func TestEmbedBatchedCanceledBacklogIsCleared(t *testing.T) {
+	baseBacklog := testutil.ToFloat64(metrics.BatchBacklog)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tei := &fakeTEI{fail: func([]string) error {
+		cancel()
+		return nil
+	}}
+	s := newTestService(tei, nil, nil)
+
+	_, err := s.Embed(ctx, &entities.EmbedRequest{
+		Inputs:    entities.Input{Data: numbers(100)},
+		Normalize: entities.BoolPtr(false),
+		AutoBatch: entities.BoolPtr(true),
+	})
+
+	if err == nil {
+		t.Error("Embed succeeded although the context was canceled mid-way")
+	}
+	if got := testutil.ToFloat64(metrics.BatchBacklog) - baseBacklog; got != 0 {
+		t.Errorf("backlog after cancellation = %v, want 0", got)
+	}
+}
+
+// waitForGauge polls gauge until it reads want, failing after a second
+func waitForGauge(t *testing.T, gauge prometheus.Gauge, want float64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(gauge) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("gauge = %v, want %v", testutil.ToFloat64(gauge), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalescedPendingGauge(t *testing.T) {
+	base := testutil.ToFloat64(metrics.CoalescedPending)
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	tei := &fakeTEI{fail: func([]string) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}}
+	s := newTestService(tei, &config.EmbeddingConfig{CoalesceRequests: true}, nil)
+
+	done := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := embedNumbers(s, 2, nil)
+			done <- err
+		}()
+	}
+	<-started
+
+	// A fourth identical request joins the call and then gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	gaveUp := make(chan error)
+	go func() {
+		_, err := s.Embed(ctx, &entities.EmbedRequest{
+			Inputs:    entities.Input{Data: numbers(2)},
+			Normalize: entities.BoolPtr(false),
+		})
+		gaveUp <- err
+	}()
+	waitForGauge(t, metrics.CoalescedPending, base+4)
+
+	cancel()
+	if err := <-gaveUp; err == nil {
+		t.Error("canceled waiter succeeded, want its context error")
+	}
+	waitForGauge(t, metrics.CoalescedPending, base+3)
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("Embed: %v", err)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.CoalescedPending) - base; got != 0 {
+		t.Errorf("coalesced pending after completion = %v, want 0", got)
+	}
+	if got := tei.batchCount(); got != 1 {
+		t.Errorf("TEI received %d requests, want 1 shared by every waiter", got)
+	}
+}
@@ -0,0 +1,69 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+)
+
+// embedShared embeds req through the cache or TEI. With request coalescing
+// enabled, concurrent identical requests share a single call: it runs
+// detached from the caller's cancellation, so a waiter that gives up does
+// not fail the others, and each waiter that did not start the call gets
+// its own copy of the result.
+func (s *Service) embedShared(ctx context.Context, req *entities.EmbedRequest) ([][]float32, error) {
+	if !s.config.CoalesceRequests {
+		return s.embedOnce(ctx, req)
+	}
+
+	metrics.CoalescedPending.Inc()
+	defer metrics.CoalescedPending.Dec()
+	flight := s.flights.DoChan(flightKey(req), func() (any, error) {
+		return s.embedOnce(context.WithoutCancel(ctx), req)
+	})
+
+	select {
+	case result := <-flight:
+		embeddings, _ := result.Val.([][]float32)
+		if result.Shared {
+			embeddings = copyEmbeddings(embeddings)
+		}
+		return embeddings, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Service) embedOnce(ctx context.Context, req *entities.EmbedRequest) ([][]float32, error) {
+	if s.cache != nil {
+		return s.embedCached(ctx, req)
+	}
+	return s.embedUncached(ctx, req)
+}
+
+// flightKey identifies a request by its exact inputs and every option that
+// changes the embeddings or how failures are reported
+func flightKey(req *entities.EmbedRequest) string {
+	hash := sha256.New()
+	hash.Write([]byte(strconv.FormatBool(*req.AutoBatch)))
+	hash.Write([]byte(strconv.FormatBool(*req.PartialResults)))
+	for _, input := range req.Inputs.Data {
+		hash.Write([]byte(DefaultCacheKey(req, input)))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func copyEmbeddings(embeddings [][]float32) [][]float32 {
+	if embeddings == nil {
+		return nil
+	}
+	copied := make([][]float32, len(embeddings))
+	for i, embedding := range embeddings {
+		copied[i] = append([]float32(nil), embedding...)
+	}
+	return copied
+}
@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+
+	"go.uber.org/zap"
+)
+
+// EmbedHybrid computes dense and sparse embeddings of the same inputs
+// concurrently. Each leg gets its own copy of the inputs, since prompt and
+// role handling rewrite them in place. A failure in either leg fails the
+// call, and both failures are reported when both legs fail.
+func (s *Service) EmbedHybrid(ctx context.Context, req *entities.EmbedHybridRequest) (*entities.EmbedHybridResponse, error) {
+	ctx = logging.WithFields(ctx, zap.Int("batch_size", len(req.Inputs.Data)))
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("Processing hybrid embed request",
+		zap.Int("input_count", len(req.Inputs.Data)),
+	)
+
+	var (
+		wg                  sync.WaitGroup
+		dense               *entities.EmbedResponse
+		sparse              *entities.EmbedSparseResponse
+		denseErr, sparseErr error
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		dense, denseErr = s.Embed(ctx, req.DenseRequest())
+	}()
+	go func() {
+		defer wg.Done()
+		sparse, sparseErr = s.EmbedSparse(ctx, req.SparseRequest())
+	}()
+	wg.Wait()
+
+	var errs []error
+	if denseErr != nil {
+		errs = append(errs, fmt.Errorf("dense embedding failed: %w", denseErr))
+	}
+	if sparseErr != nil {
+		errs = append(errs, fmt.Errorf("sparse embedding failed: %w", sparseErr))
+	}
+	if len(errs) > 0 {
+		err := stderrors.Join(errs...)
+		logger.Error("Hybrid embed request failed", zap.Error(err))
+		return nil, err
+	}
+
+	return &entities.EmbedHybridResponse{
+		Dense:  dense.Embeddings,
+		Sparse: sparse.Embeddings,
+	}, nil
+}
@@ -0,0 +1,54 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// EmbedImage embeds images with a multimodal model, returning one vector
+// per image like Embed does for text
+func (s *Service) EmbedImage(ctx context.Context, req *entities.EmbedImageRequest) (*entities.EmbedResponse, error) {
+	ctx = logging.WithFields(ctx, zap.Int("batch_size", len(req.Images)))
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("Processing embed image request",
+		zap.Int("input_count", len(req.Images)),
+	)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("embedding.input_count", len(req.Images)))
+
+	req.SetDefaults()
+
+	if err := s.validator.ValidateImages(req.Images, "images"); err != nil {
+		logger.Error("Embed image request validation failed", zap.Error(err))
+		return nil, err
+	}
+
+	responseData, err := s.httpClient.Post(ctx, entities.EndpointEmbed, req)
+	if err != nil {
+		logger.Error("Embed image request failed", zap.Error(err))
+		return nil, fmt.Errorf("embed request failed: %w", err)
+	}
+
+	response, err := decodeEmbeddings[[]float32](logger, entities.EndpointEmbed, responseData, len(req.Images))
+	if err != nil {
+		return nil, err
+	}
+	for _, embedding := range response {
+		if len(embedding) == 0 {
+			return nil, errors.NewTEIError("TEI returned an empty embedding", errors.ErrorTypeResponseMalformed)
+		}
+	}
+
+	if *req.Normalize {
+		s.checkNorms(response)
+	}
+
+	return &entities.EmbedResponse{Embeddings: response}, nil
+}
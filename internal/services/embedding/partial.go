@@ -0,0 +1,65 @@
+package embedding
+
+import (
+	stderrors "errors"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+)
+
+// partialFailures expands a BatchError into one error per input, nil for
+// the inputs whose embeddings were returned. It returns nil when err is not
+// a BatchError or no input succeeded, so that the request fails as a whole.
+func partialFailures(err error, embeddings [][]float32) []error {
+	var batchErr *errors.BatchError
+	if !stderrors.As(err, &batchErr) || embeddings == nil {
+		return nil
+	}
+
+	failed := make([]error, len(embeddings))
+	count := 0
+	for _, failure := range batchErr.Failures {
+		for i := failure.Start; i < failure.End; i++ {
+			failed[i] = failure.Err
+			count++
+		}
+	}
+
+	if count == len(embeddings) {
+		return nil
+	}
+	return failed
+}
+
+// fanOutFailures expands the errors of distinct inputs back to one per
+// original input, like fanOut does for embeddings
+func fanOutFailures(failed []error, positions []int) []error {
+	expanded := make([]error, len(positions))
+	for i, j := range positions {
+		expanded[i] = failed[j]
+	}
+	return expanded
+}
+
+// mergePartialMisses fills embeddings with the cache misses that TEI did
+// embed, caching them, and reports the failed misses at their positions in
+// the full request. Each failed input gets its own entry in the returned
+// BatchError since the misses are not contiguous.
+func (s *Service) mergePartialMisses(keys []string, embeddings [][]float32, missing []int,
+	response [][]float32, batchErr *errors.BatchError) ([][]float32, error) {
+	merged := &errors.BatchError{}
+	for _, failure := range batchErr.Failures {
+		for j := failure.Start; j < failure.End; j++ {
+			merged.Add(missing[j], missing[j]+1, failure.Err)
+		}
+	}
+
+	for j, i := range missing {
+		if response[j] == nil {
+			continue
+		}
+		embeddings[i] = response[j]
+		s.cache.Set(keys[i], response[j])
+	}
+
+	return embeddings, merged
+}
@@ -0,0 +1,57 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+
+	"go.uber.org/zap"
+)
+
+// checkBatchTokens tokenizes a batch about to be sent to /embed and rejects
+// it when it exceeds the model's maximum batch tokens, or an input exceeds
+// the maximum input length without truncation, sparing a request TEI would
+// refuse. It is best effort: the batch passes when the model's limits are
+// unknown or tokenization fails.
+func (s *Service) checkBatchTokens(ctx context.Context, req *entities.EmbedRequest) error {
+	logger := logging.FromContext(ctx, s.logger)
+	validationCfg := s.validator.Config()
+	if validationCfg.MaxBatchTokens <= 0 && validationCfg.MaxInputTokens <= 0 {
+		return nil
+	}
+
+	tokenizeReq := &entities.TokenizeRequest{
+		Inputs:     req.Inputs,
+		PromptName: req.PromptName,
+	}
+	tokenizeReq.SetDefaults()
+
+	responseData, err := s.httpClient.Post(ctx, entities.EndpointTokenize, tokenizeReq)
+	if err != nil {
+		logger.Debug("Token pre-flight check skipped, tokenize failed", zap.Error(err))
+		return nil
+	}
+
+	var tokens [][]entities.Token
+	if err := json.Unmarshal(responseData, &tokens); err != nil || len(tokens) != len(req.Inputs.Data) {
+		logger.Debug("Token pre-flight check skipped, unexpected tokenize response")
+		return nil
+	}
+
+	counts := make([]int, len(tokens))
+	for i, inputTokens := range tokens {
+		counts[i] = len(inputTokens)
+		// Truncated inputs only count up to the maximum input length
+		if *req.Truncate && validationCfg.MaxInputTokens > 0 {
+			counts[i] = min(counts[i], validationCfg.MaxInputTokens)
+		}
+	}
+
+	if validationErr := s.validator.ValidateTokenCounts(counts, "inputs"); validationErr != nil {
+		logger.Debug("Batch rejected by token pre-flight check", zap.Error(validationErr))
+		return validationErr
+	}
+	return nil
+}
@@ -1,109 +1,749 @@
 package embedding
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
 
+	"github.com/blackprince001/embedding-inference/internal/config"
 	"github.com/blackprince001/embedding-inference/internal/domain/entities"
 	"github.com/blackprince001/embedding-inference/internal/domain/errors"
 	"github.com/blackprince001/embedding-inference/internal/domain/interfaces"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/cache"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 )
 
 type Service struct {
 	httpClient interfaces.HTTPClient
+	config     *config.EmbeddingConfig
+	cache      *cache.Cache
+	prompts    *entities.PromptRegistry
+	roles      *entities.RolePrefixRegistry
 	logger     *zap.Logger
 	validator  *entities.Validator
+	cacheKey   CacheKeyFunc
+	flights    singleflight.Group
+
+	// dimension is the model's native embedding length as last observed
+	// from TEI, used to validate Dimensions and size degraded zero vectors
+	dimension atomic.Int64
 }
 
-func NewService(httpClient interfaces.HTTPClient, logger *zap.Logger) *Service {
+// NewService creates an embedding service. embeddingCache may be nil, in
+// which case every request goes to TEI.
+func NewService(httpClient interfaces.HTTPClient, cfg *config.EmbeddingConfig, embeddingCache *cache.Cache, rolePrefixes *entities.RolePrefixRegistry, validator *entities.Validator, logger *zap.Logger) *Service {
 	return &Service{
 		httpClient: httpClient,
+		config:     cfg,
+		cache:      embeddingCache,
+		prompts:    entities.NewPromptRegistry(cfg.Prompts),
+		roles:      rolePrefixes,
 		logger:     logger.Named("embedding"),
-		validator:  entities.NewValidator(entities.DefaultValidationConfig()),
+		validator:  validator,
+		cacheKey:   DefaultCacheKey,
+	}
+}
+
+// SetCacheKeyFunc replaces how cache keys are derived, for example to
+// treat inputs differing only in case as the same. A nil fn restores
+// DefaultCacheKey. It must be called before the service handles requests.
+func (s *Service) SetCacheKeyFunc(fn CacheKeyFunc) {
+	if fn == nil {
+		fn = DefaultCacheKey
 	}
+	s.cacheKey = fn
 }
 
 func (s *Service) Embed(ctx context.Context, req *entities.EmbedRequest) (*entities.EmbedResponse, error) {
-	s.logger.Debug("Processing embed request",
+	ctx = logging.WithFields(ctx, zap.Int("batch_size", len(req.Inputs.Data)))
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("Processing embed request",
 		zap.Int("input_count", len(req.Inputs.Data)),
 		zap.Bool("normalize", req.Normalize != nil && *req.Normalize),
 	)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("embedding.input_count", len(req.Inputs.Data)))
 
-	req.SetDefaults()
+	inputs := req.Inputs.Data
+	if err := s.prepareEmbedRequest(req); err != nil {
+		return nil, err
+	}
 
 	if err := s.validator.ValidateEmbedRequest(req); err != nil {
-		s.logger.Error("Embed request validation failed", zap.Error(err))
+		logger.Error("Embed request validation failed", zap.Error(err))
+		return nil, err
+	}
+
+	if native := int(s.dimension.Load()); req.Dimensions != nil && native > 0 && *req.Dimensions > native {
+		return nil, errors.NewValidationError("dimensions",
+			"exceeds the model's embedding dimension", map[string]any{
+				"dimensions":     *req.Dimensions,
+				"max_dimensions": native,
+			})
+	}
+
+	// With deduplication only the distinct inputs are embedded; positions
+	// maps every original input to its distinct one
+	embedReq := req
+	var positions []int
+	if *req.Deduplicate {
+		var unique []string
+		if unique, positions = dedupe(req.Inputs.Data); len(unique) < len(req.Inputs.Data) {
+			uniqueReq := *req
+			uniqueReq.Inputs = entities.Input{Data: unique}
+			embedReq = &uniqueReq
+		} else {
+			positions = nil
+		}
+	}
+
+	embeddings, err := s.embedShared(ctx, embedReq)
+	var failed []error
+	if err != nil && *req.PartialResults {
+		if failed = partialFailures(err, embeddings); failed != nil {
+			err = nil
+		}
+	}
+	if err == nil && positions != nil {
+		embeddings = fanOut(embeddings, positions)
+		if failed != nil {
+			failed = fanOutFailures(failed, positions)
+		}
+	}
+
+	degraded := false
+	if err != nil {
+		if req.AllowDegraded == nil || !*req.AllowDegraded || !isOutage(err) {
+			return nil, err
+		}
+		if embeddings, degraded = s.degradedEmbeddings(embedReq, embeddings, err); !degraded {
+			return nil, err
+		}
+		if positions != nil {
+			embeddings = fanOut(embeddings, positions)
+		}
+		logger.Warn("TEI unavailable, serving degraded embeddings",
+			zap.Int("input_count", len(req.Inputs.Data)),
+			zap.Error(err),
+		)
+	}
+
+	resp := &entities.EmbedResponse{Embeddings: embeddings, Degraded: degraded}
+	for i, inputErr := range failed {
+		if inputErr != nil {
+			resp.Errors = append(resp.Errors, entities.InputError{Index: i, Err: inputErr})
+		}
+	}
+	if len(resp.Errors) > 0 {
+		logger.Warn("Returning partial embed results",
+			zap.Int("failed_inputs", len(resp.Errors)),
+			zap.Int("input_count", len(embeddings)),
+		)
+	}
+	if s.config.DetectTruncation && *req.Truncate && !degraded {
+		resp.Truncated = s.detectTruncation(ctx, req)
+	}
+	if req.EncodingFormat == entities.EncodingInt8 {
+		resp.Quantized = make([]entities.QuantizedEmbedding, len(embeddings))
+		for i, embedding := range embeddings {
+			if resp.Quantized[i], err = entities.QuantizeInt8(embedding); err != nil {
+				logger.Error("Failed to quantize embedding", zap.Int("index", i), zap.Error(err))
+				return nil, err
+			}
+		}
+		resp.Embeddings = nil
+	}
+	if req.EchoRequest != nil && *req.EchoRequest {
+		resp.Echo = entities.NewRequestEcho(inputs)
+	}
+
+	return resp, nil
+}
+
+// EmbedPlainText embeds a single text sent to TEI as a text/plain body,
+// skipping JSON encoding of the request. TEI applies its own defaults, so
+// the vector is normalized and the input is not truncated; the cache and
+// local prompt handling are bypassed.
+func (s *Service) EmbedPlainText(ctx context.Context, text string) ([]float32, error) {
+	logger := logging.FromContext(ctx, s.logger)
+	if err := s.validator.ValidateText(text, "input"); err != nil {
+		return nil, err
+	}
+
+	responseData, err := s.httpClient.PostRaw(ctx, entities.EndpointEmbed, []byte(text), entities.ContentTypeTextPlain)
+	if err != nil {
+		logger.Error("Plain text embed request failed", zap.Error(err))
+		return nil, fmt.Errorf("embed request failed: %w", err)
+	}
+
+	response, err := decodeEmbeddings[[]float32](logger, entities.EndpointEmbed, responseData, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(response[0]) == 0 {
+		return nil, errors.NewTEIError("TEI returned an empty embedding", errors.ErrorTypeResponseMalformed)
+	}
+
+	return response[0], nil
+}
+
+// ValidateEmbed checks req as Embed would, without calling TEI, and returns
+// every violation found, or nil if the request is valid
+func (s *Service) ValidateEmbed(req *entities.EmbedRequest) *errors.MultiValidationError {
+	if err := s.prepareEmbedRequest(req); err != nil {
+		var validationErr *errors.ValidationError
+		if stderrors.As(err, &validationErr) {
+			return &errors.MultiValidationError{Errors: []errors.ValidationError{*validationErr}}
+		}
+	}
+
+	return s.validator.CollectEmbedRequestErrors(req)
+}
+
+// prepareEmbedRequest fills in defaults and applies role prefixes and local
+// prompt templates, leaving req holding the text to send to TEI
+func (s *Service) prepareEmbedRequest(req *entities.EmbedRequest) error {
+	if req.AutoBatch == nil {
+		req.AutoBatch = entities.BoolPtr(s.config.AutoBatch)
+	}
+	if req.Deduplicate == nil {
+		req.Deduplicate = entities.BoolPtr(s.config.Deduplicate)
+	}
+	if req.PartialResults == nil {
+		req.PartialResults = entities.BoolPtr(false)
+	}
+	if req.InputRole == "" {
+		s.applyRequestDefaults(&req.PromptName, &req.Truncate, &req.TruncationDirection)
+	} else {
+		s.applyRequestDefaults(nil, &req.Truncate, &req.TruncationDirection)
+	}
+	req.SetDefaults()
+
+	if req.PromptName == nil {
+		req.Inputs.Data = s.roles.Prefixes().Apply(req.InputRole, req.Inputs.Data)
+	}
+
+	return s.expandPrompt(&req.PromptName, &req.Inputs)
+}
+
+// degradedEmbeddings answers the inputs of a request that TEI could not
+// embed, using the cache where possible and zero vectors of the last seen
+// dimension otherwise. When err is a BatchError only the inputs of the
+// failed batches are replaced, keeping the embeddings of the batches that
+// succeeded. It returns false when the dimension is not known yet.
+func (s *Service) degradedEmbeddings(req *entities.EmbedRequest, embeddings [][]float32, err error) ([][]float32, bool) {
+	dimension := int(s.dimension.Load())
+	if req.Dimensions != nil {
+		dimension = *req.Dimensions
+	}
+	if dimension == 0 {
+		s.logger.Error("Cannot serve degraded embeddings before the embedding dimension is known")
+		return nil, false
+	}
+
+	failed := make([]bool, len(req.Inputs.Data))
+	var batchErr *errors.BatchError
+	if stderrors.As(err, &batchErr) && len(embeddings) == len(failed) {
+		for _, failure := range batchErr.Failures {
+			for i := failure.Start; i < failure.End; i++ {
+				failed[i] = true
+			}
+		}
+	} else {
+		embeddings = make([][]float32, len(failed))
+		for i := range failed {
+			failed[i] = true
+		}
+	}
+
+	for i, input := range req.Inputs.Data {
+		if !failed[i] {
+			continue
+		}
+		if s.cache != nil {
+			if embedding, ok := s.cache.Get(s.cacheKey(req, input)); ok {
+				embeddings[i] = embedding
+				metrics.DegradedResponses.WithLabelValues("cache").Inc()
+				continue
+			}
+		}
+		embeddings[i] = make([]float32, dimension)
+		metrics.DegradedResponses.WithLabelValues("zero").Inc()
+	}
+
+	return embeddings, true
+}
+
+// embedCached serves inputs from the cache where possible and only sends
+// the misses to TEI.
+func (s *Service) embedCached(ctx context.Context, req *entities.EmbedRequest) ([][]float32, error) {
+	logger := logging.FromContext(ctx, s.logger)
+	inputs := req.Inputs.Data
+	embeddings := make([][]float32, len(inputs))
+	keys := make([]string, len(inputs))
+
+	var missing []int
+	for i, input := range inputs {
+		keys[i] = s.cacheKey(req, input)
+		if embedding, ok := s.cache.Get(keys[i]); ok {
+			embeddings[i] = embedding
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	logger.Debug("Embedding cache lookup",
+		zap.Int("hits", len(inputs)-len(missing)),
+		zap.Int("misses", len(missing)),
+	)
+
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	missData := make([]string, len(missing))
+	for j, i := range missing {
+		missData[j] = inputs[i]
+	}
+
+	missReq := *req
+	missReq.Inputs = entities.Input{Data: missData}
+
+	response, err := s.embedUncached(ctx, &missReq)
+	if err != nil {
+		var batchErr *errors.BatchError
+		if !*req.PartialResults || response == nil || !stderrors.As(err, &batchErr) {
+			return nil, err
+		}
+		return s.mergePartialMisses(keys, embeddings, missing, response, batchErr)
+	}
+
+	if err := checkEmbeddingCount(logger, len(missing), len(response)); err != nil {
 		return nil, err
 	}
 
+	for j, i := range missing {
+		embeddings[i] = response[j]
+		s.cache.Set(keys[i], response[j])
+	}
+
+	return embeddings, nil
+}
+
+func (s *Service) embedUncached(ctx context.Context, req *entities.EmbedRequest) ([][]float32, error) {
+	maxBatchSize := s.validator.Config().MaxBatchSize
+	if *req.AutoBatch && len(req.Inputs.Data) > maxBatchSize {
+		return s.embedBatched(ctx, req, maxBatchSize)
+	}
+
+	return s.embed(ctx, req)
+}
+
+func (s *Service) embed(ctx context.Context, req *entities.EmbedRequest) ([][]float32, error) {
+	logger := logging.FromContext(ctx, s.logger)
+	if s.config.PreflightTokens {
+		if err := s.checkBatchTokens(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
 	responseData, err := s.httpClient.Post(ctx, entities.EndpointEmbed, req)
 	if err != nil {
-		s.logger.Error("Embed request failed", zap.Error(err))
+		logger.Error("Embed request failed", zap.Error(err))
 		return nil, fmt.Errorf("embed request failed: %w", err)
 	}
 
-	var response [][]float32
-	if err := json.Unmarshal(responseData, &response); err != nil {
-		s.logger.Error("Failed to parse embed response", zap.Error(err))
-		return nil, errors.NewTEIError("failed to parse response", errors.ErrorTypeBackend)
+	response, err := decodeEmbeddings[[]float32](logger, entities.EndpointEmbed, responseData, len(req.Inputs.Data))
+	if err != nil {
+		return nil, err
+	}
+	for _, embedding := range response {
+		if len(embedding) == 0 {
+			return nil, errors.NewTEIError("TEI returned an empty embedding", errors.ErrorTypeResponseMalformed)
+		}
+	}
+
+	if len(response) > 0 {
+		native := len(response[0])
+		if req.Dimensions == nil || native > *req.Dimensions {
+			s.dimension.Store(int64(native))
+		}
 	}
 
-	return &entities.EmbedResponse{Embeddings: response}, nil
+	if req.Dimensions != nil {
+		truncateDimensions(response, *req.Dimensions, *req.Normalize)
+	}
+
+	if *req.Normalize {
+		s.checkNorms(response)
+	}
+
+	return response, nil
+}
+
+// decodeEmbeddings parses a TEI response holding one entry per input. An
+// empty body, a malformed one and a wrong number of entries are all
+// backend errors, since the request itself was valid.
+func decodeEmbeddings[T any](logger *zap.Logger, endpoint string, data []byte, expected int) ([]T, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		logger.Error("Empty response from TEI", zap.String("endpoint", endpoint))
+		return nil, errors.NewTEIError("empty response from TEI", errors.ErrorTypeResponseMalformed)
+	}
+
+	response, err := entities.DecodeEmbeddings[T](data)
+	if err != nil {
+		logger.Error("Failed to parse response", zap.String("endpoint", endpoint), zap.Error(err))
+		return nil, errors.NewTEIError("failed to parse response", errors.ErrorTypeResponseMalformed)
+	}
+
+	if err := checkEmbeddingCount(logger, expected, len(response)); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// checkEmbeddingCount fails unless TEI returned one embedding per input
+func checkEmbeddingCount(logger *zap.Logger, expected, received int) error {
+	if received == expected {
+		return nil
+	}
+
+	logger.Error("Response embedding count mismatch",
+		zap.Int("expected", expected),
+		zap.Int("received", received),
+	)
+	if received == 0 {
+		return errors.NewTEIError("TEI returned no embeddings", errors.ErrorTypeResponseMismatch)
+	}
+	return errors.NewTEIError("response embedding count mismatch", errors.ErrorTypeResponseMismatch)
+}
+
+// checkNorms verifies that normalized embeddings have unit length,
+// logging and optionally re-normalizing those that do not
+func (s *Service) checkNorms(embeddings [][]float32) {
+	if s.config.NormCheck == "" || s.config.NormCheck == "off" {
+		return
+	}
+
+	renormalize := s.config.NormCheck == "renormalize"
+	violations := 0
+	worst := 1.0
+	for _, embedding := range embeddings {
+		norm := entities.L2Norm(embedding)
+		if norm == 0 || math.Abs(norm-1) <= s.config.NormTolerance {
+			continue
+		}
+
+		violations++
+		if math.Abs(norm-1) > math.Abs(worst-1) {
+			worst = norm
+		}
+		if renormalize {
+			entities.L2Normalize(embedding)
+		}
+	}
+
+	if violations > 0 {
+		metrics.NormViolations.Add(float64(violations))
+		s.logger.Warn("TEI returned non-unit vectors for a normalized request",
+			zap.Int("violations", violations),
+			zap.Float64("worst_norm", worst),
+			zap.Bool("renormalized", renormalize),
+		)
+	}
+}
+
+// truncateDimensions shortens Matryoshka embeddings that TEI returned at
+// full size, re-normalizing them when normalized output was requested
+func truncateDimensions(embeddings [][]float32, dimensions int, normalize bool) {
+	for i, embedding := range embeddings {
+		if len(embedding) <= dimensions {
+			continue
+		}
+		embeddings[i] = embedding[:dimensions]
+		if normalize {
+			entities.L2Normalize(embeddings[i])
+		}
+	}
+}
+
+// embedBatched splits the request into sub-batches of at most batchSize
+// inputs, embeds them concurrently and concatenates the results in input
+// order.
+func (s *Service) embedBatched(ctx context.Context, req *entities.EmbedRequest, batchSize int) ([][]float32, error) {
+	logger := logging.FromContext(ctx, s.logger)
+	inputs := req.Inputs.Data
+
+	logger.Debug("Splitting embed request into sub-batches",
+		zap.Int("input_count", len(inputs)),
+		zap.Int("batch_size", batchSize),
+		zap.Int("max_concurrent_batches", s.config.MaxConcurrentBatches),
+	)
+
+	embeddings, err := runBatches(ctx, len(inputs), batchSize, s.config.MaxConcurrentBatches,
+		func(ctx context.Context, start, end int) ([][]float32, error) {
+			subReq := *req
+			subReq.Inputs = entities.Input{Data: inputs[start:end]}
+			return s.embed(ctx, &subReq)
+		})
+	if err != nil {
+		logger.Error("Embed sub-batches failed", zap.Error(err))
+		return embeddings, err
+	}
+
+	return embeddings, nil
 }
 
 func (s *Service) EmbedAll(ctx context.Context, req *entities.EmbedAllRequest) (*entities.EmbedAllResponse, error) {
-	s.logger.Debug("Processing embed_all request",
+	ctx = logging.WithFields(ctx, zap.Int("batch_size", len(req.Inputs.Data)))
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("Processing embed_all request",
 		zap.Int("input_count", len(req.Inputs.Data)),
 	)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("embedding.input_count", len(req.Inputs.Data)))
 
+	if req.AutoBatch == nil {
+		req.AutoBatch = entities.BoolPtr(s.config.AutoBatch)
+	}
+	s.applyRequestDefaults(&req.PromptName, &req.Truncate, &req.TruncationDirection)
 	req.SetDefaults()
 
-	if err := req.Validate(); err != nil {
-		s.logger.Error("EmbedAll request validation failed", zap.Error(err))
+	if err := s.expandPrompt(&req.PromptName, &req.Inputs); err != nil {
 		return nil, err
 	}
 
+	if err := s.validator.ValidateEmbedAllRequest(req); err != nil {
+		logger.Error("EmbedAll request validation failed", zap.Error(err))
+		return nil, err
+	}
+
+	inputs := req.Inputs.Data
+	maxBatchSize := s.validator.Config().MaxBatchSize
+	if !*req.AutoBatch || len(inputs) <= maxBatchSize {
+		response, err := s.embedAll(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &entities.EmbedAllResponse{Embeddings: capTokens(response, req.MaxTokensPerInput)}, nil
+	}
+
+	response, err := runBatches(ctx, len(inputs), maxBatchSize, s.config.MaxConcurrentBatches,
+		func(ctx context.Context, start, end int) ([][][]float32, error) {
+			subReq := *req
+			subReq.Inputs = entities.Input{Data: inputs[start:end]}
+			return s.embedAll(ctx, &subReq)
+		})
+	if err != nil {
+		logger.Error("EmbedAll sub-batches failed", zap.Error(err))
+		return nil, err
+	}
+
+	return &entities.EmbedAllResponse{Embeddings: capTokens(response, req.MaxTokensPerInput)}, nil
+}
+
+// capTokens keeps at most limit token embeddings of each input; a nil
+// limit keeps them all
+func capTokens(embeddings [][][]float32, limit *int) [][][]float32 {
+	if limit == nil {
+		return embeddings
+	}
+	for i, tokens := range embeddings {
+		if len(tokens) > *limit {
+			embeddings[i] = tokens[:*limit]
+		}
+	}
+	return embeddings
+}
+
+func (s *Service) embedAll(ctx context.Context, req *entities.EmbedAllRequest) ([][][]float32, error) {
+	logger := logging.FromContext(ctx, s.logger)
 	responseData, err := s.httpClient.Post(ctx, entities.EndpointEmbedAll, req)
 	if err != nil {
-		s.logger.Error("EmbedAll request failed", zap.Error(err))
+		logger.Error("EmbedAll request failed", zap.Error(err))
 		return nil, fmt.Errorf("embed_all request failed: %w", err)
 	}
 
-	var response [][][]float32
-	if err := json.Unmarshal(responseData, &response); err != nil {
-		s.logger.Error("Failed to parse embed_all response", zap.Error(err))
-		return nil, errors.NewTEIError("failed to parse response", errors.ErrorTypeBackend)
+	response, err := decodeEmbeddings[[][]float32](logger, entities.EndpointEmbedAll, responseData, len(req.Inputs.Data))
+	if err != nil {
+		return nil, err
 	}
 
-	return &entities.EmbedAllResponse{Embeddings: response}, nil
+	return response, nil
 }
 
 func (s *Service) EmbedSparse(ctx context.Context, req *entities.EmbedSparseRequest) (*entities.EmbedSparseResponse, error) {
-	s.logger.Debug("Processing embed_sparse request",
+	ctx = logging.WithFields(ctx, zap.Int("batch_size", len(req.Inputs.Data)))
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("Processing embed_sparse request",
 		zap.Int("input_count", len(req.Inputs.Data)),
 	)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("embedding.input_count", len(req.Inputs.Data)))
 
+	s.applyRequestDefaults(&req.PromptName, &req.Truncate, &req.TruncationDirection)
 	req.SetDefaults()
 
-	if err := req.Validate(); err != nil {
-		s.logger.Error("EmbedSparse request validation failed", zap.Error(err))
+	if err := s.expandPrompt(&req.PromptName, &req.Inputs); err != nil {
+		return nil, err
+	}
+
+	if err := s.validator.ValidateEmbedSparseRequest(req); err != nil {
+		logger.Error("EmbedSparse request validation failed", zap.Error(err))
 		return nil, err
 	}
 
 	responseData, err := s.httpClient.Post(ctx, entities.EndpointEmbedSparse, req)
 	if err != nil {
-		s.logger.Error("EmbedSparse request failed", zap.Error(err))
+		logger.Error("EmbedSparse request failed", zap.Error(err))
 		return nil, fmt.Errorf("embed_sparse request failed: %w", err)
 	}
 
-	var response [][]entities.SparseValue
-	if err := json.Unmarshal(responseData, &response); err != nil {
-		s.logger.Error("Failed to parse embed_sparse response", zap.Error(err))
-		return nil, errors.NewTEIError("failed to parse response", errors.ErrorTypeBackend)
+	response, err := decodeEmbeddings[[]entities.SparseValue](logger, entities.EndpointEmbedSparse, responseData, len(req.Inputs.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TopK != nil {
+		for i, embedding := range response {
+			response[i] = entities.PruneSparse(embedding, *req.TopK)
+		}
 	}
 
 	return &entities.EmbedSparseResponse{Embeddings: response}, nil
 }
+
+// applyRequestDefaults fills in the configured prompt name, truncation and
+// truncation direction when the request leaves them unset. promptName may
+// be nil to leave the prompt alone.
+func (s *Service) applyRequestDefaults(promptName **string, truncate **bool, direction *entities.TruncationDirection) {
+	if promptName != nil && *promptName == nil && s.config.DefaultPromptName != "" {
+		*promptName = entities.StringPtr(s.config.DefaultPromptName)
+	}
+	if *truncate == nil {
+		*truncate = entities.BoolPtr(s.config.DefaultTruncate)
+	}
+	if *direction == "" {
+		*direction = s.defaultTruncationDirection()
+	}
+}
+
+// defaultTruncationDirection maps the configured direction to the value
+// TEI expects
+func (s *Service) defaultTruncationDirection() entities.TruncationDirection {
+	if s.config.DefaultTruncationDirection == "left" {
+		return entities.TruncationLeft
+	}
+	return entities.TruncationRight
+}
+
+// expandPrompt applies a locally registered prompt template to inputs and
+// clears the prompt name, so TEI receives the final text. It does nothing
+// unless local expansion is enabled.
+func (s *Service) expandPrompt(promptName **string, inputs *entities.Input) error {
+	if !s.config.ExpandPrompts || *promptName == nil {
+		return nil
+	}
+
+	expanded, err := s.prompts.Expand(**promptName, inputs.Data)
+	if err != nil {
+		return err
+	}
+
+	inputs.Data = expanded
+	*promptName = nil
+	return nil
+}
+
+// dedupe returns the distinct inputs in first-seen order and, for every
+// input, the index of its distinct copy
+func dedupe(inputs []string) ([]string, []int) {
+	unique := make([]string, 0, len(inputs))
+	positions := make([]int, len(inputs))
+	seen := make(map[string]int, len(inputs))
+
+	for i, input := range inputs {
+		j, ok := seen[input]
+		if !ok {
+			j = len(unique)
+			seen[input] = j
+			unique = append(unique, input)
+		}
+		positions[i] = j
+	}
+
+	return unique, positions
+}
+
+// fanOut expands embeddings of distinct inputs back to one per original
+// input. Duplicates get their own copy so callers may modify them freely.
+func fanOut(embeddings [][]float32, positions []int) [][]float32 {
+	used := make([]bool, len(embeddings))
+	expanded := make([][]float32, len(positions))
+
+	for i, j := range positions {
+		if !used[j] {
+			used[j] = true
+			expanded[i] = embeddings[j]
+			continue
+		}
+		expanded[i] = append([]float32(nil), embeddings[j]...)
+	}
+
+	return expanded
+}
+
+// CacheKeyFunc derives the cache key of one input of req. It sees the
+// request after defaults are applied and prompts are expanded, and must
+// give equal keys only to inputs whose embeddings may be shared.
+type CacheKeyFunc func(req *entities.EmbedRequest, input string) string
+
+// DefaultCacheKey identifies an embedding by the SHA-256 of its exact input
+// text and every request parameter that changes the resulting vector.
+// Custom CacheKeyFuncs can normalize the input and then call it.
+func DefaultCacheKey(req *entities.EmbedRequest, input string) string {
+	var promptName, dimensions string
+	if req.PromptName != nil {
+		promptName = *req.PromptName
+	}
+	if req.Dimensions != nil {
+		dimensions = strconv.Itoa(*req.Dimensions)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		strconv.FormatBool(*req.Normalize),
+		dimensions,
+		strconv.FormatBool(*req.Truncate),
+		string(req.TruncationDirection),
+		promptName,
+		input,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// isOutage reports whether err shows TEI is unreachable rather than the
+// request being bad. A BatchError is an outage only if every failed batch is.
+func isOutage(err error) bool {
+	var batchErr *errors.BatchError
+	if stderrors.As(err, &batchErr) {
+		for _, failure := range batchErr.Failures {
+			if !isOutage(failure.Err) {
+				return false
+			}
+		}
+		return batchErr.HasErrors()
+	}
+
+	var teiErr *errors.TEIError
+	return stderrors.As(err, &teiErr) && teiErr.IsOutage()
+}
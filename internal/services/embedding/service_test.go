@@ -0,0 +1,450 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/cache"
+
+	"go.uber.org/zap"
+)
+
+// fakeTEI answers /embed with the vector [n, 1] for an input that is the
+// decimal number n, recording every batch it receives. fail, when set,
+// decides which batches fail and with what error.
+type fakeTEI struct {
+	mu      sync.Mutex
+	batches [][]string
+	fail    func(inputs []string) error
+}
+
+func (f *fakeTEI) Post(_ context.Context, endpoint string, body any) ([]byte, error) {
+	if endpoint != entities.EndpointEmbed {
+		return nil, errors.NewTEIError("unexpected endpoint "+endpoint, errors.ErrorTypeBackend)
+	}
+	inputs := body.(*entities.EmbedRequest).Inputs.Data
+
+	f.mu.Lock()
+	f.batches = append(f.batches, inputs)
+	fail := f.fail
+	f.mu.Unlock()
+
+	if fail != nil {
+		if err := fail(inputs); err != nil {
+			return nil, err
+		}
+	}
+
+	embeddings := make([][]float32, len(inputs))
+	for i, input := range inputs {
+		n, err := strconv.Atoi(input)
+		if err != nil {
+			return nil, errors.NewTEIError("fake TEI only embeds numbers", errors.ErrorTypeValidation)
+		}
+		embeddings[i] = []float32{float32(n), 1}
+	}
+	return json.Marshal(embeddings)
+}
+
+func (f *fakeTEI) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.NewTEIError("not implemented", errors.ErrorTypeBackend)
+}
+
+func (f *fakeTEI) PostRaw(context.Context, string, []byte, string) ([]byte, error) {
+	return nil, errors.NewTEIError("not implemented", errors.ErrorTypeBackend)
+}
+
+func (f *fakeTEI) SetTimeout(time.Duration) {}
+
+func (f *fakeTEI) Close() error { return nil }
+
+func (f *fakeTEI) batchCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.batches)
+}
+
+// newTestService builds a Service over tei with the default validation
+// limits
+func newTestService(tei *fakeTEI, cfg *config.EmbeddingConfig, embeddingCache *cache.Cache) *Service {
+	if cfg == nil {
+		cfg = &config.EmbeddingConfig{}
+	}
+	return NewService(tei, cfg, embeddingCache, entities.NewRolePrefixRegistry(nil),
+		entities.NewValidator(nil), zap.NewNop())
+}
+
+// numbers returns the inputs "0" to "n-1"
+func numbers(n int) []string {
+	inputs := make([]string, n)
+	for i := range inputs {
+		inputs[i] = strconv.Itoa(i)
+	}
+	return inputs
+}
+
+func embedNumbers(s *Service, n int, configure func(*entities.EmbedRequest)) (*entities.EmbedResponse, error) {
+	req := &entities.EmbedRequest{
+		Inputs:    entities.Input{Data: numbers(n)},
+		Normalize: entities.BoolPtr(false),
+	}
+	if configure != nil {
+		configure(req)
+	}
+	return s.Embed(context.Background(), req)
+}
+
+func TestEmbedAutoBatchSplitsAndPreservesOrder(t *testing.T) {
+	tei := &fakeTEI{}
+	s := newTestService(tei, &config.EmbeddingConfig{MaxConcurrentBatches: 4}, nil)
+
+	resp, err := embedNumbers(s, 200, func(req *entities.EmbedRequest) {
+		req.AutoBatch = entities.BoolPtr(true)
+	})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	if got := tei.batchCount(); got != 7 {
+		t.Errorf("TEI received %d batches, want 7", got)
+	}
+	for _, batch := range tei.batches {
+		if len(batch) > 32 {
+			t.Errorf("batch of %d inputs exceeds the maximum batch size", len(batch))
+		}
+	}
+	if len(resp.Embeddings) != 200 {
+		t.Fatalf("got %d embeddings, want 200", len(resp.Embeddings))
+	}
+	for i, embedding := range resp.Embeddings {
+		if embedding[0] != float32(i) {
+			t.Fatalf("embedding %d belongs to input %v: results are out of order", i, embedding[0])
+		}
+	}
+}
+
+func TestEmbedAutoBatchFromConfig(t *testing.T) {
+	tei := &fakeTEI{}
+	s := newTestService(tei, &config.EmbeddingConfig{AutoBatch: true}, nil)
+
+	if _, err := embedNumbers(s, 40, nil); err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if got := tei.batchCount(); got != 2 {
+		t.Errorf("TEI received %d batches, want 2", got)
+	}
+}
+
+func TestEmbedRejectsOversizedBatchByDefault(t *testing.T) {
+	tei := &fakeTEI{}
+	s := newTestService(tei, nil, nil)
+
+	_, err := embedNumbers(s, 33, nil)
+
+	var validationErr *errors.MultiValidationError
+	if !stderrors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want a validation error", err)
+	}
+	if got := tei.batchCount(); got != 0 {
+		t.Errorf("TEI received %d batches, want none", got)
+	}
+}
+
+func TestEmbedAutoBatchAggregatesFailures(t *testing.T) {
+	tei := &fakeTEI{fail: func(inputs []string) error {
+		if inputs[0] == "32" || inputs[0] == "96" {
+			return errors.NewTEIError("batch rejected", errors.ErrorTypeValidation)
+		}
+		return nil
+	}}
+	s := newTestService(tei, nil, nil)
+
+	_, err := embedNumbers(s, 100, func(req *entities.EmbedRequest) {
+		req.AutoBatch = entities.BoolPtr(true)
+	})
+
+	var batchErr *errors.BatchError
+	if !stderrors.As(err, &batchErr) {
+		t.Fatalf("err = %v, want a BatchError", err)
+	}
+	if len(batchErr.Failures) != 2 {
+		t.Fatalf("got %d failed batches, want 2", len(batchErr.Failures))
+	}
+	for i, want := range [][2]int{{32, 64}, {96, 100}} {
+		if f := batchErr.Failures[i]; f.Start != want[0] || f.End != want[1] {
+			t.Errorf("failure %d covers [%d:%d], want [%d:%d]", i, f.Start, f.End, want[0], want[1])
+		}
+	}
+}
+
+func TestEmbedEchoOnlyWhenRequested(t *testing.T) {
+	s := newTestService(&fakeTEI{}, nil, nil)
+
+	resp, err := embedNumbers(s, 3, nil)
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if resp.Echo != nil {
+		t.Errorf("echo = %+v without echo_request, want none", resp.Echo)
+	}
+
+	resp, err = embedNumbers(s, 3, func(req *entities.EmbedRequest) {
+		req.EchoRequest = entities.BoolPtr(true)
+	})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if resp.Echo == nil {
+		t.Fatal("no echo with echo_request set")
+	}
+	if resp.Echo.InputCount != 3 {
+		t.Errorf("echo input count = %d, want 3", resp.Echo.InputCount)
+	}
+	if want := entities.NewRequestEcho(numbers(3)).InputHash; resp.Echo.InputHash != want {
+		t.Errorf("echo input hash = %q, want %q", resp.Echo.InputHash, want)
+	}
+}
+
+func TestEmbedDegradedDuringOutage(t *testing.T) {
+	tei := &fakeTEI{}
+	s := newTestService(tei, nil, cache.New(&config.CacheConfig{}))
+
+	// A successful call caches input 0 and teaches the service the
+	// embedding dimension
+	if _, err := embedNumbers(s, 1, nil); err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	tei.fail = func([]string) error {
+		return errors.NewTEIError("connection refused", errors.ErrorTypeNetwork)
+	}
+	embedOutage := func(allowDegraded bool) (*entities.EmbedResponse, error) {
+		return s.Embed(context.Background(), &entities.EmbedRequest{
+			Inputs:        entities.Input{Data: []string{"0", "5"}},
+			Normalize:     entities.BoolPtr(false),
+			AllowDegraded: entities.BoolPtr(allowDegraded),
+		})
+	}
+
+	t.Run("cached and zero vectors", func(t *testing.T) {
+		resp, err := embedOutage(true)
+		if err != nil {
+			t.Fatalf("Embed: %v", err)
+		}
+		if !resp.Degraded {
+			t.Error("response not flagged as degraded")
+		}
+		if got := resp.Embeddings[0]; len(got) != 2 || got[0] != 0 || got[1] != 1 {
+			t.Errorf("cached input embedding = %v, want the cached [0 1]", got)
+		}
+		if got := resp.Embeddings[1]; len(got) != 2 || got[0] != 0 || got[1] != 0 {
+			t.Errorf("uncached input embedding = %v, want the zero vector [0 0]", got)
+		}
+	})
+
+	t.Run("opt-out", func(t *testing.T) {
+		if _, err := embedOutage(false); !isOutage(err) {
+			t.Errorf("err = %v, want the outage error", err)
+		}
+	})
+
+	t.Run("not an outage", func(t *testing.T) {
+		tei.fail = func([]string) error {
+			return errors.NewTEIError("bad input", errors.ErrorTypeValidation)
+		}
+		if _, err := embedOutage(true); err == nil {
+			t.Error("degraded vectors served for a request TEI rejected")
+		}
+	})
+}
+
+func TestEmbedDegradedKeepsSucceededBatches(t *testing.T) {
+	tei := &fakeTEI{fail: func(inputs []string) error {
+		if inputs[0] == "32" {
+			return errors.NewTEIError("connection refused", errors.ErrorTypeNetwork)
+		}
+		return nil
+	}}
+	s := newTestService(tei, nil, nil)
+
+	resp, err := embedNumbers(s, 100, func(req *entities.EmbedRequest) {
+		req.AutoBatch = entities.BoolPtr(true)
+		req.AllowDegraded = entities.BoolPtr(true)
+	})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if !resp.Degraded {
+		t.Error("response not flagged as degraded")
+	}
+
+	for i, got := range resp.Embeddings {
+		want := []float32{float32(i), 1}
+		if i >= 32 && i < 64 {
+			want = []float32{0, 0}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("embedding %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestEmbedNotDegradedWhenABatchIsRejected(t *testing.T) {
+	tei := &fakeTEI{fail: func(inputs []string) error {
+		switch inputs[0] {
+		case "32":
+			return errors.NewTEIError("connection refused", errors.ErrorTypeNetwork)
+		case "64":
+			return errors.NewTEIError("batch rejected", errors.ErrorTypeValidation)
+		}
+		return nil
+	}}
+	s := newTestService(tei, nil, nil)
+
+	_, err := embedNumbers(s, 100, func(req *entities.EmbedRequest) {
+		req.AutoBatch = entities.BoolPtr(true)
+		req.AllowDegraded = entities.BoolPtr(true)
+	})
+	var batchErr *errors.BatchError
+	if !stderrors.As(err, &batchErr) {
+		t.Errorf("err = %v, want the BatchError instead of degraded vectors", err)
+	}
+}
+
+func TestEmbedDegradedNeedsKnownDimension(t *testing.T) {
+	tei := &fakeTEI{fail: func([]string) error {
+		return errors.NewTEIError("connection refused", errors.ErrorTypeNetwork)
+	}}
+	s := newTestService(tei, nil, nil)
+
+	_, err := embedNumbers(s, 1, func(req *entities.EmbedRequest) {
+		req.AllowDegraded = entities.BoolPtr(true)
+	})
+	if !isOutage(err) {
+		t.Errorf("err = %v, want the outage error while the dimension is unknown", err)
+	}
+}
+
+func TestEmbedStreamRejectsInt8(t *testing.T) {
+	tei := &fakeTEI{}
+	s := newTestService(tei, nil, nil)
+
+	err := s.EmbedStream(context.Background(), &entities.EmbedRequest{EncodingFormat: entities.EncodingInt8},
+		func() (*entities.StreamInput, error) { return &entities.StreamInput{ID: "a", Text: "1"}, nil },
+		func([]entities.StreamResult) error { return nil })
+
+	var validationErr *errors.ValidationError
+	if !stderrors.As(err, &validationErr) || validationErr.Field != "encoding_format" {
+		t.Errorf("err = %v, want an encoding_format validation error", err)
+	}
+	if got := tei.batchCount(); got != 0 {
+		t.Errorf("TEI received %d batches, want none", got)
+	}
+}
+
+func TestEmbedStreamAbortNamesFailingIDs(t *testing.T) {
+	tei := &fakeTEI{fail: func(inputs []string) error {
+		if slices.Contains(inputs, "35") {
+			return errors.NewTEIError("backend failed", errors.ErrorTypeBackend)
+		}
+		return nil
+	}}
+	s := newTestService(tei, nil, nil)
+
+	next := 0
+	sent := 0
+	err := s.EmbedStream(context.Background(), &entities.EmbedRequest{Normalize: entities.BoolPtr(false)},
+		func() (*entities.StreamInput, error) {
+			if next == 40 {
+				return nil, io.EOF
+			}
+			next++
+			return &entities.StreamInput{ID: "row-" + strconv.Itoa(next-1), Text: strconv.Itoa(next - 1)}, nil
+		},
+		func(results []entities.StreamResult) error {
+			sent += len(results)
+			return nil
+		})
+
+	// The first batch of 32 is sent before the second one fails
+	var streamErr *errors.StreamBatchError
+	if !stderrors.As(err, &streamErr) {
+		t.Fatalf("err = %v, want a StreamBatchError", err)
+	}
+	if streamErr.FirstID != "row-32" || streamErr.LastID != "row-39" {
+		t.Errorf("failing ids = %q to %q, want row-32 to row-39", streamErr.FirstID, streamErr.LastID)
+	}
+	var teiErr *errors.TEIError
+	if !stderrors.As(err, &teiErr) || teiErr.Type != errors.ErrorTypeBackend {
+		t.Errorf("err = %v, want it to wrap the backend error", err)
+	}
+	if sent != 32 {
+		t.Errorf("sent %d results, want the 32 of the first batch", sent)
+	}
+}
+
+func TestEmbedAllAndSparseEnforceConfiguredLimits(t *testing.T) {
+	tei := &fakeTEI{}
+	validator := entities.NewValidator(&entities.ValidationConfig{
+		MaxInputLength:    8,
+		MaxBatchSize:      4,
+		MaxSentencesCount: 100,
+		FailFastTexts:     true,
+	})
+	s := NewService(tei, &config.EmbeddingConfig{}, nil, entities.NewRolePrefixRegistry(nil), validator, zap.NewNop())
+
+	rpcs := map[string]func(inputs []string, autoBatch bool) error{
+		"EmbedAll": func(inputs []string, autoBatch bool) error {
+			_, err := s.EmbedAll(context.Background(), &entities.EmbedAllRequest{
+				Inputs:    entities.Input{Data: inputs},
+				AutoBatch: entities.BoolPtr(autoBatch),
+			})
+			return err
+		},
+		"EmbedSparse": func(inputs []string, _ bool) error {
+			_, err := s.EmbedSparse(context.Background(), &entities.EmbedSparseRequest{
+				Inputs: entities.Input{Data: inputs},
+			})
+			return err
+		},
+	}
+
+	for name, call := range rpcs {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range []struct {
+				name   string
+				inputs []string
+				field  string
+			}{
+				{"batch size", numbers(5), "inputs"},
+				{"input length", []string{"0", "123456789"}, "inputs[1]"},
+			} {
+				err := call(tt.inputs, false)
+
+				var validationErr *errors.MultiValidationError
+				if !stderrors.As(err, &validationErr) {
+					t.Fatalf("%s: err = %v, want a validation error", tt.name, err)
+				}
+				if got := validationErr.Errors[0].Field; got != tt.field {
+					t.Errorf("%s: violation on %q, want %q", tt.name, got, tt.field)
+				}
+			}
+		})
+	}
+
+	// Auto-batching lifts only the batch size limit of EmbedAll; the fake
+	// TEI then fails the call, but not validation
+	var validationErr *errors.MultiValidationError
+	if err := rpcs["EmbedAll"](numbers(5), true); stderrors.As(err, &validationErr) {
+		t.Errorf("auto-batched EmbedAll failed validation: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package embedding
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+
+	"go.uber.org/zap"
+)
+
+// EmbedStream reads inputs from recv until it returns io.EOF, embeds them
+// in batches of the maximum batch size and sends each batch's results in
+// input order. req supplies the options of every batch; its inputs are
+// ignored, and int8 encoding is rejected as streamed results only carry
+// float vectors. A batch is only sent once it is full or the input ends.
+// When a batch fails the stream ends with a StreamBatchError naming the
+// batch's first and last ids, unless the configured stream error policy is
+// "continue", in which case each of its inputs is reported with the error
+// and the stream goes on.
+func (s *Service) EmbedStream(ctx context.Context, req *entities.EmbedRequest,
+	recv func() (*entities.StreamInput, error), send func([]entities.StreamResult) error) error {
+	if req.EncodingFormat == entities.EncodingInt8 {
+		return errors.NewValidationError("encoding_format",
+			"int8 is not supported for streamed embeddings", string(req.EncodingFormat))
+	}
+
+	logger := logging.FromContext(ctx, s.logger)
+	continueOnError := s.config.StreamErrorPolicy == "continue"
+	maxBatchSize := s.validator.Config().MaxBatchSize
+
+	batch := make([]entities.StreamInput, 0, maxBatchSize)
+	embedded, failed := 0, 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		texts := make([]string, len(batch))
+		for i, input := range batch {
+			texts[i] = input.Text
+		}
+		batchReq := *req
+		batchReq.Inputs = entities.Input{Data: texts}
+		batchReq.AutoBatch = entities.BoolPtr(false)
+		batchReq.EncodingFormat = entities.EncodingFloat
+
+		results := make([]entities.StreamResult, len(batch))
+		resp, err := s.Embed(ctx, &batchReq)
+		if err != nil {
+			if !continueOnError {
+				return &errors.StreamBatchError{
+					FirstID: batch[0].ID,
+					LastID:  batch[len(batch)-1].ID,
+					Err:     err,
+				}
+			}
+			logger.Warn("Streamed embed batch failed, continuing",
+				zap.String("first_id", batch[0].ID),
+				zap.Int("batch_size", len(batch)),
+				zap.Error(err),
+			)
+			for i, input := range batch {
+				results[i] = entities.StreamResult{ID: input.ID, Err: err}
+			}
+			failed += len(batch)
+		} else {
+			for i, input := range batch {
+				results[i] = entities.StreamResult{ID: input.ID, Embedding: resp.Embeddings[i]}
+			}
+			// Inputs that failed under partial results carry their own error
+			for _, inputErr := range resp.Errors {
+				results[inputErr.Index].Err = inputErr.Err
+			}
+			embedded += len(batch) - len(resp.Errors)
+			failed += len(resp.Errors)
+		}
+
+		batch = batch[:0]
+		return send(results)
+	}
+
+	for {
+		input, err := recv()
+		if stderrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		batch = append(batch, *input)
+		if len(batch) == maxBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	logger.Debug("Streamed embed completed",
+		zap.Int("embedded", embedded),
+		zap.Int("failed", failed),
+	)
+	return nil
+}
@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// EmbedTokens embeds pre-tokenized inputs, such as ids returned by
+// Tokenize, without TEI tokenizing them again
+func (s *Service) EmbedTokens(ctx context.Context, req *entities.EmbedTokensRequest) (*entities.EmbedResponse, error) {
+	ctx = logging.WithFields(ctx, zap.Int("batch_size", len(req.Inputs)))
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("Processing embed tokens request",
+		zap.Int("input_count", len(req.Inputs)),
+	)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("embedding.input_count", len(req.Inputs)))
+
+	if req.TruncationDirection == "" {
+		req.TruncationDirection = s.defaultTruncationDirection()
+	}
+	req.SetDefaults()
+
+	if err := s.validator.ValidateTokenIDs(req.Inputs, "inputs", *req.Truncate); err != nil {
+		logger.Error("Embed tokens request validation failed", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.validator.ValidateTruncationDirection(req.TruncationDirection); err != nil {
+		return nil, err
+	}
+
+	responseData, err := s.httpClient.Post(ctx, entities.EndpointEmbed, req)
+	if err != nil {
+		logger.Error("Embed tokens request failed", zap.Error(err))
+		return nil, fmt.Errorf("embed request failed: %w", err)
+	}
+
+	response, err := decodeEmbeddings[[]float32](logger, entities.EndpointEmbed, responseData, len(req.Inputs))
+	if err != nil {
+		return nil, err
+	}
+	for _, embedding := range response {
+		if len(embedding) == 0 {
+			return nil, errors.NewTEIError("TEI returned an empty embedding", errors.ErrorTypeResponseMalformed)
+		}
+	}
+
+	if *req.Normalize {
+		s.checkNorms(response)
+	}
+
+	return &entities.EmbedResponse{Embeddings: response}, nil
+}
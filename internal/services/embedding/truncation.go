@@ -0,0 +1,66 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+
+	"go.uber.org/zap"
+)
+
+// detectTruncation tokenizes the inputs of req and reports which of them
+// exceed the model's maximum input length, and so were cut by TEI. It is
+// best effort: nil is returned when the limit is unknown or tokenization
+// fails.
+func (s *Service) detectTruncation(ctx context.Context, req *entities.EmbedRequest) []bool {
+	logger := logging.FromContext(ctx, s.logger)
+	maxInputTokens := s.validator.Config().MaxInputTokens
+	if maxInputTokens <= 0 {
+		return nil
+	}
+
+	inputs := req.Inputs.Data
+	batchSize := max(s.validator.Config().MaxBatchSize, 1)
+	truncated := make([]bool, len(inputs))
+
+	for start := 0; start < len(inputs); start += batchSize {
+		end := min(start+batchSize, len(inputs))
+
+		tokenizeReq := &entities.TokenizeRequest{
+			Inputs:     entities.Input{Data: inputs[start:end]},
+			PromptName: req.PromptName,
+		}
+		tokenizeReq.SetDefaults()
+
+		responseData, err := s.httpClient.Post(ctx, entities.EndpointTokenize, tokenizeReq)
+		if err != nil {
+			logger.Debug("Truncation check skipped, tokenize failed", zap.Error(err))
+			return nil
+		}
+
+		var tokens [][]entities.Token
+		if err := json.Unmarshal(responseData, &tokens); err != nil || len(tokens) != end-start {
+			logger.Debug("Truncation check skipped, unexpected tokenize response")
+			return nil
+		}
+
+		for i, inputTokens := range tokens {
+			if len(inputTokens) <= maxInputTokens {
+				continue
+			}
+
+			truncated[start+i] = true
+			metrics.TruncatedInputs.Inc()
+			logger.Warn("Input truncated by TEI",
+				zap.Int("index", start+i),
+				zap.Int("tokens", len(inputTokens)),
+				zap.Int("max_tokens", maxInputTokens),
+			)
+		}
+	}
+
+	return truncated
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/domain/interfaces"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	httpClient interfaces.HTTPClient
+	logger     *zap.Logger
+}
+
+func NewService(httpClient interfaces.HTTPClient, logger *zap.Logger) *Service {
+	return &Service{
+		httpClient: httpClient,
+		logger:     logger.Named("model"),
+	}
+}
+
+func (s *Service) Info(ctx context.Context) (*entities.ModelInfo, error) {
+	logger := logging.FromContext(ctx, s.logger)
+	responseData, err := s.httpClient.Get(ctx, entities.EndpointInfo)
+	if err != nil {
+		logger.Error("Info request failed", zap.Error(err))
+		return nil, fmt.Errorf("info request failed: %w", err)
+	}
+
+	var info entities.ModelInfo
+	if err := json.Unmarshal(responseData, &info); err != nil {
+		logger.Error("Failed to parse info response", zap.Error(err))
+		return nil, errors.NewTEIError("failed to parse response", errors.ErrorTypeResponseMalformed)
+	}
+
+	return &info, nil
+}
@@ -0,0 +1,66 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// DriftReport compares two embeddings of the same inputs. Distances holds
+// the cosine distance (1 - cosine similarity) of each input, from 0 for an
+// unchanged direction up to 2 for an opposite one; Shifted lists the
+// indices whose distance exceeds the threshold.
+type DriftReport struct {
+	Distances []float32 `json:"distances"`
+	Shifted   []int     `json:"shifted"`
+
+	Mean   float32 `json:"mean"`
+	Median float32 `json:"median"`
+	P95    float32 `json:"p95"`
+	Max    float32 `json:"max"`
+}
+
+// Drift measures how far each input moved between two embeddings of a
+// corpus, e.g. before and after a model upgrade, to judge whether it needs
+// re-indexing. before[i] and after[i] must embed the same input with the
+// same dimension; vectors from models of different dimensions are not
+// comparable.
+func Drift(before, after [][]float32, threshold float32) (*DriftReport, error) {
+	if len(before) != len(after) {
+		return nil, fmt.Errorf("got %d embeddings before and %d after", len(before), len(after))
+	}
+
+	report := &DriftReport{Distances: make([]float32, len(before))}
+	if len(before) == 0 {
+		return report, nil
+	}
+
+	var sum float32
+	for i := range before {
+		if len(before[i]) != len(after[i]) {
+			return nil, fmt.Errorf("embedding %d has dimension %d before and %d after", i, len(before[i]), len(after[i]))
+		}
+
+		distance := 1 - cosine(before[i], after[i])
+		report.Distances[i] = distance
+		sum += distance
+		if distance > threshold {
+			report.Shifted = append(report.Shifted, i)
+		}
+	}
+
+	sorted := slices.Clone(report.Distances)
+	slices.Sort(sorted)
+	report.Mean = sum / float32(len(sorted))
+	report.Median = percentile(sorted, 0.5)
+	report.P95 = percentile(sorted, 0.95)
+	report.Max = sorted[len(sorted)-1]
+
+	return report, nil
+}
+
+// percentile returns the nearest-rank percentile p of sorted values
+func percentile(sorted []float32, p float64) float32 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
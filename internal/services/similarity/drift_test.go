@@ -0,0 +1,132 @@
+package similarity
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// unchanged returns n copies of the vector [1 0]
+func unchanged(n int) [][]float32 {
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = []float32{1, 0}
+	}
+	return vectors
+}
+
+func TestDrift(t *testing.T) {
+	// Of twenty inputs the last two moved, one orthogonally and one to the
+	// opposite direction
+	manyAfter := unchanged(20)
+	manyAfter[18] = []float32{0, 1}
+	manyAfter[19] = []float32{-1, 0}
+
+	tests := []struct {
+		name      string
+		before    [][]float32
+		after     [][]float32
+		threshold float32
+
+		distances []float32
+		shifted   []int
+		mean      float32
+		median    float32
+		p95       float32
+		max       float32
+	}{
+		{
+			name:      "unchanged, orthogonal and opposite",
+			before:    unchanged(4),
+			after:     [][]float32{{2, 0}, {0, 1}, {-1, 0}, {1, 0}},
+			threshold: 0.5,
+			distances: []float32{0, 1, 2, 0},
+			shifted:   []int{1, 2},
+			mean:      0.75,
+			median:    0,
+			p95:       2,
+			max:       2,
+		},
+		{
+			name:      "single input",
+			before:    [][]float32{{3, 4}},
+			after:     [][]float32{{4, 3}},
+			threshold: 0.1,
+			distances: []float32{0.04},
+			mean:      0.04,
+			median:    0.04,
+			p95:       0.04,
+			max:       0.04,
+		},
+		{
+			name:      "p95 below the maximum",
+			before:    unchanged(20),
+			after:     manyAfter,
+			threshold: 1,
+			distances: append(make([]float32, 18), 1, 2),
+			shifted:   []int{19},
+			mean:      0.15,
+			median:    0,
+			p95:       1,
+			max:       2,
+		},
+		{
+			name:      "empty",
+			distances: []float32{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Drift(tt.before, tt.after, tt.threshold)
+			if err != nil {
+				t.Fatalf("Drift: %v", err)
+			}
+
+			if len(report.Distances) != len(tt.distances) {
+				t.Fatalf("got %d distances, want %d", len(report.Distances), len(tt.distances))
+			}
+			for i, want := range tt.distances {
+				if !approxEqual(report.Distances[i], want) {
+					t.Errorf("distance %d = %v, want %v", i, report.Distances[i], want)
+				}
+			}
+			if !slices.Equal(report.Shifted, tt.shifted) {
+				t.Errorf("shifted = %v, want %v", report.Shifted, tt.shifted)
+			}
+			for _, stat := range []struct {
+				name      string
+				got, want float32
+			}{
+				{"mean", report.Mean, tt.mean},
+				{"median", report.Median, tt.median},
+				{"p95", report.P95, tt.p95},
+				{"max", report.Max, tt.max},
+			} {
+				if !approxEqual(stat.got, stat.want) {
+					t.Errorf("%s = %v, want %v", stat.name, stat.got, stat.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDriftRejectsMismatchedEmbeddings(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after [][]float32
+	}{
+		{"different input counts", unchanged(3), unchanged(2)},
+		{"different dimensions", unchanged(2), [][]float32{{1, 0}, {1, 0, 0}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := Drift(tt.before, tt.after, 0.1); err == nil {
+			t.Errorf("%s: Drift succeeded, want an error", tt.name)
+		}
+	}
+}
+
+func approxEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
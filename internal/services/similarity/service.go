@@ -4,51 +4,88 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
+	"github.com/blackprince001/embedding-inference/internal/config"
 	"github.com/blackprince001/embedding-inference/internal/domain/entities"
 	"github.com/blackprince001/embedding-inference/internal/domain/errors"
 	"github.com/blackprince001/embedding-inference/internal/domain/interfaces"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
 
 	"go.uber.org/zap"
 )
 
 type Service struct {
 	httpClient interfaces.HTTPClient
+	embedder   interfaces.EmbeddingService
+	config     *config.SimilarityConfig
+	roles      *entities.RolePrefixRegistry
 	logger     *zap.Logger
 	validator  *entities.Validator
 }
 
-func NewService(httpClient interfaces.HTTPClient, logger *zap.Logger) *Service {
+// NewService creates a similarity service. embedder is used for the
+// locally computed similarity paths.
+func NewService(httpClient interfaces.HTTPClient, embedder interfaces.EmbeddingService, cfg *config.SimilarityConfig, rolePrefixes *entities.RolePrefixRegistry, validator *entities.Validator, logger *zap.Logger) *Service {
 	return &Service{
 		httpClient: httpClient,
+		embedder:   embedder,
+		config:     cfg,
+		roles:      rolePrefixes,
 		logger:     logger.Named("similarity"),
-		validator:  entities.NewValidator(entities.DefaultValidationConfig()),
+		validator:  validator,
 	}
 }
 
 func (s *Service) CalculateSimilarity(ctx context.Context, req *entities.SimilarityRequest) (*entities.SimilarityResponse, error) {
-	s.logger.Debug("Processing similarity request",
-		zap.String("source_sentence", truncateString(req.Inputs.SourceSentence, 50)),
+	ctx = logging.WithFields(ctx, zap.Int("batch_size", len(req.Inputs.Sentences)))
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("Processing similarity request",
+		zap.Int("source_chars", len(req.Inputs.SourceSentence)),
 		zap.Int("sentences_count", len(req.Inputs.Sentences)),
 	)
 
 	req.SetDefaults()
 
 	if err := s.validator.ValidateSimilarityRequest(req); err != nil {
-		s.logger.Error("Similarity request validation failed", zap.Error(err))
+		logger.Error("Similarity request validation failed", zap.Error(err))
 		return nil, err
 	}
 
+	sentences := req.Inputs.Sentences
+	req = s.applyRolePrefixes(req)
+
+	var resp *entities.SimilarityResponse
+	var err error
+	if req.Parameters.Metric != "" || s.config.ComputeLocally {
+		resp, err = s.calculateSimilarityLocal(ctx, req)
+	} else {
+		resp, err = s.calculateSimilarityTEI(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Parameters.Ranked != nil && *req.Parameters.Ranked {
+		resp.Ranked = rankSentences(sentences, resp.Similarities)
+	}
+
+	return resp, nil
+}
+
+// calculateSimilarityTEI scores req with TEI's /similarity route
+func (s *Service) calculateSimilarityTEI(ctx context.Context, req *entities.SimilarityRequest) (*entities.SimilarityResponse, error) {
+	logger := logging.FromContext(ctx, s.logger)
 	responseData, err := s.httpClient.Post(ctx, entities.EndpointSimilarity, req)
 	if err != nil {
-		s.logger.Error("Similarity request failed", zap.Error(err))
+		logger.Error("Similarity request failed", zap.Error(err))
 		return nil, fmt.Errorf("similarity request failed: %w", err)
 	}
 
 	var response []float32
 	if err := json.Unmarshal(responseData, &response); err != nil {
-		s.logger.Error("Failed to parse similarity response", zap.Error(err))
-		return nil, errors.NewTEIError("failed to parse response", errors.ErrorTypeBackend)
+		logger.Error("Failed to parse similarity response", zap.Error(err))
+		return nil, errors.NewTEIError("failed to parse response", errors.ErrorTypeResponseMalformed)
 	}
 
 	si := entities.SimilarityResponse{
@@ -56,14 +93,14 @@ func (s *Service) CalculateSimilarity(ctx context.Context, req *entities.Similar
 	}
 
 	if len(si.Similarities) != len(req.Inputs.Sentences) {
-		s.logger.Error("Response similarity count mismatch",
+		logger.Error("Response similarity count mismatch",
 			zap.Int("expected", len(req.Inputs.Sentences)),
 			zap.Int("received", len(si.Similarities)),
 		)
-		return nil, errors.NewTEIError("response similarity count mismatch", errors.ErrorTypeBackend)
+		return nil, errors.NewTEIError("response similarity count mismatch", errors.ErrorTypeResponseMismatch)
 	}
 
-	s.logger.Debug("Similarity request completed",
+	logger.Debug("Similarity request completed",
 		zap.Int("similarities_count", len(si.Similarities)),
 		zap.Float32("avg_similarity", calculateAverage(si.Similarities)),
 	)
@@ -72,33 +109,83 @@ func (s *Service) CalculateSimilarity(ctx context.Context, req *entities.Similar
 }
 
 func (s *Service) CalculatePairwiseSimilarity(ctx context.Context, sentences1, sentences2 []string) ([][]float32, error) {
+	logger := logging.FromContext(ctx, s.logger)
 	if len(sentences1) == 0 || len(sentences2) == 0 {
 		return nil, errors.NewValidationError("sentences", "both sentence arrays must be non-empty", nil)
 	}
 
+	if s.config.ComputeLocally {
+		return s.CalculatePairwiseSimilarityLocal(ctx, sentences1, sentences2, entities.SimilarityCosine)
+	}
+
 	results := make([][]float32, len(sentences1))
 
+	// A failed row cancels the rows still in flight
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := max(s.config.MaxConcurrentRequests, 1)
+	sem := make(chan struct{}, concurrency)
+
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+
+dispatch:
 	for i, sentence1 := range sentences1 {
-		req := &entities.SimilarityRequest{
-			Inputs: entities.SimilarityInput{
-				SourceSentence: sentence1,
-				Sentences:      sentences2,
-			},
+		select {
+		case <-ctx.Done():
+			break dispatch
+		default:
 		}
 
-		resp, err := s.CalculateSimilarity(ctx, req)
-		if err != nil {
-			s.logger.Error("Pairwise similarity calculation failed",
-				zap.Int("sentence1_index", i),
-				zap.Error(err),
-			)
-			return nil, fmt.Errorf("pairwise similarity failed at index %d: %w", i, err)
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
 		}
 
-		results[i] = resp.Similarities
+		wg.Add(1)
+		go func(i int, sentence1 string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			req := &entities.SimilarityRequest{
+				Inputs: entities.SimilarityInput{
+					SourceSentence: sentence1,
+					Sentences:      sentences2,
+				},
+			}
+
+			resp, err := s.CalculateSimilarity(ctx, req)
+			if err != nil {
+				failOnce.Do(func() {
+					logger.Error("Pairwise similarity calculation failed",
+						zap.Int("sentence1_index", i),
+						zap.Error(err),
+					)
+					firstErr = fmt.Errorf("pairwise similarity failed at index %d: %w", i, err)
+					cancel()
+				})
+				return
+			}
+
+			results[i] = resp.Similarities
+		}(i, sentence1)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	s.logger.Debug("Pairwise similarity completed",
+	logger.Debug("Pairwise similarity completed",
 		zap.Int("sentences1_count", len(sentences1)),
 		zap.Int("sentences2_count", len(sentences2)),
 	)
@@ -106,7 +193,131 @@ func (s *Service) CalculatePairwiseSimilarity(ctx context.Context, sentences1, s
 	return results, nil
 }
 
-func (s *Service) FindMostSimilar(ctx context.Context, sourceSentence string, candidates []string, topK int) (*MostSimilarResult, error) {
+// ValidateSimilarity checks req as CalculateSimilarity would, without
+// calling TEI, and returns every violation found, or nil if the request is
+// valid
+func (s *Service) ValidateSimilarity(req *entities.SimilarityRequest) *errors.MultiValidationError {
+	req.SetDefaults()
+	return s.validator.CollectSimilarityRequestErrors(req)
+}
+
+// calculateSimilarityLocal embeds the source and candidate sentences in a
+// single /embed call and scores them with the requested metric.
+func (s *Service) calculateSimilarityLocal(ctx context.Context, req *entities.SimilarityRequest) (*entities.SimilarityResponse, error) {
+	logger := logging.FromContext(ctx, s.logger)
+	sentences := append([]string{req.Inputs.SourceSentence}, req.Inputs.Sentences...)
+
+	resp, err := s.embedder.Embed(ctx, &entities.EmbedRequest{
+		Inputs:              entities.Input{Data: sentences},
+		Normalize:           entities.BoolPtr(true),
+		PromptName:          req.Parameters.PromptName,
+		Truncate:            req.Parameters.Truncate,
+		TruncationDirection: req.Parameters.TruncationDirection,
+		AutoBatch:           entities.BoolPtr(true),
+	})
+	if err != nil {
+		logger.Error("Local similarity embedding failed", zap.Error(err))
+		return nil, fmt.Errorf("similarity request failed: %w", err)
+	}
+
+	if len(resp.Embeddings) != len(sentences) {
+		return nil, errors.NewTEIError("response embedding count mismatch", errors.ErrorTypeResponseMismatch)
+	}
+
+	score := scoreFunc(req.Parameters.Metric)
+	source := resp.Embeddings[0]
+	similarities := make([]float32, len(req.Inputs.Sentences))
+	for i, embedding := range resp.Embeddings[1:] {
+		similarities[i] = score(source, embedding)
+	}
+
+	logger.Debug("Local similarity request completed",
+		zap.String("metric", string(req.Parameters.Metric)),
+		zap.Int("similarities_count", len(similarities)),
+	)
+
+	return &entities.SimilarityResponse{Similarities: similarities}, nil
+}
+
+// CalculatePairwiseSimilarityLocal embeds both sentence sets once via
+// /embed and computes the similarity matrix locally with the given metric,
+// replacing one /similarity call per source sentence with two embed calls.
+func (s *Service) CalculatePairwiseSimilarityLocal(ctx context.Context, sentences1, sentences2 []string, metric entities.SimilarityMetric) ([][]float32, error) {
+	logger := logging.FromContext(ctx, s.logger)
+	if len(sentences1) == 0 || len(sentences2) == 0 {
+		return nil, errors.NewValidationError("sentences", "both sentence arrays must be non-empty", nil)
+	}
+
+	embeddings1, err := s.embedNormalized(ctx, sentences1, entities.InputRoleQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed sentences1: %w", err)
+	}
+
+	embeddings2, err := s.embedNormalized(ctx, sentences2, entities.InputRoleDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed sentences2: %w", err)
+	}
+
+	score := scoreFunc(metric)
+	results := make([][]float32, len(embeddings1))
+	for i, a := range embeddings1 {
+		results[i] = make([]float32, len(embeddings2))
+		for j, b := range embeddings2 {
+			results[i][j] = score(a, b)
+		}
+	}
+
+	logger.Debug("Local pairwise similarity completed",
+		zap.Int("sentences1_count", len(sentences1)),
+		zap.Int("sentences2_count", len(sentences2)),
+	)
+
+	return results, nil
+}
+
+func (s *Service) embedNormalized(ctx context.Context, sentences []string, role entities.InputRole) ([][]float32, error) {
+	resp, err := s.embedder.Embed(ctx, &entities.EmbedRequest{
+		Inputs:    entities.Input{Data: sentences},
+		Normalize: entities.BoolPtr(true),
+		AutoBatch: entities.BoolPtr(true),
+		InputRole: role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Embeddings) != len(sentences) {
+		return nil, errors.NewTEIError("response embedding count mismatch", errors.ErrorTypeResponseMismatch)
+	}
+
+	return resp.Embeddings, nil
+}
+
+// applyRolePrefixes returns a copy of req with the current model's query
+// prefix on the source sentence and document prefix on the candidates. An
+// explicit prompt name takes precedence over the prefixes.
+func (s *Service) applyRolePrefixes(req *entities.SimilarityRequest) *entities.SimilarityRequest {
+	if req.Parameters.PromptName != nil {
+		return req
+	}
+
+	prefixes := s.roles.Prefixes()
+	if prefixes == (entities.RolePrefixes{}) {
+		return req
+	}
+
+	prefixed := *req
+	prefixed.Inputs.SourceSentence = prefixes.Apply(entities.InputRoleQuery, []string{req.Inputs.SourceSentence})[0]
+	prefixed.Inputs.Sentences = prefixes.Apply(entities.InputRoleDocument, req.Inputs.Sentences)
+	return &prefixed
+}
+
+// FindMostSimilar returns the topK candidates most similar to
+// sourceSentence, best first. With minSimilarity set, candidates scoring
+// below it are dropped as well, so fewer than topK matches, or none, are
+// returned when too few candidates reach the threshold; topK still caps
+// the number of matches.
+func (s *Service) FindMostSimilar(ctx context.Context, sourceSentence string, candidates []string, topK int, minSimilarity *float32) (*MostSimilarResult, error) {
 	if topK <= 0 {
 		return nil, errors.NewValidationError("topK", "must be positive", topK)
 	}
@@ -127,60 +338,89 @@ func (s *Service) FindMostSimilar(ctx context.Context, sourceSentence string, ca
 		return nil, fmt.Errorf("similarity calculation failed: %w", err)
 	}
 
-	type indexedSimilarity struct {
-		Index      int
-		Sentence   string
-		Similarity float32
-	}
+	top := selectTopK(resp.Similarities, topK)
 
-	indexed := make([]indexedSimilarity, len(resp.Similarities))
-	for i, sim := range resp.Similarities {
-		indexed[i] = indexedSimilarity{
-			Index:      i,
-			Sentence:   candidates[i],
-			Similarity: sim,
+	results := make([]SimilarSentence, 0, len(top))
+	for _, match := range top {
+		// Matches are sorted best first, so the rest score lower still
+		if minSimilarity != nil && match.Score < *minSimilarity {
+			break
 		}
+		results = append(results, SimilarSentence{
+			Index:      match.Index,
+			Sentence:   candidates[match.Index],
+			Similarity: match.Score,
+		})
 	}
 
-	for i := 0; i < len(indexed); i++ {
-		for j := i + 1; j < len(indexed); j++ {
-			if indexed[j].Similarity > indexed[i].Similarity {
-				indexed[i], indexed[j] = indexed[j], indexed[i]
-			}
-		}
+	return &MostSimilarResult{
+		SourceSentence: sourceSentence,
+		TopMatches:     results,
+	}, nil
+}
+
+// NearestNeighbors embeds query and returns the topK vectors of a
+// precomputed corpus with the highest dot product, best first. The query is
+// embedded normalized with the query role prefix, so corpus vectors must be
+// normalized too for the scores to be cosine similarities.
+func (s *Service) NearestNeighbors(ctx context.Context, query string, corpus [][]float32, topK int) ([]Neighbor, error) {
+	if topK <= 0 {
+		return nil, errors.NewValidationError("topK", "must be positive", topK)
 	}
 
-	results := make([]SimilarSentence, topK)
-	for i := 0; i < topK; i++ {
-		results[i] = SimilarSentence{
-			Index:      indexed[i].Index,
-			Sentence:   indexed[i].Sentence,
-			Similarity: indexed[i].Similarity,
+	if len(corpus) == 0 {
+		return nil, errors.NewValidationError("corpus", "must be non-empty", nil)
+	}
+
+	embeddings, err := s.embedNormalized(ctx, []string{query}, entities.InputRoleQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	queryVector := embeddings[0]
+
+	scores := make([]float32, len(corpus))
+	for i, vector := range corpus {
+		if len(vector) != len(queryVector) {
+			return nil, errors.NewValidationError(fmt.Sprintf("corpus[%d]", i),
+				"dimension does not match the query embedding", map[string]int{
+					"dimension":       len(vector),
+					"query_dimension": len(queryVector),
+				})
 		}
+		scores[i] = dot(queryVector, vector)
 	}
 
-	return &MostSimilarResult{
-		SourceSentence: sourceSentence,
-		TopMatches:     results,
-	}, nil
+	top := selectTopK(scores, min(topK, len(corpus)))
+
+	neighbors := make([]Neighbor, len(top))
+	for i, match := range top {
+		neighbors[i] = Neighbor{Index: match.Index, Score: match.Score}
+	}
+
+	return neighbors, nil
 }
 
+type Neighbor = entities.Neighbor
+
 type MostSimilarResult struct {
 	SourceSentence string            `json:"source_sentence"`
 	TopMatches     []SimilarSentence `json:"top_matches"`
 }
 
-type SimilarSentence struct {
-	Index      int     `json:"index"`
-	Sentence   string  `json:"sentence"`
-	Similarity float32 `json:"similarity"`
-}
-
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+type SimilarSentence = entities.SimilarSentence
+
+// rankSentences labels every sentence with its score, best first
+func rankSentences(sentences []string, scores []float32) []SimilarSentence {
+	top := selectTopK(scores, len(scores))
+	ranked := make([]SimilarSentence, len(top))
+	for i, match := range top {
+		ranked[i] = SimilarSentence{
+			Index:      match.Index,
+			Sentence:   sentences[match.Index],
+			Similarity: match.Score,
+		}
 	}
-	return s[:maxLen] + "..."
+	return ranked
 }
 
 func calculateAverage(values []float32) float32 {
@@ -0,0 +1,140 @@
+package similarity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+
+	"go.uber.org/zap"
+)
+
+// StreamSimilarity scores the candidates of req against its source sentence
+// in chunks of chunkSize, calling send with the topK matches so far after
+// each chunk. The number of candidates is not limited by the configured
+// maximum sentences count, since they are embedded one chunk at a time.
+// A chunkSize of zero uses the maximum batch size. It stops at the first
+// error returned by send.
+func (s *Service) StreamSimilarity(ctx context.Context, req *entities.SimilarityRequest, topK, chunkSize int,
+	send func(*entities.SimilarityProgress) error) error {
+	ctx = logging.WithFields(ctx, zap.Int("batch_size", len(req.Inputs.Sentences)))
+	logger := logging.FromContext(ctx, s.logger)
+
+	maxBatchSize := s.validator.Config().MaxBatchSize
+	if chunkSize <= 0 || chunkSize > maxBatchSize {
+		chunkSize = maxBatchSize
+	}
+
+	req.SetDefaults()
+	if err := s.validateStreamRequest(req, topK); err != nil {
+		return err
+	}
+
+	candidates := req.Inputs.Sentences
+	topK = min(topK, len(candidates))
+
+	source, err := s.embedForStream(ctx, req, []string{req.Inputs.SourceSentence}, entities.InputRoleQuery)
+	if err != nil {
+		return fmt.Errorf("failed to embed source sentence: %w", err)
+	}
+
+	score := scoreFunc(req.Parameters.Metric)
+	best := newTopK(topK)
+
+	for start := 0; start < len(candidates); start += chunkSize {
+		end := min(start+chunkSize, len(candidates))
+
+		for i := start; i < end; i++ {
+			if err := s.validator.ValidateText(candidates[i], fmt.Sprintf("sentences[%d]", i)); err != nil {
+				return err
+			}
+		}
+
+		embeddings, err := s.embedForStream(ctx, req, candidates[start:end], entities.InputRoleDocument)
+		if err != nil {
+			logger.Error("Streaming similarity chunk failed",
+				zap.Int("chunk_start", start),
+				zap.Error(err),
+			)
+			return fmt.Errorf("failed to embed sentences %d-%d: %w", start, end-1, err)
+		}
+
+		for i, embedding := range embeddings {
+			best.offer(scoredIndex{Index: start + i, Score: score(source[0], embedding)})
+		}
+
+		top := best.sorted()
+		progress := &entities.SimilarityProgress{
+			TopMatches: make([]entities.SimilarityMatch, len(top)),
+			Scored:     end,
+			Total:      len(candidates),
+			Done:       end == len(candidates),
+		}
+		for i, match := range top {
+			progress.TopMatches[i] = entities.SimilarityMatch{Index: match.Index, Score: match.Score}
+		}
+
+		if err := send(progress); err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("Streaming similarity completed",
+		zap.String("metric", string(req.Parameters.Metric)),
+		zap.Int("sentences_count", len(candidates)),
+	)
+
+	return nil
+}
+
+// validateStreamRequest checks everything but the candidate texts, which
+// are validated chunk by chunk
+func (s *Service) validateStreamRequest(req *entities.SimilarityRequest, topK int) error {
+	if topK <= 0 {
+		return errors.NewValidationError("top_k", "must be positive", topK)
+	}
+
+	if len(req.Inputs.Sentences) == 0 {
+		return errors.NewValidationError("sentences", "cannot be empty", 0)
+	}
+
+	if err := s.validator.ValidateText(req.Inputs.SourceSentence, "source_sentence"); err != nil {
+		return err
+	}
+
+	if err := s.validator.ValidatePromptName(req.Parameters.PromptName); err != nil {
+		return err
+	}
+
+	if err := s.validator.ValidateTruncationDirection(req.Parameters.TruncationDirection); err != nil {
+		return err
+	}
+
+	if err := s.validator.ValidateSimilarityMetric(req.Parameters.Metric); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) embedForStream(ctx context.Context, req *entities.SimilarityRequest, sentences []string, role entities.InputRole) ([][]float32, error) {
+	resp, err := s.embedder.Embed(ctx, &entities.EmbedRequest{
+		Inputs:              entities.Input{Data: append([]string(nil), sentences...)},
+		Normalize:           entities.BoolPtr(true),
+		PromptName:          req.Parameters.PromptName,
+		Truncate:            req.Parameters.Truncate,
+		TruncationDirection: req.Parameters.TruncationDirection,
+		InputRole:           role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Embeddings) != len(sentences) {
+		return nil, errors.NewTEIError("response embedding count mismatch", errors.ErrorTypeResponseMismatch)
+	}
+
+	return resp.Embeddings, nil
+}
@@ -0,0 +1,82 @@
+package similarity
+
+import (
+	"container/heap"
+	"sort"
+)
+
+type scoredIndex struct {
+	Index int
+	Score float32
+}
+
+// less orders by score, breaking ties so that the lower index ranks higher
+func (a scoredIndex) less(b scoredIndex) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.Index > b.Index
+}
+
+// minHeap keeps the lowest-ranked candidate at the root so it can be
+// evicted in O(log k) when a better one arrives.
+type minHeap []scoredIndex
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].less(h[j]) }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(scoredIndex)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// topK tracks the k highest-ranked candidates offered so far in a bounded
+// min-heap, so candidates can arrive in chunks
+type topK struct {
+	k int
+	h minHeap
+}
+
+func newTopK(k int) *topK {
+	return &topK{k: k, h: make(minHeap, 0, max(k, 0))}
+}
+
+// offer adds candidate if it ranks among the k best so far, in O(log k)
+func (t *topK) offer(candidate scoredIndex) {
+	if t.k <= 0 {
+		return
+	}
+	if t.h.Len() < t.k {
+		heap.Push(&t.h, candidate)
+		return
+	}
+	if t.h[0].less(candidate) {
+		t.h[0] = candidate
+		heap.Fix(&t.h, 0)
+	}
+}
+
+// sorted returns the candidates kept so far in descending order, leaving
+// the heap intact for further offers
+func (t *topK) sorted() []scoredIndex {
+	if len(t.h) == 0 {
+		return nil
+	}
+	top := append([]scoredIndex(nil), t.h...)
+	sort.Slice(top, func(i, j int) bool { return top[j].less(top[i]) })
+	return top
+}
+
+// selectTopK returns the k highest scores with their indices, sorted in
+// descending order, in O(n log k).
+func selectTopK(scores []float32, k int) []scoredIndex {
+	top := newTopK(k)
+	for i, score := range scores {
+		top.offer(scoredIndex{Index: i, Score: score})
+	}
+	return top.sorted()
+}
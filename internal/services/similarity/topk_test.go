@@ -0,0 +1,151 @@
+package similarity
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/interfaces"
+
+	"go.uber.org/zap"
+)
+
+func TestSelectTopK(t *testing.T) {
+	scores := []float32{0.2, 0.9, 0.5, 0.9, -0.1, 0.7}
+
+	tests := []struct {
+		name string
+		k    int
+		want []int
+	}{
+		{"top three", 3, []int{1, 3, 5}},
+		{"ties keep the lower index first", 2, []int{1, 3}},
+		{"k beyond the candidates", 10, []int{1, 3, 5, 2, 0, 4}},
+		{"zero", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, match := range selectTopK(scores, tt.k) {
+				if match.Score != scores[match.Index] {
+					t.Errorf("index %d scored %v, want %v", match.Index, match.Score, scores[match.Index])
+				}
+				got = append(got, match.Index)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("selectTopK(k=%d) indices = %v, want %v", tt.k, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectTopKMatchesFullSort(t *testing.T) {
+	scores := randomScores(1000)
+
+	got := selectTopK(scores, 25)
+	want := sortAllTopK(scores, 25)
+
+	if !slices.Equal(got, want) {
+		t.Errorf("selectTopK = %v, want %v", got, want)
+	}
+}
+
+func TestTopKOfferedInChunks(t *testing.T) {
+	scores := randomScores(1000)
+	top := newTopK(25)
+
+	for start := 0; start < len(scores); start += 64 {
+		end := min(start+64, len(scores))
+		for i := start; i < end; i++ {
+			top.offer(scoredIndex{Index: i, Score: scores[i]})
+		}
+
+		want := sortAllTopK(scores[:end], min(25, end))
+		if got := top.sorted(); !slices.Equal(got, want) {
+			t.Fatalf("after %d scores: top = %v, want %v", end, got, want)
+		}
+	}
+}
+
+// sortAllTopK is the full-sort selection selectTopK replaced, kept as a
+// reference for correctness and benchmarks
+func sortAllTopK(scores []float32, k int) []scoredIndex {
+	all := make([]scoredIndex, len(scores))
+	for i, score := range scores {
+		all[i] = scoredIndex{Index: i, Score: score}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[j].less(all[i]) })
+	return all[:k]
+}
+
+func randomScores(n int) []float32 {
+	rng := rand.New(rand.NewSource(1))
+	scores := make([]float32, n)
+	for i := range scores {
+		scores[i] = rng.Float32()*2 - 1
+	}
+	return scores
+}
+
+func BenchmarkSelectTopK(b *testing.B) {
+	scores := randomScores(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		selectTopK(scores, 10)
+	}
+}
+
+func BenchmarkSortAllTopK(b *testing.B) {
+	scores := randomScores(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sortAllTopK(scores, 10)
+	}
+}
+
+// scoresTEI answers every /similarity request with the same precomputed scores
+type scoresTEI struct {
+	interfaces.HTTPClient
+	body []byte
+}
+
+func (c *scoresTEI) Post(ctx context.Context, endpoint string, body any) ([]byte, error) {
+	return c.body, nil
+}
+
+func BenchmarkFindMostSimilar(b *testing.B) {
+	const n = 10000
+	body, err := json.Marshal(randomScores(n))
+	if err != nil {
+		b.Fatal(err)
+	}
+	candidates := make([]string, n)
+	for i := range candidates {
+		candidates[i] = "candidate " + strconv.Itoa(i)
+	}
+
+	validation := entities.DefaultValidationConfig()
+	validation.MaxSentencesCount = n
+	validation.MaxBatchSize = n
+	s := NewService(&scoresTEI{body: body}, nil, &config.SimilarityConfig{},
+		entities.NewRolePrefixRegistry(nil), entities.NewValidator(validation), zap.NewNop())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := s.FindMostSimilar(context.Background(), "source", candidates, 10, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(result.TopMatches) != 10 {
+			b.Fatalf("got %d matches, want 10", len(result.TopMatches))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package similarity
+
+import (
+	"math"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+)
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func norm(v []float32) float32 {
+	return float32(math.Sqrt(float64(dot(v, v))))
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if either is a
+// zero vector
+func cosine(a, b []float32) float32 {
+	denominator := norm(a) * norm(b)
+	if denominator == 0 {
+		return 0
+	}
+	return dot(a, b) / denominator
+}
+
+// negativeEuclidean returns the negated euclidean distance so that higher
+// scores mean more similar vectors, as with the other metrics
+func negativeEuclidean(a, b []float32) float32 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i] - b[i])
+		sum += d * d
+	}
+	return -float32(math.Sqrt(sum))
+}
+
+func scoreFunc(metric entities.SimilarityMetric) func(a, b []float32) float32 {
+	switch metric {
+	case entities.SimilarityDot:
+		return dot
+	case entities.SimilarityEuclidean:
+		return negativeEuclidean
+	default:
+		return cosine
+	}
+}
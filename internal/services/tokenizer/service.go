@@ -0,0 +1,126 @@
+package tokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/domain/interfaces"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	httpClient interfaces.HTTPClient
+	validator  *entities.Validator
+	logger     *zap.Logger
+}
+
+func NewService(httpClient interfaces.HTTPClient, validator *entities.Validator, logger *zap.Logger) *Service {
+	return &Service{
+		httpClient: httpClient,
+		validator:  validator,
+		logger:     logger.Named("tokenizer"),
+	}
+}
+
+func (s *Service) Tokenize(ctx context.Context, req *entities.TokenizeRequest) (*entities.TokenizeResponse, error) {
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("Processing tokenize request",
+		zap.Int("input_count", len(req.Inputs.Data)),
+	)
+
+	req.SetDefaults()
+
+	if err := req.Validate(); err != nil {
+		logger.Error("Tokenize request validation failed", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.validator.ValidatePromptName(req.PromptName); err != nil {
+		return nil, err
+	}
+
+	responseData, err := s.httpClient.Post(ctx, entities.EndpointTokenize, req)
+	if err != nil {
+		logger.Error("Tokenize request failed", zap.Error(err))
+		return nil, fmt.Errorf("tokenize request failed: %w", err)
+	}
+
+	var response [][]entities.Token
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		logger.Error("Failed to parse tokenize response", zap.Error(err))
+		return nil, errors.NewTEIError("failed to parse response", errors.ErrorTypeResponseMalformed)
+	}
+
+	if len(response) != len(req.Inputs.Data) {
+		logger.Error("Response tokenization count mismatch",
+			zap.Int("expected", len(req.Inputs.Data)),
+			zap.Int("received", len(response)),
+		)
+		return nil, errors.NewTEIError("response tokenization count mismatch", errors.ErrorTypeResponseMismatch)
+	}
+
+	return &entities.TokenizeResponse{Tokens: response}, nil
+}
+
+// CountTokens tokenizes req and reports per-input and total token counts,
+// flagging inputs longer than the model's maximum input length. The limit
+// counts the special tokens TEI adds, so they are included in the check
+// when req excludes them. Truncated is left unset when the limit, or the
+// number of special tokens, is not known.
+func (s *Service) CountTokens(ctx context.Context, req *entities.TokenizeRequest) (*entities.TokenCountResponse, error) {
+	tokenized, err := s.Tokenize(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	maxInputTokens := s.validator.Config().MaxInputTokens
+	limitKnown := maxInputTokens > 0
+	special := 0
+	if limitKnown && !*req.AddSpecialTokens {
+		if special, err = s.SpecialTokenCount(ctx); err != nil {
+			logging.FromContext(ctx, s.logger).Debug("Truncation check skipped, special token count unknown", zap.Error(err))
+			limitKnown = false
+		}
+	}
+
+	resp := &entities.TokenCountResponse{
+		Counts:         make([]entities.TokenCount, len(tokenized.Tokens)),
+		MaxInputTokens: maxInputTokens,
+	}
+	for i, tokens := range tokenized.Tokens {
+		resp.Counts[i] = entities.TokenCount{Tokens: len(tokens)}
+		if limitKnown {
+			resp.Counts[i].Truncated = entities.BoolPtr(len(tokens)+special > maxInputTokens)
+		}
+		resp.Total += len(tokens)
+	}
+
+	return resp, nil
+}
+
+// SpecialTokenCount returns how many special tokens TEI adds to an input
+func (s *Service) SpecialTokenCount(ctx context.Context) (int, error) {
+	resp, err := s.Tokenize(ctx, &entities.TokenizeRequest{
+		Inputs:           entities.Input{Data: []string{"a"}},
+		AddSpecialTokens: entities.BoolPtr(true),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Tokens) == 0 {
+		return 0, fmt.Errorf("expected 1 tokenized input, got 0")
+	}
+
+	count := 0
+	for _, token := range resp.Tokens[0] {
+		if token.Special {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,82 @@
+package tokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/interfaces"
+
+	"go.uber.org/zap"
+)
+
+// wordTEI tokenizes on whitespace, wrapping each input in [CLS] and [SEP]
+// when special tokens are added
+type wordTEI struct {
+	interfaces.HTTPClient
+}
+
+func (wordTEI) Post(_ context.Context, _ string, body any) ([]byte, error) {
+	req := body.(*entities.TokenizeRequest)
+	tokens := make([][]entities.Token, len(req.Inputs.Data))
+	for i, input := range req.Inputs.Data {
+		if *req.AddSpecialTokens {
+			tokens[i] = append(tokens[i], entities.Token{Text: "[CLS]", Special: true})
+		}
+		for _, word := range strings.Fields(input) {
+			tokens[i] = append(tokens[i], entities.Token{Text: word})
+		}
+		if *req.AddSpecialTokens {
+			tokens[i] = append(tokens[i], entities.Token{Text: "[SEP]", Special: true})
+		}
+	}
+	return json.Marshal(tokens)
+}
+
+func TestCountTokensTruncated(t *testing.T) {
+	inputs := []string{"one two", "one two three", "one two three four five"}
+
+	tests := []struct {
+		name           string
+		maxInputTokens int
+		addSpecial     bool
+		wantTokens     []int
+		wantTruncated  []bool
+	}{
+		{"with special tokens", 5, true, []int{4, 5, 7}, []bool{false, false, true}},
+		{"special tokens count against the limit", 5, false, []int{2, 3, 5}, []bool{false, false, true}},
+		{"unknown limit", 0, false, []int{2, 3, 5}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validation := entities.DefaultValidationConfig()
+			validation.MaxInputTokens = tt.maxInputTokens
+			s := NewService(wordTEI{}, entities.NewValidator(validation), zap.NewNop())
+
+			resp, err := s.CountTokens(context.Background(), &entities.TokenizeRequest{
+				Inputs:           entities.Input{Data: inputs},
+				AddSpecialTokens: entities.BoolPtr(tt.addSpecial),
+			})
+			if err != nil {
+				t.Fatalf("CountTokens: %v", err)
+			}
+
+			for i, count := range resp.Counts {
+				if count.Tokens != tt.wantTokens[i] {
+					t.Errorf("input %d: tokens = %d, want %d", i, count.Tokens, tt.wantTokens[i])
+				}
+				switch {
+				case tt.wantTruncated == nil && count.Truncated != nil:
+					t.Errorf("input %d: truncated = %v, want it unset", i, *count.Truncated)
+				case tt.wantTruncated != nil && count.Truncated == nil:
+					t.Errorf("input %d: truncated unset, want %v", i, tt.wantTruncated[i])
+				case tt.wantTruncated != nil && *count.Truncated != tt.wantTruncated[i]:
+					t.Errorf("input %d: truncated = %v, want %v", i, *count.Truncated, tt.wantTruncated[i])
+				}
+			}
+		})
+	}
+}
@@ -2,12 +2,32 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/cache"
 	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/metrics"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/ratelimit"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/requestid"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/tlsutil"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/tracing"
 	"github.com/blackprince001/embedding-inference/internal/infrastructure/wrapper"
 	"github.com/blackprince001/embedding-inference/internal/server"
 
@@ -15,13 +35,27 @@ import (
 
 	"github.com/blackprince001/embedding-inference/pkg/client"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func main() {
-	cfg, err := config.LoadConfig()
+	configPath := flag.String("config", os.Getenv("TEI_CLIENT_CONFIG"), "path to the config file (default configs/docker.yaml), also set by TEI_CLIENT_CONFIG")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("failed to load config: %s", err)
 	}
@@ -32,61 +66,715 @@ func main() {
 		log.Fatalf("failed to create logger: %s", err)
 	}
 
+	tracingCfg := cfg.Tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), &tracingCfg)
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %s", err)
+	}
+
 	teiCfg := cfg.TEI
-	httpClient, err := wrapper.NewHTTPClient(&teiCfg, logger)
+	clientCfg := cfg.Client
+	httpClient, err := wrapper.NewHTTPClient(&teiCfg, &clientCfg, logger)
 	if err != nil {
 		log.Fatalf("failed to create HTTP client: %s", err)
 	}
 
+	if cfg.TEI.Warmup {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.TEI.Timeout)
+		err := httpClient.Warmup(ctx)
+		cancel()
+		if err != nil {
+			log.Fatalf("TEI warmup failed: %s", err)
+		}
+	}
+
 	client := client.NewClient(cfg, httpClient, logger)
 
+	if cfg.Validation.UseModelInfo {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.TEI.Timeout)
+		if _, err := client.ApplyModelLimits(ctx); err != nil {
+			logger.Warn("Could not fetch model info, using configured validation limits", zap.Error(err))
+		}
+		cancel()
+	}
+
+	if cfg.TEI.APIVersion == wrapper.APIVersionAuto {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.TEI.Timeout)
+		info, err := client.Info(ctx)
+		cancel()
+		if err == nil {
+			err = httpClient.SetAPIVersion(info.Version)
+		}
+		if err != nil {
+			logger.Warn("Could not detect the TEI version, targeting the latest API", zap.Error(err))
+		}
+	}
+
+	creds, err := serverCredentials(&cfg.GRPC)
+	if err != nil {
+		log.Fatalf("failed to configure gRPC transport security: %s", err)
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.GRPC.MaxConcurrentRequests > 0 {
+		limiter = ratelimit.NewLimiter(cfg.GRPC.MaxConcurrentRequests, cfg.GRPC.QueueDepth, cfg.GRPC.QueueTimeout)
+		metrics.RegisterQueueDepth(func() float64 { return float64(limiter.Queued()) })
+	}
+	unaryLimit, streamLimit := concurrencyLimitInterceptors(limiter)
+	unaryAuth, streamAuth := apiKeyInterceptors(&cfg.GRPC)
+
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(loggingInterceptor(logger.Logger)),
-		grpc.MaxRecvMsgSize(16*1024*1024), // 16MB max message size
-		grpc.MaxSendMsgSize(16*1024*1024),
+		creds,
+		grpc.ChainUnaryInterceptor(
+			requestIDInterceptor(),
+			tracingInterceptor(),
+			metricsInterceptor(),
+			unaryAuth,
+			unaryLimit,
+			loggingInterceptor(logger.Logger, cfg.Log.RedactInputs),
+			timeoutInterceptor(&cfg.GRPC),
+			recoveryInterceptor(logger.Logger),
+		),
+		grpc.ChainStreamInterceptor(
+			streamRequestIDInterceptor(),
+			streamTracingInterceptor(),
+			streamMetricsInterceptor(),
+			streamAuth,
+			streamLimit,
+			streamLoggingInterceptor(logger.Logger),
+			streamTimeoutInterceptor(&cfg.GRPC),
+			streamRecoveryInterceptor(logger.Logger),
+		),
+		grpc.MaxRecvMsgSize(cfg.GRPC.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(cfg.GRPC.MaxSendMsgSize),
 	)
 
-	textEmbeddingsServer := server.NewServer(client, logger.Logger)
+	textEmbeddingsServer := server.NewServer(client, logger.Logger, cfg.GRPC.MaxSendMsgSize)
 	pb.RegisterTextEmbeddingsServiceServer(grpcServer, textEmbeddingsServer)
 
 	reflection.Register(grpcServer)
 
+	var metricsServer *http.Server
+	if cfg.GRPC.MetricsPort > 0 {
+		metricsServer = serveMetrics(cfg.GRPC.MetricsPort, httpClient, client, limiter, logger.Logger)
+	}
+
 	ls, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", cfg.GRPC.Port))
 	if err != nil {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	if err := grpcServer.Serve(ls); err != nil {
-		log.Fatalf("Failed to serve gRPC server: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(ls)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			log.Fatalf("Failed to serve gRPC server: %v", err)
+		}
+	case <-ctx.Done():
+		logger.Info("Shutting down", zap.Duration("drain_timeout", cfg.GRPC.ShutdownTimeout))
+	}
+
+	shutdown(grpcServer, metricsServer, cfg.GRPC.ShutdownTimeout, logger.Logger)
+
+	client.Close()
+	httpClient.Close()
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.GRPC.ShutdownTimeout)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("Failed to flush traces", zap.Error(err))
+	}
+	cancel()
+
+	_ = logger.Sync()
+}
+
+// serverCredentials returns the transport credentials for the gRPC server,
+// refusing to run without TLS unless insecure transport is explicitly allowed
+func serverCredentials(cfg *config.GRPCConfig) (grpc.ServerOption, error) {
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		if cfg.ClientCAFile != "" {
+			return nil, fmt.Errorf("grpc.client_ca_file requires grpc.tls_cert_file and grpc.tls_key_file")
+		}
+		if !cfg.AllowInsecure {
+			return nil, fmt.Errorf("no TLS certificate configured and grpc.allow_insecure is false")
+		}
+		return grpc.Creds(insecure.NewCredentials()), nil
+	}
+
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return nil, fmt.Errorf("grpc.tls_cert_file and grpc.tls_key_file must be set together")
+	}
+
+	tlsConfig, err := tlsutil.ServerConfig(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpc.Creds(credentials.NewTLS(tlsConfig)), nil
+}
+
+// shutdown lets in-flight RPCs finish within timeout before forcing the
+// gRPC server closed, then stops the metrics server
+func shutdown(grpcServer *grpc.Server, metricsServer *http.Server, timeout time.Duration, logger *zap.Logger) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+	case <-timer.C:
+		logger.Warn("Drain timeout exceeded, closing remaining connections")
+		grpcServer.Stop()
+	}
+
+	if metricsServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			logger.Error("Failed to shut down metrics server", zap.Error(err))
+		}
 	}
 }
 
-func loggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+// loggingInterceptor logs every RPC with a summary of its request and
+// response. Full payloads, which contain caller text and vectors, are only
+// logged at debug level and only when redactInputs is false.
+func loggingInterceptor(logger *zap.Logger, redactInputs bool) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req any,
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (resp any, err error) {
-		logger.Info("Received gRPC request",
+		// The request fields are also carried in ctx so that services log
+		// them on every line
+		ctx = logging.WithFields(ctx,
 			zap.String("method", info.FullMethod),
-			zap.Any("request", req),
+			zap.String("request_id", requestid.FromContext(ctx)),
 		)
+		logger := logging.FromContext(ctx, logger)
+
+		logger.Info("Received gRPC request", server.RequestLogFields(req)...)
+		if !redactInputs {
+			logger.Debug("gRPC request payload", zap.Any("request", req))
+		}
 
 		resp, err = handler(ctx, req)
 
 		if err != nil {
-			logger.Error("gRPC request failed",
-				zap.String("method", info.FullMethod),
-				zap.Error(err),
-			)
+			logger.Error("gRPC request failed", zap.Error(err))
+		} else {
+			logger.Info("gRPC request succeeded", server.ResponseLogFields(resp)...)
+			if !redactInputs {
+				logger.Debug("gRPC response payload", zap.Any("response", resp))
+			}
+		}
+
+		return resp, err
+	}
+}
+
+// streamLoggingInterceptor is loggingInterceptor for streaming RPCs. Stream
+// messages are not logged, only the start and end of the call.
+func streamLoggingInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := logging.WithFields(stream.Context(),
+			zap.String("method", info.FullMethod),
+			zap.String("request_id", requestid.FromContext(stream.Context())),
+		)
+		logger := logging.FromContext(ctx, logger)
+
+		logger.Info("Received gRPC stream")
+
+		err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+
+		if err != nil {
+			logger.Error("gRPC stream failed", zap.Error(err))
 		} else {
-			logger.Info("gRPC request succeeded",
-				zap.String("method", info.FullMethod),
-				zap.Any("response", resp),
-			)
+			logger.Info("gRPC stream completed")
+		}
+
+		return err
+	}
+}
+
+// contextStream replaces the context of a server stream, so that stream
+// interceptors can pass values and deadlines on to the handler
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// requestIDInterceptor places the caller's x-request-id, or a newly
+// generated one, in the context and returns it in the response headers
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		id := incomingRequestID(ctx)
+
+		ctx = requestid.NewContext(ctx, id)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id))
+
+		return handler(ctx, req)
+	}
+}
+
+// streamRequestIDInterceptor is requestIDInterceptor for streaming RPCs
+func streamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		id := incomingRequestID(stream.Context())
+
+		ctx := requestid.NewContext(stream.Context(), id)
+		_ = stream.SetHeader(metadata.Pairs(requestid.MetadataKey, id))
+
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// incomingRequestID returns the caller's x-request-id, or a newly generated
+// one when it sent none
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestid.MetadataKey); len(values) > 0 && values[0] != "" {
+			return values[0]
 		}
+	}
+	return requestid.New()
+}
+
+// tracingInterceptor starts a server span for each RPC, continuing any
+// trace context the caller sent in metadata
+func tracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		ctx, span := startServerSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		resp, err = handler(ctx, req)
+
+		endServerSpan(span, err)
+		return resp, err
+	}
+}
+
+// streamTracingInterceptor is tracingInterceptor for streaming RPCs
+func streamTracingInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, span := startServerSpan(stream.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+
+		endServerSpan(span, err)
+		return err
+	}
+}
+
+// startServerSpan starts the server span of an RPC as a child of any trace
+// context in the incoming metadata
+func startServerSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, tracing.MetadataCarrier(md))
+	}
+
+	return tracing.Tracer().Start(ctx, strings.TrimPrefix(fullMethod, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", fullMethod),
+			attribute.String("request_id", requestid.FromContext(ctx)),
+		),
+	)
+}
+
+// endServerSpan records the outcome of an RPC on its span
+func endServerSpan(span trace.Span, err error) {
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
+
+func metricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		start := time.Now()
+
+		resp, err = handler(ctx, req)
+
+		metrics.RPCDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		metrics.RPCRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
 
 		return resp, err
 	}
 }
+
+// streamMetricsInterceptor is metricsInterceptor for streaming RPCs; the
+// duration covers the whole stream
+func streamMetricsInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+
+		err := handler(srv, stream)
+
+		metrics.RPCDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		metrics.RPCRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+
+		return err
+	}
+}
+
+// timeoutMetadataKey lets callers without native deadline support request a
+// timeout for a single RPC, as a Go duration string such as "90s"
+const timeoutMetadataKey = "x-tei-timeout"
+
+// timeoutInterceptor bounds RPCs that arrive without a deadline by the
+// x-tei-timeout metadata value, or else the configured per-method or
+// default request timeout
+func timeoutInterceptor(cfg *config.GRPCConfig) grpc.UnaryServerInterceptor {
+	timeoutFor := rpcTimeouts(cfg, cfg.RequestTimeout)
+
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		timeout, err := timeoutFor(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
+
+// streamTimeoutInterceptor is timeoutInterceptor for streaming RPCs. The
+// timeout bounds the whole stream, so the default request timeout is not
+// applied: long bulk streams making steady progress would be cut off. Only
+// a per-method timeout or the x-tei-timeout metadata bounds a stream.
+func streamTimeoutInterceptor(cfg *config.GRPCConfig) grpc.StreamServerInterceptor {
+	timeoutFor := rpcTimeouts(cfg, 0)
+
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		timeout, err := timeoutFor(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		if timeout <= 0 {
+			return handler(srv, stream)
+		}
+
+		ctx, cancel := context.WithTimeout(stream.Context(), timeout)
+		defer cancel()
+
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// rpcTimeouts returns a function giving the timeout to apply to an RPC of
+// fullMethod, falling back to defaultTimeout, or zero when it already has a
+// deadline or none is configured
+func rpcTimeouts(cfg *config.GRPCConfig, defaultTimeout time.Duration) func(ctx context.Context, fullMethod string) (time.Duration, error) {
+	methodTimeouts := make(map[string]time.Duration, len(cfg.MethodTimeouts))
+	for method, timeout := range cfg.MethodTimeouts {
+		methodTimeouts[strings.ToLower(method)] = timeout
+	}
+
+	return func(ctx context.Context, fullMethod string) (time.Duration, error) {
+		if _, ok := ctx.Deadline(); ok {
+			return 0, nil
+		}
+
+		timeout := defaultTimeout
+		if methodTimeout, ok := methodTimeouts[strings.ToLower(path.Base(fullMethod))]; ok {
+			timeout = methodTimeout
+		}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(timeoutMetadataKey); len(values) > 0 {
+				requested, err := time.ParseDuration(values[0])
+				if err != nil || requested <= 0 {
+					return 0, status.Errorf(codes.InvalidArgument,
+						"invalid %s metadata %q: must be a positive duration", timeoutMetadataKey, values[0])
+				}
+				timeout = requested
+			}
+		}
+		return timeout, nil
+	}
+}
+
+// concurrencyLimitInterceptors bound the RPCs in flight with limiter,
+// shared between unary and streaming calls. Calls that get no slot fail
+// with ResourceExhausted, or with the context's error if the caller gives
+// up while queued. A nil limiter disables the limit.
+func concurrencyLimitInterceptors(limiter *ratelimit.Limiter) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	acquire := func(ctx context.Context) error {
+		if limiter == nil {
+			return nil
+		}
+		err := limiter.Acquire(ctx)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+			return status.FromContextError(err).Err()
+		default:
+			return status.Errorf(codes.ResourceExhausted, "server is at its concurrent request limit: %v", err)
+		}
+	}
+	release := func() {
+		if limiter != nil {
+			limiter.Release()
+		}
+	}
+
+	unary := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := acquire(ctx); err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := acquire(stream.Context()); err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, stream)
+	}
+
+	return unary, stream
+}
+
+// apiKey is a configured API key, known only by its hash
+type apiKey struct {
+	hash   []byte
+	label  string
+	bucket *ratelimit.Bucket
+}
+
+// apiKeyInterceptors reject calls whose authorization metadata does not
+// hold one of the configured API keys with Unauthenticated, and calls over
+// their key's rate limit with ResourceExhausted and a RetryInfo detail.
+// The health and reflection services stay open, as does every method when
+// no keys are configured. Presented keys are compared by hash and never
+// logged.
+func apiKeyInterceptors(cfg *config.GRPCConfig) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	limits := make(map[string]config.APIKeyLimitConfig, len(cfg.APIKeyLimits))
+	for _, limit := range cfg.APIKeyLimits {
+		limits[limit.KeyHash] = limit
+	}
+
+	allowed := make([]apiKey, 0, len(cfg.APIKeyHashes))
+	for _, hash := range cfg.APIKeyHashes {
+		decoded, _ := hex.DecodeString(hash)
+		key := apiKey{hash: decoded, label: hash[:8]}
+		if limit, ok := limits[hash]; ok {
+			if limit.Name != "" {
+				key.label = limit.Name
+			}
+			key.bucket = ratelimit.New(limit.RequestsPerSecond, limit.Burst)
+		}
+		allowed = append(allowed, key)
+	}
+
+	authorize := func(ctx context.Context, method string) error {
+		if len(allowed) == 0 || strings.HasPrefix(method, "/grpc.health.") || strings.HasPrefix(method, "/grpc.reflection.") {
+			return nil
+		}
+
+		values := metadata.ValueFromIncomingContext(ctx, "authorization")
+		if len(values) == 0 {
+			return status.Error(codes.Unauthenticated, "missing API key")
+		}
+		key := strings.TrimSpace(values[0])
+		if scheme, token, ok := strings.Cut(key, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			key = strings.TrimSpace(token)
+		}
+
+		sum := sha256.Sum256([]byte(key))
+		for _, caller := range allowed {
+			if subtle.ConstantTimeCompare(sum[:], caller.hash) == 1 {
+				return caller.admit()
+			}
+		}
+		return status.Error(codes.Unauthenticated, "invalid API key")
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+
+	return unary, stream
+}
+
+// admit takes a token from the key's rate limit, if it has one, and
+// counts the call
+func (k apiKey) admit() error {
+	if k.bucket != nil {
+		if ok, wait := k.bucket.Allow(); !ok {
+			metrics.APIKeyRequests.WithLabelValues(k.label, "rejected").Inc()
+			st := status.Newf(codes.ResourceExhausted, "rate limit exceeded for API key %q", k.label)
+			if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+				st = detailed
+			}
+			return st.Err()
+		}
+	}
+
+	metrics.APIKeyRequests.WithLabelValues(k.label, "allowed").Inc()
+	return nil
+}
+
+// recoveryInterceptor turns a panic in a handler into codes.Internal
+// instead of crashing the server
+func recoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Recovered from panic in gRPC handler",
+					zap.String("method", info.FullMethod),
+					zap.Any("panic", r),
+					zap.ByteString("stack", debug.Stack()),
+				)
+				err = status.Errorf(codes.Internal, "internal error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// streamRecoveryInterceptor is recoveryInterceptor for streaming RPCs
+func streamRecoveryInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Recovered from panic in gRPC stream handler",
+					zap.String("method", info.FullMethod),
+					zap.Any("panic", r),
+					zap.ByteString("stack", debug.Stack()),
+				)
+				err = status.Errorf(codes.Internal, "internal error")
+			}
+		}()
+
+		return handler(srv, stream)
+	}
+}
+
+// serveMetrics serves Prometheus metrics on /metrics and a JSON snapshot of
+// the TEI client counters, replica state, cache counters and request queue
+// depth on /stats
+func serveMetrics(port int, httpClient *wrapper.Client, embeddingClient *client.Client, limiter *ratelimit.Limiter, logger *zap.Logger) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		stats := struct {
+			wrapper.Stats
+			metrics.EmbeddingStats
+			Cache          *cache.Stats `json:"cache,omitempty"`
+			QueuedRequests int64        `json:"queued_requests"`
+		}{httpClient.Stats(), metrics.CurrentEmbeddingStats(), embeddingClient.CacheStats(), limiter.Queued()}
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			logger.Error("Failed to write client stats", zap.Error(err))
+		}
+	})
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("0.0.0.0:%d", port),
+		Handler: mux,
+	}
+	logger.Info("Serving metrics", zap.String("addr", srv.Addr))
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Metrics server stopped", zap.Error(err))
+		}
+	}()
+
+	return srv
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/ratelimit"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/requestid"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeServerStream is a grpc.ServerStream carrying only a context and the
+// headers set on it
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestStreamInterceptorsPropagateContext(t *testing.T) {
+	chain := []grpc.StreamServerInterceptor{
+		streamRequestIDInterceptor(),
+		streamTracingInterceptor(),
+		streamMetricsInterceptor(),
+		streamLoggingInterceptor(zap.NewNop()),
+		streamTimeoutInterceptor(&config.GRPCConfig{
+			RequestTimeout: time.Minute,
+			MethodTimeouts: map[string]time.Duration{"EmbedStream": time.Hour},
+		}),
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(requestid.MetadataKey, "req-123"))
+	stream := &fakeServerStream{ctx: ctx}
+	info := &grpc.StreamServerInfo{FullMethod: "/tei.v1.TextEmbeddingsService/EmbedStream"}
+
+	var handlerCtx context.Context
+	handler := func(srv any, stream grpc.ServerStream) error {
+		handlerCtx = stream.Context()
+		return nil
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		interceptor, next := chain[i], handler
+		handler = func(srv any, stream grpc.ServerStream) error {
+			return interceptor(srv, stream, info, next)
+		}
+	}
+
+	if err := handler(nil, stream); err != nil {
+		t.Fatalf("stream chain: %v", err)
+	}
+
+	if got := requestid.FromContext(handlerCtx); got != "req-123" {
+		t.Errorf("request ID in handler context = %q, want %q", got, "req-123")
+	}
+	if got := stream.header.Get(requestid.MetadataKey); len(got) != 1 || got[0] != "req-123" {
+		t.Errorf("request ID header = %v, want [req-123]", got)
+	}
+	if _, ok := handlerCtx.Deadline(); !ok {
+		t.Error("handler context has no deadline, want the EmbedStream method timeout")
+	}
+}
+
+func TestStreamTimeoutInterceptorSkipsDefaultTimeout(t *testing.T) {
+	interceptor := streamTimeoutInterceptor(&config.GRPCConfig{
+		RequestTimeout: time.Minute,
+		MethodTimeouts: map[string]time.Duration{"StreamSimilarity": time.Hour},
+	})
+
+	for _, tt := range []struct {
+		method      string
+		wantTimeout bool
+	}{
+		{"/tei.v1.TextEmbeddingsService/EmbedStream", false},
+		{"/tei.v1.TextEmbeddingsService/StreamSimilarity", true},
+	} {
+		var hasDeadline bool
+		err := interceptor(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: tt.method},
+			func(_ any, stream grpc.ServerStream) error {
+				_, hasDeadline = stream.Context().Deadline()
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.method, err)
+		}
+		if hasDeadline != tt.wantTimeout {
+			t.Errorf("%s: deadline set = %v, want %v", tt.method, hasDeadline, tt.wantTimeout)
+		}
+	}
+}
+
+func TestStreamTimeoutInterceptorRejectsInvalidTimeout(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(timeoutMetadataKey, "soon"))
+	interceptor := streamTimeoutInterceptor(&config.GRPCConfig{})
+
+	err := interceptor(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/svc/Stream"},
+		func(any, grpc.ServerStream) error {
+			t.Fatal("handler called despite an invalid timeout")
+			return nil
+		})
+	if err == nil {
+		t.Fatal("expected an error for an invalid timeout")
+	}
+}
+
+func TestConcurrencyLimitRejectsUnaryOverLimit(t *testing.T) {
+	unary, _ := concurrencyLimitInterceptors(ratelimit.NewLimiter(1, 0, time.Second))
+	info := &grpc.UnaryServerInfo{FullMethod: "/tei.v1.TextEmbeddingsService/Embed"}
+
+	holding, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := unary(context.Background(), nil, info, func(context.Context, any) (any, error) {
+			close(holding)
+			<-release
+			return nil, nil
+		})
+		done <- err
+	}()
+	<-holding
+
+	_, err := unary(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		t.Error("handler called over the concurrency limit")
+		return nil, nil
+	})
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Errorf("code = %v while the slot is held, want %v", got, codes.ResourceExhausted)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("call holding the slot: %v", err)
+	}
+	if _, err := unary(context.Background(), nil, info, func(context.Context, any) (any, error) { return nil, nil }); err != nil {
+		t.Errorf("call after the slot was released: %v", err)
+	}
+}
+
+func TestConcurrencyLimitRejectsStreamOverLimit(t *testing.T) {
+	_, stream := concurrencyLimitInterceptors(ratelimit.NewLimiter(1, 0, time.Second))
+	info := &grpc.StreamServerInfo{FullMethod: "/tei.v1.TextEmbeddingsService/EmbedStream"}
+
+	holding, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- stream(nil, &fakeServerStream{ctx: context.Background()}, info, func(any, grpc.ServerStream) error {
+			close(holding)
+			<-release
+			return nil
+		})
+	}()
+	<-holding
+
+	err := stream(nil, &fakeServerStream{ctx: context.Background()}, info, func(any, grpc.ServerStream) error {
+		t.Error("handler called over the concurrency limit")
+		return nil
+	})
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Errorf("code = %v while the slot is held, want %v", got, codes.ResourceExhausted)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("stream holding the slot: %v", err)
+	}
+}
+
+// callWithKey runs a unary call through interceptor, presenting key as
+// the authorization metadata unless it is empty
+func callWithKey(interceptor grpc.UnaryServerInterceptor, key string) error {
+	ctx := context.Background()
+	if key != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", key))
+	}
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/tei.v1.TextEmbeddingsService/Embed"},
+		func(context.Context, any) (any, error) { return nil, nil })
+	return err
+}
+
+func TestAPIKeyInterceptorAuthenticates(t *testing.T) {
+	sum := sha256.Sum256([]byte("s3cret"))
+	unary, _ := apiKeyInterceptors(&config.GRPCConfig{APIKeyHashes: []string{hex.EncodeToString(sum[:])}})
+
+	for _, tt := range []struct {
+		name string
+		key  string
+		want codes.Code
+	}{
+		{"bare key", "s3cret", codes.OK},
+		{"bearer key", "Bearer s3cret", codes.OK},
+		{"missing key", "", codes.Unauthenticated},
+		{"wrong key", "Bearer guess", codes.Unauthenticated},
+	} {
+		if got := status.Code(callWithKey(unary, tt.key)); got != tt.want {
+			t.Errorf("%s: code = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyInterceptorRateLimits(t *testing.T) {
+	sum := sha256.Sum256([]byte("s3cret"))
+	hash := hex.EncodeToString(sum[:])
+	const burst = 3
+	unary, _ := apiKeyInterceptors(&config.GRPCConfig{
+		APIKeyHashes: []string{hash},
+		APIKeyLimits: []config.APIKeyLimitConfig{{Name: "test", KeyHash: hash, RequestsPerSecond: 0.01, Burst: burst}},
+	})
+
+	for i := 0; i < burst; i++ {
+		if err := callWithKey(unary, "s3cret"); err != nil {
+			t.Fatalf("call %d within the burst: %v", i+1, err)
+		}
+	}
+
+	err := callWithKey(unary, "s3cret")
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Fatalf("code = %v past the burst, want %v", got, codes.ResourceExhausted)
+	}
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	if retryInfo == nil || retryInfo.GetRetryDelay().AsDuration() <= 0 {
+		t.Errorf("RetryInfo = %v, want a positive retry delay", retryInfo)
+	}
+}
+
+// testCA is a throwaway certificate authority for TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := &testCA{cert: cert, key: key, dir: t.TempDir()}
+	ca.write(t, "ca.crt", "CERTIFICATE", der)
+	return ca
+}
+
+// issue writes a certificate for name signed by the CA and its key,
+// returning their paths
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ca.write(t, name+".crt", "CERTIFICATE", der), ca.write(t, name+".key", "EC PRIVATE KEY", keyDER)
+}
+
+func (ca *testCA) write(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(ca.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// serveHealth runs a gRPC server with only the health service, using the
+// credentials cfg selects, and returns its address
+func serveHealth(t *testing.T, cfg *config.GRPCConfig) string {
+	t.Helper()
+
+	creds, err := serverCredentials(cfg)
+	if err != nil {
+		t.Fatalf("serverCredentials: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer(creds)
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	return listener.Addr().String()
+}
+
+// checkHealth calls the health service at addr over creds
+func checkHealth(t *testing.T, addr string, creds credentials.TransportCredentials) error {
+	t.Helper()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestServerCredentialsTLS(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	addr := serveHealth(t, &config.GRPCConfig{TLSCertFile: certFile, TLSKeyFile: keyFile})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	if err := checkHealth(t, addr, credentials.NewTLS(&tls.Config{RootCAs: roots})); err != nil {
+		t.Errorf("TLS client: %v", err)
+	}
+	if err := checkHealth(t, addr, insecure.NewCredentials()); err == nil {
+		t.Error("plaintext client was accepted by a TLS server")
+	}
+}
+
+func TestServerCredentialsMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	addr := serveHealth(t, &config.GRPCConfig{
+		TLSCertFile:  certFile,
+		TLSKeyFile:   keyFile,
+		ClientCAFile: filepath.Join(ca.dir, "ca.crt"),
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCertFile, clientKeyFile := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkHealth(t, addr, credentials.NewTLS(&tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{clientCert},
+	})); err != nil {
+		t.Errorf("client with a certificate: %v", err)
+	}
+	if err := checkHealth(t, addr, credentials.NewTLS(&tls.Config{RootCAs: roots})); err == nil {
+		t.Error("client without a certificate was accepted under mTLS")
+	}
+}
+
+func TestServerCredentialsRequireExplicitInsecure(t *testing.T) {
+	if _, err := serverCredentials(&config.GRPCConfig{}); err == nil {
+		t.Error("serverCredentials allowed plaintext without grpc.allow_insecure")
+	}
+	if _, err := serverCredentials(&config.GRPCConfig{AllowInsecure: true}); err != nil {
+		t.Errorf("serverCredentials with grpc.allow_insecure: %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+)
+
+// ChunkOptions controls how EmbedDocument splits a document
+type ChunkOptions struct {
+	// MaxTokens is the largest chunk in tokens, excluding the special
+	// tokens TEI adds and the tokens of the default prompt. Zero uses the
+	// model's maximum input length, and larger values are capped to it.
+	MaxTokens int
+
+	// OverlapTokens is how many tokens each chunk repeats from the end of
+	// the previous one. It must be smaller than MaxTokens.
+	OverlapTokens int
+
+	Normalize bool
+}
+
+// DocumentChunk is one embedded piece of a document. Start and End are
+// byte offsets into the document, so Text == document[Start:End].
+type DocumentChunk struct {
+	Text      string
+	Start     int
+	End       int
+	Embedding []float32
+}
+
+// docToken is a token of the document with offsets into the whole document
+type docToken struct {
+	start, stop int
+}
+
+// segment is a byte range of the document tokenized as one input.
+// continued is set when it is the rest of a sentence that was too long.
+type segment struct {
+	start, end int
+	continued  bool
+}
+
+// EmbedDocument splits a long document into chunks of at most
+// opts.MaxTokens tokens and embeds them. Chunks end at sentence boundaries
+// where one fits and are cut between tokens otherwise. Call
+// ApplyModelLimits first unless opts.MaxTokens is set.
+func (c *Client) EmbedDocument(ctx context.Context, document string, opts ChunkOptions) ([]DocumentChunk, error) {
+	validationCfg := c.validator.Config()
+
+	maxTokens := opts.MaxTokens
+	if limit := validationCfg.MaxInputTokens; limit > 0 {
+		reserved, err := c.inputOverhead(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if maxTokens <= 0 || maxTokens > limit-reserved {
+			maxTokens = limit - reserved
+		}
+	}
+	if maxTokens <= 0 {
+		return nil, fmt.Errorf("chunk size unknown: set MaxTokens or call ApplyModelLimits")
+	}
+	if opts.OverlapTokens < 0 || opts.OverlapTokens >= maxTokens {
+		return nil, fmt.Errorf("overlap of %d tokens must be non-negative and below the chunk size of %d", opts.OverlapTokens, maxTokens)
+	}
+
+	segments := splitSentences(document, validationCfg.MaxInputLength)
+	tokens, boundaries, err := c.tokenizeSegments(ctx, document, segments)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	spans := chunkTokens(tokens, boundaries, maxTokens, opts.OverlapTokens, validationCfg.MaxInputLength)
+	chunks := make([]DocumentChunk, len(spans))
+	texts := make([]string, len(spans))
+	for i, span := range spans {
+		start, end := tokens[span[0]].start, tokens[span[1]-1].stop
+		chunks[i] = DocumentChunk{Text: document[start:end], Start: start, End: end}
+		texts[i] = chunks[i].Text
+	}
+
+	resp, err := c.Embed(ctx, &entities.EmbedRequest{
+		Inputs:    entities.Input{Data: texts},
+		Normalize: &opts.Normalize,
+		AutoBatch: entities.BoolPtr(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(chunks) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(chunks), len(resp.Embeddings))
+	}
+
+	for i := range chunks {
+		chunks[i].Embedding = resp.Embeddings[i]
+	}
+	return chunks, nil
+}
+
+// specialTokenCount returns how many special tokens TEI adds to an input,
+// which a chunk must leave room for
+func (c *Client) specialTokenCount(ctx context.Context) (int, error) {
+	return c.tokenizerService.SpecialTokenCount(ctx)
+}
+
+// inputOverhead returns how many tokens Embed adds to every chunk: the
+// special tokens and, when one is configured, the default prompt. The
+// prompt is measured by tokenizing a probe with and without it.
+func (c *Client) inputOverhead(ctx context.Context) (int, error) {
+	promptName := c.config.Embedding.DefaultPromptName
+	if promptName == "" {
+		return c.specialTokenCount(ctx)
+	}
+
+	probe := []string{"a"}
+	prompted := &entities.TokenizeRequest{
+		Inputs:           entities.Input{Data: probe},
+		AddSpecialTokens: entities.BoolPtr(true),
+	}
+	if c.config.Embedding.ExpandPrompts {
+		expanded, err := entities.NewPromptRegistry(c.config.Embedding.Prompts).Expand(promptName, probe)
+		if err != nil {
+			return 0, err
+		}
+		prompted.Inputs.Data = expanded
+	} else {
+		prompted.PromptName = &promptName
+	}
+
+	withPrompt, err := c.countProbeTokens(ctx, prompted)
+	if err != nil {
+		return 0, err
+	}
+	bare, err := c.countProbeTokens(ctx, &entities.TokenizeRequest{
+		Inputs:           entities.Input{Data: probe},
+		AddSpecialTokens: entities.BoolPtr(false),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return max(withPrompt-bare, 0), nil
+}
+
+// countProbeTokens returns the token count of the single input of req
+func (c *Client) countProbeTokens(ctx context.Context, req *entities.TokenizeRequest) (int, error) {
+	resp, err := c.Tokenize(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Tokens) == 0 {
+		return 0, fmt.Errorf("expected 1 tokenized input, got 0")
+	}
+	return len(resp.Tokens[0]), nil
+}
+
+// tokenizeSegments tokenizes the segments of document without special
+// tokens and returns the tokens with document offsets, along with whether
+// each token begins a sentence
+func (c *Client) tokenizeSegments(ctx context.Context, document string, segments []segment) ([]docToken, []bool, error) {
+	var tokens []docToken
+	var boundaries []bool
+
+	batchSize := max(c.validator.Config().MaxBatchSize, 1)
+	for first := 0; first < len(segments); first += batchSize {
+		batch := segments[first:min(first+batchSize, len(segments))]
+		texts := make([]string, len(batch))
+		for i, seg := range batch {
+			texts[i] = document[seg.start:seg.end]
+		}
+
+		resp, err := c.Tokenize(ctx, &entities.TokenizeRequest{
+			Inputs:           entities.Input{Data: texts},
+			AddSpecialTokens: entities.BoolPtr(false),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to tokenize document: %w", err)
+		}
+
+		for i, segmentTokens := range resp.Tokens {
+			offset := batch[i].start
+			startsSentence := !batch[i].continued
+			for _, token := range segmentTokens {
+				if token.Special || token.Start == nil || token.Stop == nil {
+					continue
+				}
+				tokens = append(tokens, docToken{start: offset + *token.Start, stop: offset + *token.Stop})
+				boundaries = append(boundaries, startsSentence)
+				startsSentence = false
+			}
+		}
+	}
+
+	return tokens, boundaries, nil
+}
+
+// splitSentences returns the byte ranges of the sentences of text, each
+// ending after terminal punctuation followed by whitespace or at a newline.
+// Sentences longer than maxBytes are split at the last whitespace that
+// fits, or at a rune boundary when there is none. Whitespace between
+// sentences is left out.
+func splitSentences(text string, maxBytes int) []segment {
+	var segments []segment
+	add := func(start, end int) {
+		for start < end && unicode.IsSpace(rune(text[start])) {
+			start++
+		}
+		continued := false
+		for maxBytes > 0 && end-start > maxBytes {
+			cut := splitPoint(text[start:start+maxBytes+1], maxBytes)
+			segments = append(segments, segment{start: start, end: start + cut, continued: continued})
+			start += cut
+			continued = true
+			for start < end && unicode.IsSpace(rune(text[start])) {
+				start++
+			}
+		}
+		if start < end {
+			segments = append(segments, segment{start: start, end: end, continued: continued})
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		if r == '\n' {
+			add(start, i)
+			start = i
+			continue
+		}
+		if (r == '.' || r == '!' || r == '?') && i < len(text) {
+			if next, _ := utf8.DecodeRuneInString(text[i:]); unicode.IsSpace(next) {
+				add(start, i)
+				start = i
+			}
+		}
+	}
+	add(start, len(text))
+
+	return segments
+}
+
+// splitPoint picks where to cut s so that the first part holds at most
+// limit bytes, preferring the last whitespace
+func splitPoint(s string, limit int) int {
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	for i := cut; i > 0; i-- {
+		if unicode.IsSpace(rune(s[i])) {
+			return i
+		}
+	}
+	return max(cut, 1)
+}
+
+// chunkTokens groups tokens into spans [start, end) of at most maxTokens
+// tokens and maxBytes bytes. A span ends at the last sentence boundary that
+// fits, if any, and the next one starts overlap tokens earlier, moved
+// forward to a sentence boundary when one lies within the overlap.
+func chunkTokens(tokens []docToken, boundaries []bool, maxTokens, overlap, maxBytes int) [][2]int {
+	var spans [][2]int
+
+	start := 0
+	for start < len(tokens) {
+		end := min(start+maxTokens, len(tokens))
+		for maxBytes > 0 && end-start > 1 && tokens[end-1].stop-tokens[start].start > maxBytes {
+			end--
+		}
+
+		if end < len(tokens) {
+			for b := end; b > start; b-- {
+				if boundaries[b] {
+					end = b
+					break
+				}
+			}
+		}
+		spans = append(spans, [2]int{start, end})
+		if end == len(tokens) {
+			break
+		}
+
+		next := max(end-overlap, start+1)
+		for b := next; b < end; b++ {
+			if boundaries[b] {
+				next = b
+				break
+			}
+		}
+		start = next
+	}
+
+	return spans
+}
@@ -0,0 +1,268 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxBytes int
+		want     []segment
+	}{
+		{
+			name: "empty",
+			text: "",
+		},
+		{
+			name: "terminal punctuation",
+			text: "One. Two! Three?",
+			want: []segment{{start: 0, end: 4}, {start: 5, end: 9}, {start: 10, end: 16}},
+		},
+		{
+			name: "punctuation without whitespace",
+			text: "v1.2 is out",
+			want: []segment{{start: 0, end: 11}},
+		},
+		{
+			name: "newlines",
+			text: "line one\n\n  line two",
+			want: []segment{{start: 0, end: 9}, {start: 12, end: 20}},
+		},
+		{
+			name:     "sentence longer than the limit",
+			text:     "aaaa bbbb cccc",
+			maxBytes: 9,
+			want:     []segment{{start: 0, end: 9}, {start: 10, end: 14, continued: true}},
+		},
+		{
+			name:     "no whitespace to split at",
+			text:     "abcdefgh",
+			maxBytes: 3,
+			want: []segment{
+				{start: 0, end: 3},
+				{start: 3, end: 6, continued: true},
+				{start: 6, end: 8, continued: true},
+			},
+		},
+		{
+			name:     "multi-byte runes kept whole",
+			text:     "ééé",
+			maxBytes: 3,
+			want:     []segment{{start: 0, end: 2}, {start: 2, end: 4, continued: true}, {start: 4, end: 6, continued: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSentences(tt.text, tt.maxBytes); !slices.Equal(got, tt.want) {
+				t.Errorf("splitSentences(%q, %d) = %v, want %v", tt.text, tt.maxBytes, got, tt.want)
+			}
+		})
+	}
+}
+
+// evenTokens returns n one-byte tokens separated by one byte of whitespace,
+// with sentences starting at the given token indices
+func evenTokens(n int, sentenceStarts ...int) ([]docToken, []bool) {
+	tokens := make([]docToken, n)
+	boundaries := make([]bool, n)
+	for i := range tokens {
+		tokens[i] = docToken{start: 2 * i, stop: 2*i + 1}
+	}
+	for _, i := range sentenceStarts {
+		boundaries[i] = true
+	}
+	return tokens, boundaries
+}
+
+func TestChunkTokens(t *testing.T) {
+	tests := []struct {
+		name           string
+		n              int
+		sentenceStarts []int
+		maxTokens      int
+		overlap        int
+		maxBytes       int
+		want           [][2]int
+	}{
+		{
+			name:      "empty",
+			maxTokens: 4,
+		},
+		{
+			name:           "fits in one chunk",
+			n:              5,
+			sentenceStarts: []int{0, 3},
+			maxTokens:      8,
+			want:           [][2]int{{0, 5}},
+		},
+		{
+			name:           "ends at the last sentence boundary",
+			n:              10,
+			sentenceStarts: []int{0, 4, 8},
+			maxTokens:      6,
+			want:           [][2]int{{0, 4}, {4, 10}},
+		},
+		{
+			name:           "overlap",
+			n:              9,
+			sentenceStarts: []int{0, 3, 6},
+			maxTokens:      7,
+			overlap:        2,
+			want:           [][2]int{{0, 6}, {4, 9}},
+		},
+		{
+			name:           "overlap moved to a sentence boundary",
+			n:              9,
+			sentenceStarts: []int{0, 3, 5},
+			maxTokens:      6,
+			overlap:        3,
+			want:           [][2]int{{0, 5}, {3, 9}},
+		},
+		{
+			name:           "single sentence longer than the budget",
+			n:              7,
+			sentenceStarts: []int{0},
+			maxTokens:      3,
+			want:           [][2]int{{0, 3}, {3, 6}, {6, 7}},
+		},
+		{
+			name:           "single sentence longer than the budget with overlap",
+			n:              7,
+			sentenceStarts: []int{0},
+			maxTokens:      3,
+			overlap:        1,
+			want:           [][2]int{{0, 3}, {2, 5}, {4, 7}},
+		},
+		{
+			name:           "overlap never stalls",
+			n:              4,
+			sentenceStarts: []int{0},
+			maxTokens:      2,
+			overlap:        5,
+			want:           [][2]int{{0, 2}, {1, 3}, {2, 4}},
+		},
+		{
+			name:           "byte limit",
+			n:              6,
+			sentenceStarts: []int{0},
+			maxTokens:      10,
+			maxBytes:       5,
+			want:           [][2]int{{0, 3}, {3, 6}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, boundaries := evenTokens(tt.n, tt.sentenceStarts...)
+			got := chunkTokens(tokens, boundaries, tt.maxTokens, tt.overlap, tt.maxBytes)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("chunkTokens = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// wordTokenizerTEI serves /tokenize, splitting inputs at whitespace and
+// surrounding them with two special tokens. The prompt "query" prepends
+// "query: " the way TEI applies a prompt_name.
+func wordTokenizerTEI(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Inputs           json.RawMessage `json:"inputs"`
+			AddSpecialTokens bool            `json:"add_special_tokens"`
+			PromptName       string          `json:"prompt_name"`
+		}
+		var inputs []string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(req.Inputs, &inputs); err != nil {
+			var single string
+			if err := json.Unmarshal(req.Inputs, &single); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			inputs = []string{single}
+		}
+
+		resp := make([][]entities.Token, len(inputs))
+		for i, input := range inputs {
+			if req.PromptName == "query" {
+				input = "query: " + input
+			}
+			if req.AddSpecialTokens {
+				resp[i] = append(resp[i], entities.Token{Text: "[CLS]", Special: true})
+			}
+			for _, word := range strings.Fields(input) {
+				resp[i] = append(resp[i], entities.Token{Text: word})
+			}
+			if req.AddSpecialTokens {
+				resp[i] = append(resp[i], entities.Token{Text: "[SEP]", Special: true})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInputOverheadIncludesDefaultPrompt(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+		want      int
+	}{
+		{
+			name:      "no default prompt",
+			configure: func(*config.Config) {},
+			want:      2,
+		},
+		{
+			name: "prompt applied by TEI",
+			configure: func(cfg *config.Config) {
+				cfg.Embedding.DefaultPromptName = "query"
+			},
+			want: 3,
+		},
+		{
+			name: "prompt expanded locally",
+			configure: func(cfg *config.Config) {
+				cfg.Embedding.DefaultPromptName = "Query"
+				cfg.Embedding.ExpandPrompts = true
+				cfg.Embedding.Prompts = map[string]string{"query": "query: {text}"}
+			},
+			want: 3,
+		},
+	}
+
+	server := wordTokenizerTEI(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newConfiguredClient(t, server.URL, tt.configure)
+
+			got, err := client.inputOverhead(context.Background())
+			if err != nil {
+				t.Fatalf("inputOverhead: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("inputOverhead = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,33 +6,139 @@ import (
 
 	"github.com/blackprince001/embedding-inference/internal/config"
 	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
 	"github.com/blackprince001/embedding-inference/internal/domain/interfaces"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/cache"
 	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
 	"github.com/blackprince001/embedding-inference/internal/services/embedding"
+	"github.com/blackprince001/embedding-inference/internal/services/model"
 	"github.com/blackprince001/embedding-inference/internal/services/similarity"
+	"github.com/blackprince001/embedding-inference/internal/services/tokenizer"
+
+	"go.uber.org/zap"
 )
 
 type Client struct {
 	embeddingService  interfaces.EmbeddingService
 	similarityService interfaces.SimilarityService
+	modelService      *model.Service
+	tokenizerService  *tokenizer.Service
 	httpClient        interfaces.HTTPClient
+	cache             *cache.Cache
+	validator         *entities.Validator
+	rolePrefixes      *entities.RolePrefixRegistry
 
 	config *config.Config
 	logger *logging.Logger
 }
 
-func NewClient(cfg *config.Config, httpClient interfaces.HTTPClient, logger *logging.Logger) *Client {
+// Option customizes a Client built by NewClient
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	cacheKey embedding.CacheKeyFunc
+}
+
+// WithCacheKeyFunc derives embedding cache keys with fn instead of
+// embedding.DefaultCacheKey
+func WithCacheKeyFunc(fn embedding.CacheKeyFunc) Option {
+	return func(o *clientOptions) {
+		o.cacheKey = fn
+	}
+}
+
+func NewClient(cfg *config.Config, httpClient interfaces.HTTPClient, logger *logging.Logger, opts ...Option) *Client {
+	var options clientOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	clientLogger := logger.Named("tei-client")
 
+	var embeddingCache *cache.Cache
+	if cfg.Cache.Enabled {
+		embeddingCache = cache.New(&cfg.Cache)
+	}
+
+	validator := entities.NewValidator(&entities.ValidationConfig{
+		MaxInputLength:    cfg.Validation.MaxInputLength,
+		MaxBatchSize:      cfg.Validation.MaxBatchSize,
+		MaxSentencesCount: cfg.Validation.MaxSentencesCount,
+		AllowEmptyStrings: cfg.Validation.AllowEmptyStrings,
+		FailFast:          cfg.Validation.FailFast,
+		FailFastTexts:     cfg.Validation.FailFastTexts,
+		MaxImageBytes:     cfg.Validation.MaxImageBytes,
+	})
+
+	prefixesByModel := make(map[string]entities.RolePrefixes, len(cfg.Embedding.RolePrefixes))
+	for _, prefixes := range cfg.Embedding.RolePrefixes {
+		prefixesByModel[prefixes.Model] = entities.RolePrefixes{Query: prefixes.Query, Document: prefixes.Document}
+	}
+	rolePrefixes := entities.NewRolePrefixRegistry(prefixesByModel)
+
+	embeddingService := embedding.NewService(httpClient, &cfg.Embedding, embeddingCache, rolePrefixes, validator, clientLogger)
+	embeddingService.SetCacheKeyFunc(options.cacheKey)
+
 	return &Client{
-		embeddingService:  embedding.NewService(httpClient, clientLogger),
-		similarityService: similarity.NewService(httpClient, clientLogger),
+		embeddingService:  embeddingService,
+		similarityService: similarity.NewService(httpClient, embeddingService, &cfg.Similarity, rolePrefixes, validator, clientLogger),
+		modelService:      model.NewService(httpClient, clientLogger),
+		tokenizerService:  tokenizer.NewService(httpClient, validator, clientLogger),
 		httpClient:        httpClient,
+		cache:             embeddingCache,
+		validator:         validator,
+		rolePrefixes:      rolePrefixes,
 		config:            cfg,
 		logger:            logger,
 	}
 }
 
+// Info returns the deployed model's information from TEI's /info
+func (c *Client) Info(ctx context.Context) (*entities.ModelInfo, error) {
+	return c.modelService.Info(ctx)
+}
+
+// ApplyModelLimits fetches /info, tightens validation to the model's
+// reported limits and selects the model's query/document prefixes. It must
+// be called before the client serves requests.
+func (c *Client) ApplyModelLimits(ctx context.Context) (*entities.ModelInfo, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.validator.ApplyModelInfo(info)
+	c.rolePrefixes.UseModel(info.ModelID)
+
+	validationCfg := c.validator.Config()
+	c.logger.Info("Applied model limits to validation",
+		zap.String("model_id", info.ModelID),
+		zap.Int("max_batch_size", validationCfg.MaxBatchSize),
+		zap.Int("max_input_tokens", validationCfg.MaxInputTokens),
+		zap.Int("max_batch_tokens", validationCfg.MaxBatchTokens),
+	)
+
+	return info, nil
+}
+
+// CacheStats returns the embedding cache counters, or nil when the cache is
+// disabled
+func (c *Client) CacheStats() *cache.Stats {
+	if c.cache == nil {
+		return nil
+	}
+	stats := c.cache.Stats()
+	return &stats
+}
+
+// Close stops background work owned by the client, such as the cache sweeper
+func (c *Client) Close() error {
+	if c.cache != nil {
+		c.cache.Close()
+	}
+	return nil
+}
+
 func (c *Client) Embed(ctx context.Context, req *entities.EmbedRequest) (*entities.EmbedResponse, error) {
 	return c.embeddingService.Embed(ctx, req)
 }
@@ -41,14 +147,70 @@ func (c *Client) EmbedAll(ctx context.Context, req *entities.EmbedAllRequest) (*
 	return c.embeddingService.EmbedAll(ctx, req)
 }
 
+// EmbedImage embeds images with a multimodal model such as CLIP
+func (c *Client) EmbedImage(ctx context.Context, req *entities.EmbedImageRequest) (*entities.EmbedResponse, error) {
+	return c.embeddingService.EmbedImage(ctx, req)
+}
+
+// EmbedTokens embeds inputs given as token ids, e.g. from Tokenize
+func (c *Client) EmbedTokens(ctx context.Context, req *entities.EmbedTokensRequest) (*entities.EmbedResponse, error) {
+	return c.embeddingService.EmbedTokens(ctx, req)
+}
+
+// EmbedStream embeds inputs read from recv in batches, sending each batch's
+// results keyed by input ID. See embedding.Service.EmbedStream.
+func (c *Client) EmbedStream(ctx context.Context, req *entities.EmbedRequest, recv func() (*entities.StreamInput, error), send func([]entities.StreamResult) error) error {
+	return c.embeddingService.EmbedStream(ctx, req, recv, send)
+}
+
 func (c *Client) EmbedSparse(ctx context.Context, req *entities.EmbedSparseRequest) (*entities.EmbedSparseResponse, error) {
 	return c.embeddingService.EmbedSparse(ctx, req)
 }
 
+// EmbedHybrid returns dense and sparse embeddings of the same inputs,
+// aligned by input index
+func (c *Client) EmbedHybrid(ctx context.Context, req *entities.EmbedHybridRequest) (*entities.EmbedHybridResponse, error) {
+	return c.embeddingService.EmbedHybrid(ctx, req)
+}
+
 func (c *Client) CalculateSimilarity(ctx context.Context, req *entities.SimilarityRequest) (*entities.SimilarityResponse, error) {
 	return c.similarityService.CalculateSimilarity(ctx, req)
 }
 
+// ValidateEmbed checks an embed request without calling TEI, returning
+// every violation or nil
+func (c *Client) ValidateEmbed(req *entities.EmbedRequest) *errors.MultiValidationError {
+	return c.embeddingService.ValidateEmbed(req)
+}
+
+// StreamSimilarity scores a large candidate set in chunks, calling send
+// with the topK matches so far after each chunk
+func (c *Client) StreamSimilarity(ctx context.Context, req *entities.SimilarityRequest, topK, chunkSize int, send func(*entities.SimilarityProgress) error) error {
+	return c.similarityService.StreamSimilarity(ctx, req, topK, chunkSize, send)
+}
+
+// NearestNeighbors embeds query and returns the topK vectors of a
+// precomputed corpus with the highest dot product, best first. Corpus
+// vectors must be normalized for the scores to be cosine similarities.
+func (c *Client) NearestNeighbors(ctx context.Context, query string, corpus [][]float32, topK int) ([]entities.Neighbor, error) {
+	return c.similarityService.NearestNeighbors(ctx, query, corpus, topK)
+}
+
+// ValidateSimilarity checks a similarity request without calling TEI,
+// returning every violation or nil
+func (c *Client) ValidateSimilarity(req *entities.SimilarityRequest) *errors.MultiValidationError {
+	return c.similarityService.ValidateSimilarity(req)
+}
+
+func (c *Client) Tokenize(ctx context.Context, req *entities.TokenizeRequest) (*entities.TokenizeResponse, error) {
+	return c.tokenizerService.Tokenize(ctx, req)
+}
+
+// CountTokens returns per-input and total token counts without embedding
+func (c *Client) CountTokens(ctx context.Context, req *entities.TokenizeRequest) (*entities.TokenCountResponse, error) {
+	return c.tokenizerService.CountTokens(ctx, req)
+}
+
 func (c *Client) EmbedTexts(ctx context.Context, texts []string, normalize bool) (*entities.EmbedResponse, error) {
 	req := &entities.EmbedRequest{
 		Inputs:    entities.Input{Data: texts},
@@ -57,6 +219,35 @@ func (c *Client) EmbedTexts(ctx context.Context, texts []string, normalize bool)
 	return c.Embed(ctx, req)
 }
 
+// EmbedTextsMap embeds texts and returns each distinct text paired with its
+// vector, so callers never depend on response ordering. Duplicate texts are
+// sent to TEI only once.
+func (c *Client) EmbedTextsMap(ctx context.Context, texts []string, normalize bool) (map[string][]float32, error) {
+	unique := make([]string, 0, len(texts))
+	seen := make(map[string]struct{}, len(texts))
+	for _, text := range texts {
+		if _, ok := seen[text]; ok {
+			continue
+		}
+		seen[text] = struct{}{}
+		unique = append(unique, text)
+	}
+
+	resp, err := c.EmbedTexts(ctx, unique, normalize)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(unique) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(unique), len(resp.Embeddings))
+	}
+
+	embeddings := make(map[string][]float32, len(unique))
+	for i, text := range unique {
+		embeddings[text] = resp.Embeddings[i]
+	}
+	return embeddings, nil
+}
+
 func (c *Client) EmbedText(ctx context.Context, text string, normalize bool) ([]float32, error) {
 	resp, err := c.EmbedTexts(ctx, []string{text}, normalize)
 	if err != nil {
@@ -68,6 +259,13 @@ func (c *Client) EmbedText(ctx context.Context, text string, normalize bool) ([]
 	return resp.Embeddings[0], nil
 }
 
+// EmbedTextPlain embeds one text using a text/plain request body, which
+// avoids JSON encoding on the single-query hot path. The vector is always
+// normalized.
+func (c *Client) EmbedTextPlain(ctx context.Context, text string) ([]float32, error) {
+	return c.embeddingService.EmbedPlainText(ctx, text)
+}
+
 func (c *Client) CalculateTextSimilarity(ctx context.Context, source string, targets []string) ([]float32, error) {
 	req := &entities.SimilarityRequest{
 		Inputs: entities.SimilarityInput{
@@ -81,3 +279,10 @@ func (c *Client) CalculateTextSimilarity(ctx context.Context, source string, tar
 	}
 	return resp.Similarities, nil
 }
+
+// Drift reports the cosine distance between two embeddings of the same
+// inputs, such as a corpus embedded before and after a model upgrade, and
+// which inputs moved further than threshold
+func Drift(before, after [][]float32, threshold float32) (*similarity.DriftReport, error) {
+	return similarity.Drift(before, after, threshold)
+}
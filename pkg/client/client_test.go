@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/blackprince001/embedding-inference/internal/config"
+	"github.com/blackprince001/embedding-inference/internal/domain/entities"
+	"github.com/blackprince001/embedding-inference/internal/domain/errors"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/logging"
+	"github.com/blackprince001/embedding-inference/internal/infrastructure/wrapper"
+
+	"go.uber.org/zap"
+)
+
+// numberTEI serves /embed, answering the input "n" with the vector [n, 0]
+func numberTEI(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Inputs json.RawMessage `json:"inputs"`
+		}
+		var inputs []string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(req.Inputs, &inputs); err != nil {
+			var single string
+			if err := json.Unmarshal(req.Inputs, &single); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			inputs = []string{single}
+		}
+
+		embeddings := make([][]float32, len(inputs))
+		for i, input := range inputs {
+			n, err := strconv.Atoi(input)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			embeddings[i] = []float32{float32(n), 0}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(embeddings)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestClient builds a Client against tei with the default configuration
+// and the embedding cache enabled
+func newTestClient(t testing.TB, teiURL string) *Client {
+	t.Helper()
+
+	return newConfiguredClient(t, teiURL, func(cfg *config.Config) {
+		cfg.Cache.Enabled = true
+		cfg.Cache.MaxEntries = 64
+	})
+}
+
+// newConfiguredClient builds a Client against tei with the default
+// configuration adjusted by configure
+func newConfiguredClient(t testing.TB, teiURL string, configure func(*config.Config)) *Client {
+	t.Helper()
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.TEI.BaseURL = teiURL
+	cfg.TEI.BaseURLs = nil
+	configure(cfg)
+
+	logger := &logging.Logger{Logger: zap.NewNop()}
+	httpClient, err := wrapper.NewHTTPClient(&cfg.TEI, &cfg.Client, logger)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	return NewClient(cfg, httpClient, logger)
+}
+
+func TestConcurrentEmbedSharesClientAndCache(t *testing.T) {
+	client := newTestClient(t, numberTEI(t).URL)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				// Overlapping inputs across workers exercise cache hits,
+				// misses and evictions at once
+				inputs := []string{strconv.Itoa(i), strconv.Itoa(worker*20 + i)}
+				resp, err := client.Embed(context.Background(), &entities.EmbedRequest{
+					Inputs:    entities.Input{Data: inputs},
+					Normalize: entities.BoolPtr(false),
+				})
+				if err != nil {
+					t.Errorf("Embed: %v", err)
+					return
+				}
+				for j, input := range inputs {
+					if got := fmt.Sprint(resp.Embeddings[j][0]); got != input {
+						t.Errorf("embedding of %q = %v", input, resp.Embeddings[j])
+						return
+					}
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	// Which lookups hit depends on scheduling, but every one is counted
+	stats := client.CacheStats()
+	if stats == nil || stats.Hits+stats.Misses != 16*20*2 {
+		t.Errorf("cache stats = %+v, want %d lookups counted", stats, 16*20*2)
+	} else if stats.Evictions == 0 {
+		t.Errorf("cache stats = %+v, want evictions past 64 entries", stats)
+	}
+}
+
+func TestSpecialTokenCountWithoutTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+	client := newTestClient(t, server.URL)
+
+	if _, err := client.specialTokenCount(context.Background()); err == nil {
+		t.Error("specialTokenCount succeeded on an empty tokenize response")
+	}
+}
+
+// BenchmarkEmbedSingle compares the text/plain hot path with the JSON path
+// for one uncached text against a TEI that decodes the body it is sent
+func BenchmarkEmbedSingle(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != entities.ContentTypeTextPlain {
+			var req entities.EmbedRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[[0.6,0.8]]`))
+	}))
+	b.Cleanup(server.Close)
+
+	client := newConfiguredClient(b, server.URL, func(cfg *config.Config) {
+		cfg.Cache.Enabled = false
+	})
+	text := "what is the capital of France?"
+
+	b.Run("text_plain", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.EmbedTextPlain(context.Background(), text); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.EmbedText(context.Background(), text, true); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestFakeModeServesTokenAndSparseEmbeddings(t *testing.T) {
+	client := newConfiguredClient(t, "http://unused", func(cfg *config.Config) {
+		cfg.TEI.Mode = wrapper.ModeFake
+		cfg.TEI.FakeDimension = 8
+	})
+	ctx := context.Background()
+	inputs := entities.Input{Data: []string{"red fish blue fish", "red"}}
+
+	all, err := client.EmbedAll(ctx, &entities.EmbedAllRequest{Inputs: inputs})
+	if err != nil {
+		t.Fatalf("EmbedAll: %v", err)
+	}
+	if len(all.Embeddings) != 2 || len(all.Embeddings[0]) != 4 || len(all.Embeddings[1]) != 1 {
+		t.Fatalf("EmbedAll returned %d inputs, want 2 with one vector per word", len(all.Embeddings))
+	}
+	if len(all.Embeddings[0][0]) != 8 {
+		t.Errorf("token vector dimension = %d, want 8", len(all.Embeddings[0][0]))
+	}
+	// The same word embeds to the same vector wherever it appears
+	if fmt.Sprint(all.Embeddings[0][0]) != fmt.Sprint(all.Embeddings[1][0]) {
+		t.Errorf("vectors of %q differ across inputs", "red")
+	}
+
+	sparse, err := client.EmbedSparse(ctx, &entities.EmbedSparseRequest{Inputs: inputs})
+	if err != nil {
+		t.Fatalf("EmbedSparse: %v", err)
+	}
+	if got := len(sparse.Embeddings[0]); got != 3 {
+		t.Fatalf("sparse entries = %d, want one per distinct word", got)
+	}
+	var total float32
+	for i, value := range sparse.Embeddings[0] {
+		if i > 0 && value.Index <= sparse.Embeddings[0][i-1].Index {
+			t.Errorf("sparse indices %v are not ascending", sparse.Embeddings[0])
+		}
+		total += value.Value
+	}
+	if total != 4 {
+		t.Errorf("sparse weights sum to %v, want the 4 words", total)
+	}
+
+	hybrid, err := client.EmbedHybrid(ctx, &entities.EmbedHybridRequest{Inputs: inputs})
+	if err != nil {
+		t.Fatalf("EmbedHybrid: %v", err)
+	}
+	if len(hybrid.Dense) != 2 || len(hybrid.Sparse) != 2 {
+		t.Errorf("hybrid returned %d dense and %d sparse vectors, want 2 of each", len(hybrid.Dense), len(hybrid.Sparse))
+	}
+}
+
+func TestNearestNeighbors(t *testing.T) {
+	// numberTEI embeds the query "2" as [2 0]
+	corpus := [][]float32{{0, 1}, {1, 0}, {-1, 0}, {0.5, 0.5}}
+
+	tests := []struct {
+		name   string
+		corpus [][]float32
+		topK   int
+		want   []entities.Neighbor
+		field  string
+	}{
+		{
+			name:   "best first",
+			corpus: corpus,
+			topK:   2,
+			want:   []entities.Neighbor{{Index: 1, Score: 2}, {Index: 3, Score: 1}},
+		},
+		{
+			name:   "topK larger than the corpus",
+			corpus: corpus,
+			topK:   10,
+			want: []entities.Neighbor{
+				{Index: 1, Score: 2},
+				{Index: 3, Score: 1},
+				{Index: 0, Score: 0},
+				{Index: 2, Score: -2},
+			},
+		},
+		{
+			name:   "empty corpus",
+			corpus: nil,
+			topK:   2,
+			field:  "corpus",
+		},
+		{
+			name:   "dimension mismatch",
+			corpus: [][]float32{{1, 0}, {1, 0, 0}},
+			topK:   2,
+			field:  "corpus[1]",
+		},
+		{
+			name:   "non-positive topK",
+			corpus: corpus,
+			topK:   0,
+			field:  "topK",
+		},
+	}
+
+	client := newTestClient(t, numberTEI(t).URL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.NearestNeighbors(context.Background(), "2", tt.corpus, tt.topK)
+			if tt.field != "" {
+				var validationErr *errors.ValidationError
+				if !stderrors.As(err, &validationErr) || validationErr.Field != tt.field {
+					t.Fatalf("err = %v, want a validation error on %s", err, tt.field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NearestNeighbors: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("neighbors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
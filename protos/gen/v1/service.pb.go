@@ -76,6 +76,7 @@ const (
 	EncodingFormat_ENCODING_FORMAT_UNSPECIFIED EncodingFormat = 0
 	EncodingFormat_ENCODING_FORMAT_FLOAT       EncodingFormat = 1
 	EncodingFormat_ENCODING_FORMAT_BASE64      EncodingFormat = 2
+	EncodingFormat_ENCODING_FORMAT_INT8        EncodingFormat = 3
 )
 
 // Enum value maps for EncodingFormat.
@@ -84,11 +85,13 @@ var (
 		0: "ENCODING_FORMAT_UNSPECIFIED",
 		1: "ENCODING_FORMAT_FLOAT",
 		2: "ENCODING_FORMAT_BASE64",
+		3: "ENCODING_FORMAT_INT8",
 	}
 	EncodingFormat_value = map[string]int32{
 		"ENCODING_FORMAT_UNSPECIFIED": 0,
 		"ENCODING_FORMAT_FLOAT":       1,
 		"ENCODING_FORMAT_BASE64":      2,
+		"ENCODING_FORMAT_INT8":        3,
 	}
 )
 
@@ -119,6 +122,107 @@ func (EncodingFormat) EnumDescriptor() ([]byte, []int) {
 	return file_v1_service_proto_rawDescGZIP(), []int{1}
 }
 
+type SimilarityMetric int32
+
+const (
+	SimilarityMetric_SIMILARITY_METRIC_UNSPECIFIED SimilarityMetric = 0
+	SimilarityMetric_SIMILARITY_METRIC_COSINE      SimilarityMetric = 1
+	SimilarityMetric_SIMILARITY_METRIC_DOT         SimilarityMetric = 2
+	SimilarityMetric_SIMILARITY_METRIC_EUCLIDEAN   SimilarityMetric = 3
+)
+
+// Enum value maps for SimilarityMetric.
+var (
+	SimilarityMetric_name = map[int32]string{
+		0: "SIMILARITY_METRIC_UNSPECIFIED",
+		1: "SIMILARITY_METRIC_COSINE",
+		2: "SIMILARITY_METRIC_DOT",
+		3: "SIMILARITY_METRIC_EUCLIDEAN",
+	}
+	SimilarityMetric_value = map[string]int32{
+		"SIMILARITY_METRIC_UNSPECIFIED": 0,
+		"SIMILARITY_METRIC_COSINE":      1,
+		"SIMILARITY_METRIC_DOT":         2,
+		"SIMILARITY_METRIC_EUCLIDEAN":   3,
+	}
+)
+
+func (x SimilarityMetric) Enum() *SimilarityMetric {
+	p := new(SimilarityMetric)
+	*p = x
+	return p
+}
+
+func (x SimilarityMetric) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SimilarityMetric) Descriptor() protoreflect.EnumDescriptor {
+	return file_v1_service_proto_enumTypes[2].Descriptor()
+}
+
+func (SimilarityMetric) Type() protoreflect.EnumType {
+	return &file_v1_service_proto_enumTypes[2]
+}
+
+func (x SimilarityMetric) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SimilarityMetric.Descriptor instead.
+func (SimilarityMetric) EnumDescriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{2}
+}
+
+type InputRole int32
+
+const (
+	InputRole_INPUT_ROLE_UNSPECIFIED InputRole = 0
+	InputRole_INPUT_ROLE_QUERY       InputRole = 1
+	InputRole_INPUT_ROLE_DOCUMENT    InputRole = 2
+)
+
+// Enum value maps for InputRole.
+var (
+	InputRole_name = map[int32]string{
+		0: "INPUT_ROLE_UNSPECIFIED",
+		1: "INPUT_ROLE_QUERY",
+		2: "INPUT_ROLE_DOCUMENT",
+	}
+	InputRole_value = map[string]int32{
+		"INPUT_ROLE_UNSPECIFIED": 0,
+		"INPUT_ROLE_QUERY":       1,
+		"INPUT_ROLE_DOCUMENT":    2,
+	}
+)
+
+func (x InputRole) Enum() *InputRole {
+	p := new(InputRole)
+	*p = x
+	return p
+}
+
+func (x InputRole) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (InputRole) Descriptor() protoreflect.EnumDescriptor {
+	return file_v1_service_proto_enumTypes[3].Descriptor()
+}
+
+func (InputRole) Type() protoreflect.EnumType {
+	return &file_v1_service_proto_enumTypes[3]
+}
+
+func (x InputRole) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use InputRole.Descriptor instead.
+func (InputRole) EnumDescriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{3}
+}
+
 type EmbedRequest struct {
 	state               protoimpl.MessageState `protogen:"open.v1"`
 	Inputs              []string               `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
@@ -126,6 +230,14 @@ type EmbedRequest struct {
 	PromptName          *string                `protobuf:"bytes,3,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
 	Truncate            *bool                  `protobuf:"varint,4,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
 	TruncationDirection *TruncationDirection   `protobuf:"varint,5,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
+	AutoBatch           *bool                  `protobuf:"varint,6,opt,name=auto_batch,json=autoBatch,proto3,oneof" json:"auto_batch,omitempty"`
+	EchoRequest         *bool                  `protobuf:"varint,7,opt,name=echo_request,json=echoRequest,proto3,oneof" json:"echo_request,omitempty"`
+	AllowDegraded       *bool                  `protobuf:"varint,8,opt,name=allow_degraded,json=allowDegraded,proto3,oneof" json:"allow_degraded,omitempty"`
+	Dimensions          *uint32                `protobuf:"varint,9,opt,name=dimensions,proto3,oneof" json:"dimensions,omitempty"`
+	InputRole           *InputRole             `protobuf:"varint,10,opt,name=input_role,json=inputRole,proto3,enum=textembedding.InputRole,oneof" json:"input_role,omitempty"`
+	EncodingFormat      *EncodingFormat        `protobuf:"varint,11,opt,name=encoding_format,json=encodingFormat,proto3,enum=textembedding.EncodingFormat,oneof" json:"encoding_format,omitempty"`
+	Deduplicate         *bool                  `protobuf:"varint,12,opt,name=deduplicate,proto3,oneof" json:"deduplicate,omitempty"`
+	PartialResults      *bool                  `protobuf:"varint,13,opt,name=partial_results,json=partialResults,proto3,oneof" json:"partial_results,omitempty"`
 	unknownFields       protoimpl.UnknownFields
 	sizeCache           protoimpl.SizeCache
 }
@@ -195,27 +307,88 @@ func (x *EmbedRequest) GetTruncationDirection() TruncationDirection {
 	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
 }
 
-type EmbedResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Embeddings    []*Embedding           `protobuf:"bytes,1,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *EmbedRequest) GetAutoBatch() bool {
+	if x != nil && x.AutoBatch != nil {
+		return *x.AutoBatch
+	}
+	return false
 }
 
-func (x *EmbedResponse) Reset() {
-	*x = EmbedResponse{}
+func (x *EmbedRequest) GetEchoRequest() bool {
+	if x != nil && x.EchoRequest != nil {
+		return *x.EchoRequest
+	}
+	return false
+}
+
+func (x *EmbedRequest) GetAllowDegraded() bool {
+	if x != nil && x.AllowDegraded != nil {
+		return *x.AllowDegraded
+	}
+	return false
+}
+
+func (x *EmbedRequest) GetDimensions() uint32 {
+	if x != nil && x.Dimensions != nil {
+		return *x.Dimensions
+	}
+	return 0
+}
+
+func (x *EmbedRequest) GetInputRole() InputRole {
+	if x != nil && x.InputRole != nil {
+		return *x.InputRole
+	}
+	return InputRole_INPUT_ROLE_UNSPECIFIED
+}
+
+func (x *EmbedRequest) GetEncodingFormat() EncodingFormat {
+	if x != nil && x.EncodingFormat != nil {
+		return *x.EncodingFormat
+	}
+	return EncodingFormat_ENCODING_FORMAT_UNSPECIFIED
+}
+
+func (x *EmbedRequest) GetDeduplicate() bool {
+	if x != nil && x.Deduplicate != nil {
+		return *x.Deduplicate
+	}
+	return false
+}
+
+func (x *EmbedRequest) GetPartialResults() bool {
+	if x != nil && x.PartialResults != nil {
+		return *x.PartialResults
+	}
+	return false
+}
+
+// EmbedTokensRequest embeds inputs given as the model's token ids in place
+// of text
+type EmbedTokensRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Inputs              []*TokenIDs            `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	Normalize           *bool                  `protobuf:"varint,2,opt,name=normalize,proto3,oneof" json:"normalize,omitempty"`
+	Truncate            *bool                  `protobuf:"varint,3,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
+	TruncationDirection *TruncationDirection   `protobuf:"varint,4,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *EmbedTokensRequest) Reset() {
+	*x = EmbedTokensRequest{}
 	mi := &file_v1_service_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EmbedResponse) String() string {
+func (x *EmbedTokensRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EmbedResponse) ProtoMessage() {}
+func (*EmbedTokensRequest) ProtoMessage() {}
 
-func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
+func (x *EmbedTokensRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_v1_service_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -227,39 +400,60 @@ func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EmbedResponse.ProtoReflect.Descriptor instead.
-func (*EmbedResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use EmbedTokensRequest.ProtoReflect.Descriptor instead.
+func (*EmbedTokensRequest) Descriptor() ([]byte, []int) {
 	return file_v1_service_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *EmbedResponse) GetEmbeddings() []*Embedding {
+func (x *EmbedTokensRequest) GetInputs() []*TokenIDs {
 	if x != nil {
-		return x.Embeddings
+		return x.Inputs
 	}
 	return nil
 }
 
-type Embedding struct {
+func (x *EmbedTokensRequest) GetNormalize() bool {
+	if x != nil && x.Normalize != nil {
+		return *x.Normalize
+	}
+	return false
+}
+
+func (x *EmbedTokensRequest) GetTruncate() bool {
+	if x != nil && x.Truncate != nil {
+		return *x.Truncate
+	}
+	return false
+}
+
+func (x *EmbedTokensRequest) GetTruncationDirection() TruncationDirection {
+	if x != nil && x.TruncationDirection != nil {
+		return *x.TruncationDirection
+	}
+	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
+}
+
+type TokenIDs struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	Ids           []uint32               `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Embedding) Reset() {
-	*x = Embedding{}
+func (x *TokenIDs) Reset() {
+	*x = TokenIDs{}
 	mi := &file_v1_service_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Embedding) String() string {
+func (x *TokenIDs) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Embedding) ProtoMessage() {}
+func (*TokenIDs) ProtoMessage() {}
 
-func (x *Embedding) ProtoReflect() protoreflect.Message {
+func (x *TokenIDs) ProtoReflect() protoreflect.Message {
 	mi := &file_v1_service_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -271,42 +465,42 @@ func (x *Embedding) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Embedding.ProtoReflect.Descriptor instead.
-func (*Embedding) Descriptor() ([]byte, []int) {
+// Deprecated: Use TokenIDs.ProtoReflect.Descriptor instead.
+func (*TokenIDs) Descriptor() ([]byte, []int) {
 	return file_v1_service_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *Embedding) GetValues() []float32 {
+func (x *TokenIDs) GetIds() []uint32 {
 	if x != nil {
-		return x.Values
+		return x.Ids
 	}
 	return nil
 }
 
-type EmbedAllRequest struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	Inputs              []string               `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
-	PromptName          *string                `protobuf:"bytes,2,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
-	Truncate            *bool                  `protobuf:"varint,3,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
-	TruncationDirection *TruncationDirection   `protobuf:"varint,4,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+// EmbedImageRequest embeds images with a multimodal model such as CLIP,
+// returning one embedding per image
+type EmbedImageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Images        []*ImageInput          `protobuf:"bytes,1,rep,name=images,proto3" json:"images,omitempty"`
+	Normalize     *bool                  `protobuf:"varint,2,opt,name=normalize,proto3,oneof" json:"normalize,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EmbedAllRequest) Reset() {
-	*x = EmbedAllRequest{}
+func (x *EmbedImageRequest) Reset() {
+	*x = EmbedImageRequest{}
 	mi := &file_v1_service_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EmbedAllRequest) String() string {
+func (x *EmbedImageRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EmbedAllRequest) ProtoMessage() {}
+func (*EmbedImageRequest) ProtoMessage() {}
 
-func (x *EmbedAllRequest) ProtoReflect() protoreflect.Message {
+func (x *EmbedImageRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_v1_service_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -318,60 +512,54 @@ func (x *EmbedAllRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EmbedAllRequest.ProtoReflect.Descriptor instead.
-func (*EmbedAllRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use EmbedImageRequest.ProtoReflect.Descriptor instead.
+func (*EmbedImageRequest) Descriptor() ([]byte, []int) {
 	return file_v1_service_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *EmbedAllRequest) GetInputs() []string {
+func (x *EmbedImageRequest) GetImages() []*ImageInput {
 	if x != nil {
-		return x.Inputs
+		return x.Images
 	}
 	return nil
 }
 
-func (x *EmbedAllRequest) GetPromptName() string {
-	if x != nil && x.PromptName != nil {
-		return *x.PromptName
-	}
-	return ""
-}
-
-func (x *EmbedAllRequest) GetTruncate() bool {
-	if x != nil && x.Truncate != nil {
-		return *x.Truncate
+func (x *EmbedImageRequest) GetNormalize() bool {
+	if x != nil && x.Normalize != nil {
+		return *x.Normalize
 	}
 	return false
 }
 
-func (x *EmbedAllRequest) GetTruncationDirection() TruncationDirection {
-	if x != nil && x.TruncationDirection != nil {
-		return *x.TruncationDirection
-	}
-	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
-}
-
-type EmbedAllResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	TokenEmbeddings []*TokenEmbeddings     `protobuf:"bytes,1,rep,name=token_embeddings,json=tokenEmbeddings,proto3" json:"token_embeddings,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+// ImageInput is an http(s) URL TEI fetches, or the encoded image bytes.
+// content_type is detected from data when unset; PNG, JPEG, WebP and GIF
+// are supported.
+type ImageInput struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Source:
+	//
+	//	*ImageInput_Url
+	//	*ImageInput_Data
+	Source        isImageInput_Source `protobuf_oneof:"source"`
+	ContentType   *string             `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3,oneof" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EmbedAllResponse) Reset() {
-	*x = EmbedAllResponse{}
+func (x *ImageInput) Reset() {
+	*x = ImageInput{}
 	mi := &file_v1_service_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EmbedAllResponse) String() string {
+func (x *ImageInput) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EmbedAllResponse) ProtoMessage() {}
+func (*ImageInput) ProtoMessage() {}
 
-func (x *EmbedAllResponse) ProtoReflect() protoreflect.Message {
+func (x *ImageInput) ProtoReflect() protoreflect.Message {
 	mi := &file_v1_service_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -383,39 +571,85 @@ func (x *EmbedAllResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EmbedAllResponse.ProtoReflect.Descriptor instead.
-func (*EmbedAllResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ImageInput.ProtoReflect.Descriptor instead.
+func (*ImageInput) Descriptor() ([]byte, []int) {
 	return file_v1_service_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *EmbedAllResponse) GetTokenEmbeddings() []*TokenEmbeddings {
+func (x *ImageInput) GetSource() isImageInput_Source {
 	if x != nil {
-		return x.TokenEmbeddings
+		return x.Source
 	}
 	return nil
 }
 
-type TokenEmbeddings struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Embeddings    []*Embedding           `protobuf:"bytes,1,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ImageInput) GetUrl() string {
+	if x != nil {
+		if x, ok := x.Source.(*ImageInput_Url); ok {
+			return x.Url
+		}
+	}
+	return ""
 }
 
-func (x *TokenEmbeddings) Reset() {
-	*x = TokenEmbeddings{}
+func (x *ImageInput) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Source.(*ImageInput_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *ImageInput) GetContentType() string {
+	if x != nil && x.ContentType != nil {
+		return *x.ContentType
+	}
+	return ""
+}
+
+type isImageInput_Source interface {
+	isImageInput_Source()
+}
+
+type ImageInput_Url struct {
+	Url string `protobuf:"bytes,1,opt,name=url,proto3,oneof"`
+}
+
+type ImageInput_Data struct {
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3,oneof"`
+}
+
+func (*ImageInput_Url) isImageInput_Source() {}
+
+func (*ImageInput_Data) isImageInput_Source() {}
+
+type EmbedResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Embeddings          []*Embedding           `protobuf:"bytes,1,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
+	Echo                *RequestEcho           `protobuf:"bytes,2,opt,name=echo,proto3,oneof" json:"echo,omitempty"`
+	Degraded            bool                   `protobuf:"varint,3,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	QuantizedEmbeddings []*QuantizedEmbedding  `protobuf:"bytes,4,rep,name=quantized_embeddings,json=quantizedEmbeddings,proto3" json:"quantized_embeddings,omitempty"`
+	Truncated           []bool                 `protobuf:"varint,5,rep,packed,name=truncated,proto3" json:"truncated,omitempty"`
+	Errors              []*InputError          `protobuf:"bytes,6,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *EmbedResponse) Reset() {
+	*x = EmbedResponse{}
 	mi := &file_v1_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TokenEmbeddings) String() string {
+func (x *EmbedResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TokenEmbeddings) ProtoMessage() {}
+func (*EmbedResponse) ProtoMessage() {}
 
-func (x *TokenEmbeddings) ProtoReflect() protoreflect.Message {
+func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_v1_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -427,43 +661,1386 @@ func (x *TokenEmbeddings) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TokenEmbeddings.ProtoReflect.Descriptor instead.
-func (*TokenEmbeddings) Descriptor() ([]byte, []int) {
+// Deprecated: Use EmbedResponse.ProtoReflect.Descriptor instead.
+func (*EmbedResponse) Descriptor() ([]byte, []int) {
 	return file_v1_service_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *TokenEmbeddings) GetEmbeddings() []*Embedding {
+func (x *EmbedResponse) GetEmbeddings() []*Embedding {
 	if x != nil {
 		return x.Embeddings
 	}
 	return nil
 }
 
-type EmbedSparseRequest struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	Inputs              []string               `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
-	PromptName          *string                `protobuf:"bytes,2,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
-	Truncate            *bool                  `protobuf:"varint,3,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
-	TruncationDirection *TruncationDirection   `protobuf:"varint,4,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+func (x *EmbedResponse) GetEcho() *RequestEcho {
+	if x != nil {
+		return x.Echo
+	}
+	return nil
+}
+
+func (x *EmbedResponse) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+func (x *EmbedResponse) GetQuantizedEmbeddings() []*QuantizedEmbedding {
+	if x != nil {
+		return x.QuantizedEmbeddings
+	}
+	return nil
+}
+
+func (x *EmbedResponse) GetTruncated() []bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return nil
+}
+
+func (x *EmbedResponse) GetErrors() []*InputError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+// InputError reports a failed input of a partial embed response; code is a
+// google.rpc.Code
+type InputError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Code          int32                  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InputError) Reset() {
+	*x = InputError{}
+	mi := &file_v1_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InputError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InputError) ProtoMessage() {}
+
+func (x *InputError) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InputError.ProtoReflect.Descriptor instead.
+func (*InputError) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *InputError) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *InputError) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *InputError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type RequestEcho struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	InputCount    uint32                 `protobuf:"varint,2,opt,name=input_count,json=inputCount,proto3" json:"input_count,omitempty"`
+	InputHash     string                 `protobuf:"bytes,3,opt,name=input_hash,json=inputHash,proto3" json:"input_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestEcho) Reset() {
+	*x = RequestEcho{}
+	mi := &file_v1_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestEcho) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestEcho) ProtoMessage() {}
+
+func (x *RequestEcho) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestEcho.ProtoReflect.Descriptor instead.
+func (*RequestEcho) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RequestEcho) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RequestEcho) GetInputCount() uint32 {
+	if x != nil {
+		return x.InputCount
+	}
+	return 0
+}
+
+func (x *RequestEcho) GetInputHash() string {
+	if x != nil {
+		return x.InputHash
+	}
+	return ""
+}
+
+type Embedding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Embedding) Reset() {
+	*x = Embedding{}
+	mi := &file_v1_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Embedding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Embedding) ProtoMessage() {}
+
+func (x *Embedding) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Embedding.ProtoReflect.Descriptor instead.
+func (*Embedding) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Embedding) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// QuantizedEmbedding holds int8 values as two's-complement bytes; each
+// value is approximately byte * scale
+type QuantizedEmbedding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []byte                 `protobuf:"bytes,1,opt,name=values,proto3" json:"values,omitempty"`
+	Scale         float32                `protobuf:"fixed32,2,opt,name=scale,proto3" json:"scale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuantizedEmbedding) Reset() {
+	*x = QuantizedEmbedding{}
+	mi := &file_v1_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuantizedEmbedding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuantizedEmbedding) ProtoMessage() {}
+
+func (x *QuantizedEmbedding) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuantizedEmbedding.ProtoReflect.Descriptor instead.
+func (*QuantizedEmbedding) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *QuantizedEmbedding) GetValues() []byte {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *QuantizedEmbedding) GetScale() float32 {
+	if x != nil {
+		return x.Scale
+	}
+	return 0
+}
+
+type EmbedAllRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Inputs              []string               `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	PromptName          *string                `protobuf:"bytes,2,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
+	Truncate            *bool                  `protobuf:"varint,3,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
+	TruncationDirection *TruncationDirection   `protobuf:"varint,4,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
+	AutoBatch           *bool                  `protobuf:"varint,5,opt,name=auto_batch,json=autoBatch,proto3,oneof" json:"auto_batch,omitempty"`
+	// Keeps only the first max_tokens_per_input token embeddings of each
+	// input. Long inputs otherwise easily exceed the message size limit.
+	MaxTokensPerInput *uint32 `protobuf:"varint,6,opt,name=max_tokens_per_input,json=maxTokensPerInput,proto3,oneof" json:"max_tokens_per_input,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *EmbedAllRequest) Reset() {
+	*x = EmbedAllRequest{}
+	mi := &file_v1_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedAllRequest) ProtoMessage() {}
+
+func (x *EmbedAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedAllRequest.ProtoReflect.Descriptor instead.
+func (*EmbedAllRequest) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EmbedAllRequest) GetInputs() []string {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *EmbedAllRequest) GetPromptName() string {
+	if x != nil && x.PromptName != nil {
+		return *x.PromptName
+	}
+	return ""
+}
+
+func (x *EmbedAllRequest) GetTruncate() bool {
+	if x != nil && x.Truncate != nil {
+		return *x.Truncate
+	}
+	return false
+}
+
+func (x *EmbedAllRequest) GetTruncationDirection() TruncationDirection {
+	if x != nil && x.TruncationDirection != nil {
+		return *x.TruncationDirection
+	}
+	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
+}
+
+func (x *EmbedAllRequest) GetAutoBatch() bool {
+	if x != nil && x.AutoBatch != nil {
+		return *x.AutoBatch
+	}
+	return false
+}
+
+func (x *EmbedAllRequest) GetMaxTokensPerInput() uint32 {
+	if x != nil && x.MaxTokensPerInput != nil {
+		return *x.MaxTokensPerInput
+	}
+	return 0
+}
+
+type EmbedAllResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	TokenEmbeddings []*TokenEmbeddings     `protobuf:"bytes,1,rep,name=token_embeddings,json=tokenEmbeddings,proto3" json:"token_embeddings,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *EmbedAllResponse) Reset() {
+	*x = EmbedAllResponse{}
+	mi := &file_v1_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedAllResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedAllResponse) ProtoMessage() {}
+
+func (x *EmbedAllResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedAllResponse.ProtoReflect.Descriptor instead.
+func (*EmbedAllResponse) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EmbedAllResponse) GetTokenEmbeddings() []*TokenEmbeddings {
+	if x != nil {
+		return x.TokenEmbeddings
+	}
+	return nil
+}
+
+type TokenEmbeddings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Embeddings    []*Embedding           `protobuf:"bytes,1,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenEmbeddings) Reset() {
+	*x = TokenEmbeddings{}
+	mi := &file_v1_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenEmbeddings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenEmbeddings) ProtoMessage() {}
+
+func (x *TokenEmbeddings) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenEmbeddings.ProtoReflect.Descriptor instead.
+func (*TokenEmbeddings) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TokenEmbeddings) GetEmbeddings() []*Embedding {
+	if x != nil {
+		return x.Embeddings
+	}
+	return nil
+}
+
+type EmbedSparseRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Inputs              []string               `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	PromptName          *string                `protobuf:"bytes,2,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
+	Truncate            *bool                  `protobuf:"varint,3,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
+	TruncationDirection *TruncationDirection   `protobuf:"varint,4,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
+	TopK                *uint32                `protobuf:"varint,5,opt,name=top_k,json=topK,proto3,oneof" json:"top_k,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *EmbedSparseRequest) Reset() {
+	*x = EmbedSparseRequest{}
+	mi := &file_v1_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedSparseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedSparseRequest) ProtoMessage() {}
+
+func (x *EmbedSparseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedSparseRequest.ProtoReflect.Descriptor instead.
+func (*EmbedSparseRequest) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *EmbedSparseRequest) GetInputs() []string {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *EmbedSparseRequest) GetPromptName() string {
+	if x != nil && x.PromptName != nil {
+		return *x.PromptName
+	}
+	return ""
+}
+
+func (x *EmbedSparseRequest) GetTruncate() bool {
+	if x != nil && x.Truncate != nil {
+		return *x.Truncate
+	}
+	return false
+}
+
+func (x *EmbedSparseRequest) GetTruncationDirection() TruncationDirection {
+	if x != nil && x.TruncationDirection != nil {
+		return *x.TruncationDirection
+	}
+	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
+}
+
+func (x *EmbedSparseRequest) GetTopK() uint32 {
+	if x != nil && x.TopK != nil {
+		return *x.TopK
+	}
+	return 0
+}
+
+type EmbedSparseResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	SparseEmbeddings []*SparseEmbedding     `protobuf:"bytes,1,rep,name=sparse_embeddings,json=sparseEmbeddings,proto3" json:"sparse_embeddings,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *EmbedSparseResponse) Reset() {
+	*x = EmbedSparseResponse{}
+	mi := &file_v1_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedSparseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedSparseResponse) ProtoMessage() {}
+
+func (x *EmbedSparseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedSparseResponse.ProtoReflect.Descriptor instead.
+func (*EmbedSparseResponse) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *EmbedSparseResponse) GetSparseEmbeddings() []*SparseEmbedding {
+	if x != nil {
+		return x.SparseEmbeddings
+	}
+	return nil
+}
+
+type SparseEmbedding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []*SparseValue         `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SparseEmbedding) Reset() {
+	*x = SparseEmbedding{}
+	mi := &file_v1_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SparseEmbedding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SparseEmbedding) ProtoMessage() {}
+
+func (x *SparseEmbedding) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SparseEmbedding.ProtoReflect.Descriptor instead.
+func (*SparseEmbedding) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SparseEmbedding) GetValues() []*SparseValue {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type SparseValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Value         float32                `protobuf:"fixed32,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SparseValue) Reset() {
+	*x = SparseValue{}
+	mi := &file_v1_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SparseValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SparseValue) ProtoMessage() {}
+
+func (x *SparseValue) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SparseValue.ProtoReflect.Descriptor instead.
+func (*SparseValue) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SparseValue) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *SparseValue) GetValue() float32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type EmbedHybridRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Inputs              []string               `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	Normalize           *bool                  `protobuf:"varint,2,opt,name=normalize,proto3,oneof" json:"normalize,omitempty"`
+	PromptName          *string                `protobuf:"bytes,3,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
+	Truncate            *bool                  `protobuf:"varint,4,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
+	TruncationDirection *TruncationDirection   `protobuf:"varint,5,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
+	SparseTopK          *uint32                `protobuf:"varint,6,opt,name=sparse_top_k,json=sparseTopK,proto3,oneof" json:"sparse_top_k,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *EmbedHybridRequest) Reset() {
+	*x = EmbedHybridRequest{}
+	mi := &file_v1_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedHybridRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedHybridRequest) ProtoMessage() {}
+
+func (x *EmbedHybridRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedHybridRequest.ProtoReflect.Descriptor instead.
+func (*EmbedHybridRequest) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *EmbedHybridRequest) GetInputs() []string {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *EmbedHybridRequest) GetNormalize() bool {
+	if x != nil && x.Normalize != nil {
+		return *x.Normalize
+	}
+	return false
+}
+
+func (x *EmbedHybridRequest) GetPromptName() string {
+	if x != nil && x.PromptName != nil {
+		return *x.PromptName
+	}
+	return ""
+}
+
+func (x *EmbedHybridRequest) GetTruncate() bool {
+	if x != nil && x.Truncate != nil {
+		return *x.Truncate
+	}
+	return false
+}
+
+func (x *EmbedHybridRequest) GetTruncationDirection() TruncationDirection {
+	if x != nil && x.TruncationDirection != nil {
+		return *x.TruncationDirection
+	}
+	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
+}
+
+func (x *EmbedHybridRequest) GetSparseTopK() uint32 {
+	if x != nil && x.SparseTopK != nil {
+		return *x.SparseTopK
+	}
+	return 0
+}
+
+// EmbedHybridResponse holds both representations aligned by input index
+type EmbedHybridResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DenseEmbeddings  []*Embedding           `protobuf:"bytes,1,rep,name=dense_embeddings,json=denseEmbeddings,proto3" json:"dense_embeddings,omitempty"`
+	SparseEmbeddings []*SparseEmbedding     `protobuf:"bytes,2,rep,name=sparse_embeddings,json=sparseEmbeddings,proto3" json:"sparse_embeddings,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *EmbedHybridResponse) Reset() {
+	*x = EmbedHybridResponse{}
+	mi := &file_v1_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedHybridResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedHybridResponse) ProtoMessage() {}
+
+func (x *EmbedHybridResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedHybridResponse.ProtoReflect.Descriptor instead.
+func (*EmbedHybridResponse) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *EmbedHybridResponse) GetDenseEmbeddings() []*Embedding {
+	if x != nil {
+		return x.DenseEmbeddings
+	}
+	return nil
+}
+
+func (x *EmbedHybridResponse) GetSparseEmbeddings() []*SparseEmbedding {
+	if x != nil {
+		return x.SparseEmbeddings
+	}
+	return nil
+}
+
+type SimilarityRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SourceSentence string                 `protobuf:"bytes,1,opt,name=source_sentence,json=sourceSentence,proto3" json:"source_sentence,omitempty"`
+	Sentences      []string               `protobuf:"bytes,2,rep,name=sentences,proto3" json:"sentences,omitempty"`
+	Parameters     *SimilarityParameters  `protobuf:"bytes,3,opt,name=parameters,proto3,oneof" json:"parameters,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SimilarityRequest) Reset() {
+	*x = SimilarityRequest{}
+	mi := &file_v1_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarityRequest) ProtoMessage() {}
+
+func (x *SimilarityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarityRequest.ProtoReflect.Descriptor instead.
+func (*SimilarityRequest) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SimilarityRequest) GetSourceSentence() string {
+	if x != nil {
+		return x.SourceSentence
+	}
+	return ""
+}
+
+func (x *SimilarityRequest) GetSentences() []string {
+	if x != nil {
+		return x.Sentences
+	}
+	return nil
+}
+
+func (x *SimilarityRequest) GetParameters() *SimilarityParameters {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+type SimilarityParameters struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	PromptName          *string                `protobuf:"bytes,1,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
+	Truncate            *bool                  `protobuf:"varint,2,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
+	TruncationDirection *TruncationDirection   `protobuf:"varint,3,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
+	Metric              *SimilarityMetric      `protobuf:"varint,4,opt,name=metric,proto3,enum=textembedding.SimilarityMetric,oneof" json:"metric,omitempty"`
+	Ranked              *bool                  `protobuf:"varint,5,opt,name=ranked,proto3,oneof" json:"ranked,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *SimilarityParameters) Reset() {
+	*x = SimilarityParameters{}
+	mi := &file_v1_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarityParameters) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarityParameters) ProtoMessage() {}
+
+func (x *SimilarityParameters) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarityParameters.ProtoReflect.Descriptor instead.
+func (*SimilarityParameters) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SimilarityParameters) GetPromptName() string {
+	if x != nil && x.PromptName != nil {
+		return *x.PromptName
+	}
+	return ""
+}
+
+func (x *SimilarityParameters) GetTruncate() bool {
+	if x != nil && x.Truncate != nil {
+		return *x.Truncate
+	}
+	return false
+}
+
+func (x *SimilarityParameters) GetTruncationDirection() TruncationDirection {
+	if x != nil && x.TruncationDirection != nil {
+		return *x.TruncationDirection
+	}
+	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
+}
+
+func (x *SimilarityParameters) GetMetric() SimilarityMetric {
+	if x != nil && x.Metric != nil {
+		return *x.Metric
+	}
+	return SimilarityMetric_SIMILARITY_METRIC_UNSPECIFIED
+}
+
+func (x *SimilarityParameters) GetRanked() bool {
+	if x != nil && x.Ranked != nil {
+		return *x.Ranked
+	}
+	return false
+}
+
+// SimilarityResponse holds a score per sentence in request order and, when
+// parameters.ranked is set, every sentence labeled with its score, best
+// first
+type SimilarityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Similarities  []float32              `protobuf:"fixed32,1,rep,packed,name=similarities,proto3" json:"similarities,omitempty"`
+	Ranked        []*RankedSentence      `protobuf:"bytes,2,rep,name=ranked,proto3" json:"ranked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimilarityResponse) Reset() {
+	*x = SimilarityResponse{}
+	mi := &file_v1_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarityResponse) ProtoMessage() {}
+
+func (x *SimilarityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarityResponse.ProtoReflect.Descriptor instead.
+func (*SimilarityResponse) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SimilarityResponse) GetSimilarities() []float32 {
+	if x != nil {
+		return x.Similarities
+	}
+	return nil
+}
+
+func (x *SimilarityResponse) GetRanked() []*RankedSentence {
+	if x != nil {
+		return x.Ranked
+	}
+	return nil
+}
+
+type RankedSentence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Sentence      string                 `protobuf:"bytes,2,opt,name=sentence,proto3" json:"sentence,omitempty"`
+	Similarity    float32                `protobuf:"fixed32,3,opt,name=similarity,proto3" json:"similarity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RankedSentence) Reset() {
+	*x = RankedSentence{}
+	mi := &file_v1_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RankedSentence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RankedSentence) ProtoMessage() {}
+
+func (x *RankedSentence) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RankedSentence.ProtoReflect.Descriptor instead.
+func (*RankedSentence) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RankedSentence) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RankedSentence) GetSentence() string {
+	if x != nil {
+		return x.Sentence
+	}
+	return ""
+}
+
+func (x *RankedSentence) GetSimilarity() float32 {
+	if x != nil {
+		return x.Similarity
+	}
+	return 0
+}
+
+// StreamSimilarityRequest scores sentences in chunks of chunk_size, which
+// defaults to the maximum batch size
+type StreamSimilarityRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SourceSentence string                 `protobuf:"bytes,1,opt,name=source_sentence,json=sourceSentence,proto3" json:"source_sentence,omitempty"`
+	Sentences      []string               `protobuf:"bytes,2,rep,name=sentences,proto3" json:"sentences,omitempty"`
+	Parameters     *SimilarityParameters  `protobuf:"bytes,3,opt,name=parameters,proto3,oneof" json:"parameters,omitempty"`
+	TopK           uint32                 `protobuf:"varint,4,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	ChunkSize      *uint32                `protobuf:"varint,5,opt,name=chunk_size,json=chunkSize,proto3,oneof" json:"chunk_size,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StreamSimilarityRequest) Reset() {
+	*x = StreamSimilarityRequest{}
+	mi := &file_v1_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamSimilarityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSimilarityRequest) ProtoMessage() {}
+
+func (x *StreamSimilarityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSimilarityRequest.ProtoReflect.Descriptor instead.
+func (*StreamSimilarityRequest) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *StreamSimilarityRequest) GetSourceSentence() string {
+	if x != nil {
+		return x.SourceSentence
+	}
+	return ""
+}
+
+func (x *StreamSimilarityRequest) GetSentences() []string {
+	if x != nil {
+		return x.Sentences
+	}
+	return nil
+}
+
+func (x *StreamSimilarityRequest) GetParameters() *SimilarityParameters {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *StreamSimilarityRequest) GetTopK() uint32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *StreamSimilarityRequest) GetChunkSize() uint32 {
+	if x != nil && x.ChunkSize != nil {
+		return *x.ChunkSize
+	}
+	return 0
+}
+
+// StreamSimilarityResponse holds the best matches among the first scored
+// of total sentences, best first
+type StreamSimilarityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TopMatches    []*SimilarityMatch     `protobuf:"bytes,1,rep,name=top_matches,json=topMatches,proto3" json:"top_matches,omitempty"`
+	Scored        uint32                 `protobuf:"varint,2,opt,name=scored,proto3" json:"scored,omitempty"`
+	Total         uint32                 `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	Done          bool                   `protobuf:"varint,4,opt,name=done,proto3" json:"done,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamSimilarityResponse) Reset() {
+	*x = StreamSimilarityResponse{}
+	mi := &file_v1_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamSimilarityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSimilarityResponse) ProtoMessage() {}
+
+func (x *StreamSimilarityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSimilarityResponse.ProtoReflect.Descriptor instead.
+func (*StreamSimilarityResponse) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *StreamSimilarityResponse) GetTopMatches() []*SimilarityMatch {
+	if x != nil {
+		return x.TopMatches
+	}
+	return nil
+}
+
+func (x *StreamSimilarityResponse) GetScored() uint32 {
+	if x != nil {
+		return x.Scored
+	}
+	return 0
+}
+
+func (x *StreamSimilarityResponse) GetTotal() uint32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *StreamSimilarityResponse) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+type SimilarityMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Score         float32                `protobuf:"fixed32,2,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimilarityMatch) Reset() {
+	*x = SimilarityMatch{}
+	mi := &file_v1_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarityMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarityMatch) ProtoMessage() {}
+
+func (x *SimilarityMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarityMatch.ProtoReflect.Descriptor instead.
+func (*SimilarityMatch) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SimilarityMatch) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *SimilarityMatch) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+// EmbedStreamRequest is one input of a streamed embed. Options are read
+// from the first message only and their inputs are ignored. Results are
+// streamed back a batch at a time, as each batch fills or the client
+// closes its side of the stream.
+type EmbedStreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Options       *EmbedRequest          `protobuf:"bytes,3,opt,name=options,proto3,oneof" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedStreamRequest) Reset() {
+	*x = EmbedStreamRequest{}
+	mi := &file_v1_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedStreamRequest) ProtoMessage() {}
+
+func (x *EmbedStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedStreamRequest.ProtoReflect.Descriptor instead.
+func (*EmbedStreamRequest) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *EmbedStreamRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *EmbedStreamRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *EmbedStreamRequest) GetOptions() *EmbedRequest {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type EmbedStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*EmbedStreamResult   `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedStreamResponse) Reset() {
+	*x = EmbedStreamResponse{}
+	mi := &file_v1_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedStreamResponse) ProtoMessage() {}
+
+func (x *EmbedStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedStreamResponse.ProtoReflect.Descriptor instead.
+func (*EmbedStreamResponse) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *EmbedStreamResponse) GetResults() []*EmbedStreamResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// EmbedStreamResult holds the embedding of the input with the given id, or
+// the error of its batch as a google.rpc.Code and message
+type EmbedStreamResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Embedding     *Embedding             `protobuf:"bytes,2,opt,name=embedding,proto3" json:"embedding,omitempty"`
+	ErrorCode     int32                  `protobuf:"varint,3,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorMessage  string                 `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EmbedSparseRequest) Reset() {
-	*x = EmbedSparseRequest{}
-	mi := &file_v1_service_proto_msgTypes[6]
+func (x *EmbedStreamResult) Reset() {
+	*x = EmbedStreamResult{}
+	mi := &file_v1_service_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EmbedSparseRequest) String() string {
+func (x *EmbedStreamResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EmbedSparseRequest) ProtoMessage() {}
+func (*EmbedStreamResult) ProtoMessage() {}
 
-func (x *EmbedSparseRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_service_proto_msgTypes[6]
+func (x *EmbedStreamResult) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -474,61 +2051,65 @@ func (x *EmbedSparseRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EmbedSparseRequest.ProtoReflect.Descriptor instead.
-func (*EmbedSparseRequest) Descriptor() ([]byte, []int) {
-	return file_v1_service_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use EmbedStreamResult.ProtoReflect.Descriptor instead.
+func (*EmbedStreamResult) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{28}
 }
 
-func (x *EmbedSparseRequest) GetInputs() []string {
+func (x *EmbedStreamResult) GetId() string {
 	if x != nil {
-		return x.Inputs
+		return x.Id
 	}
-	return nil
+	return ""
 }
 
-func (x *EmbedSparseRequest) GetPromptName() string {
-	if x != nil && x.PromptName != nil {
-		return *x.PromptName
+func (x *EmbedStreamResult) GetEmbedding() *Embedding {
+	if x != nil {
+		return x.Embedding
 	}
-	return ""
+	return nil
 }
 
-func (x *EmbedSparseRequest) GetTruncate() bool {
-	if x != nil && x.Truncate != nil {
-		return *x.Truncate
+func (x *EmbedStreamResult) GetErrorCode() int32 {
+	if x != nil {
+		return x.ErrorCode
 	}
-	return false
+	return 0
 }
 
-func (x *EmbedSparseRequest) GetTruncationDirection() TruncationDirection {
-	if x != nil && x.TruncationDirection != nil {
-		return *x.TruncationDirection
+func (x *EmbedStreamResult) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
 	}
-	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
+	return ""
 }
 
-type EmbedSparseResponse struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	SparseEmbeddings []*SparseEmbedding     `protobuf:"bytes,1,rep,name=sparse_embeddings,json=sparseEmbeddings,proto3" json:"sparse_embeddings,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+type ValidateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Request:
+	//
+	//	*ValidateRequest_Embed
+	//	*ValidateRequest_Similarity
+	Request       isValidateRequest_Request `protobuf_oneof:"request"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EmbedSparseResponse) Reset() {
-	*x = EmbedSparseResponse{}
-	mi := &file_v1_service_proto_msgTypes[7]
+func (x *ValidateRequest) Reset() {
+	*x = ValidateRequest{}
+	mi := &file_v1_service_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EmbedSparseResponse) String() string {
+func (x *ValidateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EmbedSparseResponse) ProtoMessage() {}
+func (*ValidateRequest) ProtoMessage() {}
 
-func (x *EmbedSparseResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_service_proto_msgTypes[7]
+func (x *ValidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -539,40 +2120,75 @@ func (x *EmbedSparseResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EmbedSparseResponse.ProtoReflect.Descriptor instead.
-func (*EmbedSparseResponse) Descriptor() ([]byte, []int) {
-	return file_v1_service_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use ValidateRequest.ProtoReflect.Descriptor instead.
+func (*ValidateRequest) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *EmbedSparseResponse) GetSparseEmbeddings() []*SparseEmbedding {
+func (x *ValidateRequest) GetRequest() isValidateRequest_Request {
 	if x != nil {
-		return x.SparseEmbeddings
+		return x.Request
 	}
 	return nil
 }
 
-type SparseEmbedding struct {
+func (x *ValidateRequest) GetEmbed() *EmbedRequest {
+	if x != nil {
+		if x, ok := x.Request.(*ValidateRequest_Embed); ok {
+			return x.Embed
+		}
+	}
+	return nil
+}
+
+func (x *ValidateRequest) GetSimilarity() *SimilarityRequest {
+	if x != nil {
+		if x, ok := x.Request.(*ValidateRequest_Similarity); ok {
+			return x.Similarity
+		}
+	}
+	return nil
+}
+
+type isValidateRequest_Request interface {
+	isValidateRequest_Request()
+}
+
+type ValidateRequest_Embed struct {
+	Embed *EmbedRequest `protobuf:"bytes,1,opt,name=embed,proto3,oneof"`
+}
+
+type ValidateRequest_Similarity struct {
+	Similarity *SimilarityRequest `protobuf:"bytes,2,opt,name=similarity,proto3,oneof"`
+}
+
+func (*ValidateRequest_Embed) isValidateRequest_Request() {}
+
+func (*ValidateRequest_Similarity) isValidateRequest_Request() {}
+
+type ValidateResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Values        []*SparseValue         `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	Violations    []*FieldViolation      `protobuf:"bytes,2,rep,name=violations,proto3" json:"violations,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SparseEmbedding) Reset() {
-	*x = SparseEmbedding{}
-	mi := &file_v1_service_proto_msgTypes[8]
+func (x *ValidateResponse) Reset() {
+	*x = ValidateResponse{}
+	mi := &file_v1_service_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SparseEmbedding) String() string {
+func (x *ValidateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SparseEmbedding) ProtoMessage() {}
+func (*ValidateResponse) ProtoMessage() {}
 
-func (x *SparseEmbedding) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_service_proto_msgTypes[8]
+func (x *ValidateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -583,41 +2199,48 @@ func (x *SparseEmbedding) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SparseEmbedding.ProtoReflect.Descriptor instead.
-func (*SparseEmbedding) Descriptor() ([]byte, []int) {
-	return file_v1_service_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use ValidateResponse.ProtoReflect.Descriptor instead.
+func (*ValidateResponse) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *SparseEmbedding) GetValues() []*SparseValue {
+func (x *ValidateResponse) GetValid() bool {
 	if x != nil {
-		return x.Values
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ValidateResponse) GetViolations() []*FieldViolation {
+	if x != nil {
+		return x.Violations
 	}
 	return nil
 }
 
-type SparseValue struct {
+type FieldViolation struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Index         uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
-	Value         float32                `protobuf:"fixed32,2,opt,name=value,proto3" json:"value,omitempty"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SparseValue) Reset() {
-	*x = SparseValue{}
-	mi := &file_v1_service_proto_msgTypes[9]
+func (x *FieldViolation) Reset() {
+	*x = FieldViolation{}
+	mi := &file_v1_service_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SparseValue) String() string {
+func (x *FieldViolation) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SparseValue) ProtoMessage() {}
+func (*FieldViolation) ProtoMessage() {}
 
-func (x *SparseValue) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_service_proto_msgTypes[9]
+func (x *FieldViolation) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -628,49 +2251,49 @@ func (x *SparseValue) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SparseValue.ProtoReflect.Descriptor instead.
-func (*SparseValue) Descriptor() ([]byte, []int) {
-	return file_v1_service_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use FieldViolation.ProtoReflect.Descriptor instead.
+func (*FieldViolation) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *SparseValue) GetIndex() uint32 {
+func (x *FieldViolation) GetField() string {
 	if x != nil {
-		return x.Index
+		return x.Field
 	}
-	return 0
+	return ""
 }
 
-func (x *SparseValue) GetValue() float32 {
+func (x *FieldViolation) GetMessage() string {
 	if x != nil {
-		return x.Value
+		return x.Message
 	}
-	return 0
+	return ""
 }
 
-type SimilarityRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	SourceSentence string                 `protobuf:"bytes,1,opt,name=source_sentence,json=sourceSentence,proto3" json:"source_sentence,omitempty"`
-	Sentences      []string               `protobuf:"bytes,2,rep,name=sentences,proto3" json:"sentences,omitempty"`
-	Parameters     *SimilarityParameters  `protobuf:"bytes,3,opt,name=parameters,proto3,oneof" json:"parameters,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type CountTokensRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Inputs           []string               `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	AddSpecialTokens *bool                  `protobuf:"varint,2,opt,name=add_special_tokens,json=addSpecialTokens,proto3,oneof" json:"add_special_tokens,omitempty"`
+	PromptName       *string                `protobuf:"bytes,3,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *SimilarityRequest) Reset() {
-	*x = SimilarityRequest{}
-	mi := &file_v1_service_proto_msgTypes[10]
+func (x *CountTokensRequest) Reset() {
+	*x = CountTokensRequest{}
+	mi := &file_v1_service_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SimilarityRequest) String() string {
+func (x *CountTokensRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SimilarityRequest) ProtoMessage() {}
+func (*CountTokensRequest) ProtoMessage() {}
 
-func (x *SimilarityRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_service_proto_msgTypes[10]
+func (x *CountTokensRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -681,56 +2304,56 @@ func (x *SimilarityRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SimilarityRequest.ProtoReflect.Descriptor instead.
-func (*SimilarityRequest) Descriptor() ([]byte, []int) {
-	return file_v1_service_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use CountTokensRequest.ProtoReflect.Descriptor instead.
+func (*CountTokensRequest) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *SimilarityRequest) GetSourceSentence() string {
+func (x *CountTokensRequest) GetInputs() []string {
 	if x != nil {
-		return x.SourceSentence
+		return x.Inputs
 	}
-	return ""
+	return nil
 }
 
-func (x *SimilarityRequest) GetSentences() []string {
-	if x != nil {
-		return x.Sentences
+func (x *CountTokensRequest) GetAddSpecialTokens() bool {
+	if x != nil && x.AddSpecialTokens != nil {
+		return *x.AddSpecialTokens
 	}
-	return nil
+	return false
 }
 
-func (x *SimilarityRequest) GetParameters() *SimilarityParameters {
-	if x != nil {
-		return x.Parameters
+func (x *CountTokensRequest) GetPromptName() string {
+	if x != nil && x.PromptName != nil {
+		return *x.PromptName
 	}
-	return nil
+	return ""
 }
 
-type SimilarityParameters struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	PromptName          *string                `protobuf:"bytes,1,opt,name=prompt_name,json=promptName,proto3,oneof" json:"prompt_name,omitempty"`
-	Truncate            *bool                  `protobuf:"varint,2,opt,name=truncate,proto3,oneof" json:"truncate,omitempty"`
-	TruncationDirection *TruncationDirection   `protobuf:"varint,3,opt,name=truncation_direction,json=truncationDirection,proto3,enum=textembedding.TruncationDirection,oneof" json:"truncation_direction,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+type CountTokensResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Counts         []*TokenCount          `protobuf:"bytes,1,rep,name=counts,proto3" json:"counts,omitempty"`
+	Total          uint32                 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	MaxInputTokens uint32                 `protobuf:"varint,3,opt,name=max_input_tokens,json=maxInputTokens,proto3" json:"max_input_tokens,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *SimilarityParameters) Reset() {
-	*x = SimilarityParameters{}
-	mi := &file_v1_service_proto_msgTypes[11]
+func (x *CountTokensResponse) Reset() {
+	*x = CountTokensResponse{}
+	mi := &file_v1_service_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SimilarityParameters) String() string {
+func (x *CountTokensResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SimilarityParameters) ProtoMessage() {}
+func (*CountTokensResponse) ProtoMessage() {}
 
-func (x *SimilarityParameters) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_service_proto_msgTypes[11]
+func (x *CountTokensResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -741,54 +2364,56 @@ func (x *SimilarityParameters) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SimilarityParameters.ProtoReflect.Descriptor instead.
-func (*SimilarityParameters) Descriptor() ([]byte, []int) {
-	return file_v1_service_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use CountTokensResponse.ProtoReflect.Descriptor instead.
+func (*CountTokensResponse) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *SimilarityParameters) GetPromptName() string {
-	if x != nil && x.PromptName != nil {
-		return *x.PromptName
+func (x *CountTokensResponse) GetCounts() []*TokenCount {
+	if x != nil {
+		return x.Counts
 	}
-	return ""
+	return nil
 }
 
-func (x *SimilarityParameters) GetTruncate() bool {
-	if x != nil && x.Truncate != nil {
-		return *x.Truncate
+func (x *CountTokensResponse) GetTotal() uint32 {
+	if x != nil {
+		return x.Total
 	}
-	return false
+	return 0
 }
 
-func (x *SimilarityParameters) GetTruncationDirection() TruncationDirection {
-	if x != nil && x.TruncationDirection != nil {
-		return *x.TruncationDirection
+func (x *CountTokensResponse) GetMaxInputTokens() uint32 {
+	if x != nil {
+		return x.MaxInputTokens
 	}
-	return TruncationDirection_TRUNCATION_DIRECTION_UNSPECIFIED
+	return 0
 }
 
-type SimilarityResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Similarities  []float32              `protobuf:"fixed32,1,rep,packed,name=similarities,proto3" json:"similarities,omitempty"`
+type TokenCount struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Tokens uint32                 `protobuf:"varint,1,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	// Unset when the model's maximum input length is not known
+	Truncated     *bool `protobuf:"varint,2,opt,name=truncated,proto3,oneof" json:"truncated,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SimilarityResponse) Reset() {
-	*x = SimilarityResponse{}
-	mi := &file_v1_service_proto_msgTypes[12]
+func (x *TokenCount) Reset() {
+	*x = TokenCount{}
+	mi := &file_v1_service_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SimilarityResponse) String() string {
+func (x *TokenCount) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SimilarityResponse) ProtoMessage() {}
+func (*TokenCount) ProtoMessage() {}
 
-func (x *SimilarityResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_service_proto_msgTypes[12]
+func (x *TokenCount) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_service_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -799,102 +2424,294 @@ func (x *SimilarityResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SimilarityResponse.ProtoReflect.Descriptor instead.
-func (*SimilarityResponse) Descriptor() ([]byte, []int) {
-	return file_v1_service_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use TokenCount.ProtoReflect.Descriptor instead.
+func (*TokenCount) Descriptor() ([]byte, []int) {
+	return file_v1_service_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *SimilarityResponse) GetSimilarities() []float32 {
+func (x *TokenCount) GetTokens() uint32 {
 	if x != nil {
-		return x.Similarities
+		return x.Tokens
 	}
-	return nil
+	return 0
+}
+
+func (x *TokenCount) GetTruncated() bool {
+	if x != nil && x.Truncated != nil {
+		return *x.Truncated
+	}
+	return false
 }
 
 var File_v1_service_proto protoreflect.FileDescriptor
 
 const file_v1_service_proto_rawDesc = "" +
 	"\n" +
-	"\x10v1/service.proto\x12\rtextembedding\"\xb0\x02\n" +
+	"\x10v1/service.proto\x12\rtextembedding\"\xb6\x06\n" +
 	"\fEmbedRequest\x12\x16\n" +
 	"\x06inputs\x18\x01 \x03(\tR\x06inputs\x12!\n" +
 	"\tnormalize\x18\x02 \x01(\bH\x00R\tnormalize\x88\x01\x01\x12$\n" +
 	"\vprompt_name\x18\x03 \x01(\tH\x01R\n" +
 	"promptName\x88\x01\x01\x12\x1f\n" +
 	"\btruncate\x18\x04 \x01(\bH\x02R\btruncate\x88\x01\x01\x12Z\n" +
-	"\x14truncation_direction\x18\x05 \x01(\x0e2\".textembedding.TruncationDirectionH\x03R\x13truncationDirection\x88\x01\x01B\f\n" +
+	"\x14truncation_direction\x18\x05 \x01(\x0e2\".textembedding.TruncationDirectionH\x03R\x13truncationDirection\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"auto_batch\x18\x06 \x01(\bH\x04R\tautoBatch\x88\x01\x01\x12&\n" +
+	"\fecho_request\x18\a \x01(\bH\x05R\vechoRequest\x88\x01\x01\x12*\n" +
+	"\x0eallow_degraded\x18\b \x01(\bH\x06R\rallowDegraded\x88\x01\x01\x12#\n" +
+	"\n" +
+	"dimensions\x18\t \x01(\rH\aR\n" +
+	"dimensions\x88\x01\x01\x12<\n" +
+	"\n" +
+	"input_role\x18\n" +
+	" \x01(\x0e2\x18.textembedding.InputRoleH\bR\tinputRole\x88\x01\x01\x12K\n" +
+	"\x0fencoding_format\x18\v \x01(\x0e2\x1d.textembedding.EncodingFormatH\tR\x0eencodingFormat\x88\x01\x01\x12%\n" +
+	"\vdeduplicate\x18\f \x01(\bH\n" +
+	"R\vdeduplicate\x88\x01\x01\x12,\n" +
+	"\x0fpartial_results\x18\r \x01(\bH\vR\x0epartialResults\x88\x01\x01B\f\n" +
 	"\n" +
 	"_normalizeB\x0e\n" +
 	"\f_prompt_nameB\v\n" +
 	"\t_truncateB\x17\n" +
-	"\x15_truncation_direction\"I\n" +
+	"\x15_truncation_directionB\r\n" +
+	"\v_auto_batchB\x0f\n" +
+	"\r_echo_requestB\x11\n" +
+	"\x0f_allow_degradedB\r\n" +
+	"\v_dimensionsB\r\n" +
+	"\v_input_roleB\x12\n" +
+	"\x10_encoding_formatB\x0e\n" +
+	"\f_deduplicateB\x12\n" +
+	"\x10_partial_results\"\x99\x02\n" +
+	"\x12EmbedTokensRequest\x12/\n" +
+	"\x06inputs\x18\x01 \x03(\v2\x17.textembedding.TokenIDsR\x06inputs\x12!\n" +
+	"\tnormalize\x18\x02 \x01(\bH\x00R\tnormalize\x88\x01\x01\x12\x1f\n" +
+	"\btruncate\x18\x03 \x01(\bH\x01R\btruncate\x88\x01\x01\x12Z\n" +
+	"\x14truncation_direction\x18\x04 \x01(\x0e2\".textembedding.TruncationDirectionH\x02R\x13truncationDirection\x88\x01\x01B\f\n" +
+	"\n" +
+	"_normalizeB\v\n" +
+	"\t_truncateB\x17\n" +
+	"\x15_truncation_direction\"\x1c\n" +
+	"\bTokenIDs\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\rR\x03ids\"w\n" +
+	"\x11EmbedImageRequest\x121\n" +
+	"\x06images\x18\x01 \x03(\v2\x19.textembedding.ImageInputR\x06images\x12!\n" +
+	"\tnormalize\x18\x02 \x01(\bH\x00R\tnormalize\x88\x01\x01B\f\n" +
+	"\n" +
+	"_normalize\"y\n" +
+	"\n" +
+	"ImageInput\x12\x12\n" +
+	"\x03url\x18\x01 \x01(\tH\x00R\x03url\x12\x14\n" +
+	"\x04data\x18\x02 \x01(\fH\x00R\x04data\x12&\n" +
+	"\fcontent_type\x18\x03 \x01(\tH\x01R\vcontentType\x88\x01\x01B\b\n" +
+	"\x06sourceB\x0f\n" +
+	"\r_content_type\"\xca\x02\n" +
 	"\rEmbedResponse\x128\n" +
 	"\n" +
 	"embeddings\x18\x01 \x03(\v2\x18.textembedding.EmbeddingR\n" +
-	"embeddings\"#\n" +
+	"embeddings\x123\n" +
+	"\x04echo\x18\x02 \x01(\v2\x1a.textembedding.RequestEchoH\x00R\x04echo\x88\x01\x01\x12\x1a\n" +
+	"\bdegraded\x18\x03 \x01(\bR\bdegraded\x12T\n" +
+	"\x14quantized_embeddings\x18\x04 \x03(\v2!.textembedding.QuantizedEmbeddingR\x13quantizedEmbeddings\x12\x1c\n" +
+	"\ttruncated\x18\x05 \x03(\bR\ttruncated\x121\n" +
+	"\x06errors\x18\x06 \x03(\v2\x19.textembedding.InputErrorR\x06errorsB\a\n" +
+	"\x05_echo\"P\n" +
+	"\n" +
+	"InputError\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"l\n" +
+	"\vRequestEcho\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1f\n" +
+	"\vinput_count\x18\x02 \x01(\rR\n" +
+	"inputCount\x12\x1d\n" +
+	"\n" +
+	"input_hash\x18\x03 \x01(\tR\tinputHash\"#\n" +
 	"\tEmbedding\x12\x16\n" +
-	"\x06values\x18\x01 \x03(\x02R\x06values\"\x82\x02\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\"B\n" +
+	"\x12QuantizedEmbedding\x12\x16\n" +
+	"\x06values\x18\x01 \x01(\fR\x06values\x12\x14\n" +
+	"\x05scale\x18\x02 \x01(\x02R\x05scale\"\x84\x03\n" +
 	"\x0fEmbedAllRequest\x12\x16\n" +
 	"\x06inputs\x18\x01 \x03(\tR\x06inputs\x12$\n" +
 	"\vprompt_name\x18\x02 \x01(\tH\x00R\n" +
 	"promptName\x88\x01\x01\x12\x1f\n" +
 	"\btruncate\x18\x03 \x01(\bH\x01R\btruncate\x88\x01\x01\x12Z\n" +
-	"\x14truncation_direction\x18\x04 \x01(\x0e2\".textembedding.TruncationDirectionH\x02R\x13truncationDirection\x88\x01\x01B\x0e\n" +
+	"\x14truncation_direction\x18\x04 \x01(\x0e2\".textembedding.TruncationDirectionH\x02R\x13truncationDirection\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"auto_batch\x18\x05 \x01(\bH\x03R\tautoBatch\x88\x01\x01\x124\n" +
+	"\x14max_tokens_per_input\x18\x06 \x01(\rH\x04R\x11maxTokensPerInput\x88\x01\x01B\x0e\n" +
 	"\f_prompt_nameB\v\n" +
 	"\t_truncateB\x17\n" +
-	"\x15_truncation_direction\"]\n" +
+	"\x15_truncation_directionB\r\n" +
+	"\v_auto_batchB\x17\n" +
+	"\x15_max_tokens_per_input\"]\n" +
 	"\x10EmbedAllResponse\x12I\n" +
 	"\x10token_embeddings\x18\x01 \x03(\v2\x1e.textembedding.TokenEmbeddingsR\x0ftokenEmbeddings\"K\n" +
 	"\x0fTokenEmbeddings\x128\n" +
 	"\n" +
 	"embeddings\x18\x01 \x03(\v2\x18.textembedding.EmbeddingR\n" +
-	"embeddings\"\x85\x02\n" +
+	"embeddings\"\xa9\x02\n" +
 	"\x12EmbedSparseRequest\x12\x16\n" +
 	"\x06inputs\x18\x01 \x03(\tR\x06inputs\x12$\n" +
 	"\vprompt_name\x18\x02 \x01(\tH\x00R\n" +
 	"promptName\x88\x01\x01\x12\x1f\n" +
 	"\btruncate\x18\x03 \x01(\bH\x01R\btruncate\x88\x01\x01\x12Z\n" +
-	"\x14truncation_direction\x18\x04 \x01(\x0e2\".textembedding.TruncationDirectionH\x02R\x13truncationDirection\x88\x01\x01B\x0e\n" +
+	"\x14truncation_direction\x18\x04 \x01(\x0e2\".textembedding.TruncationDirectionH\x02R\x13truncationDirection\x88\x01\x01\x12\x18\n" +
+	"\x05top_k\x18\x05 \x01(\rH\x03R\x04topK\x88\x01\x01B\x0e\n" +
 	"\f_prompt_nameB\v\n" +
 	"\t_truncateB\x17\n" +
-	"\x15_truncation_direction\"b\n" +
+	"\x15_truncation_directionB\b\n" +
+	"\x06_top_k\"b\n" +
 	"\x13EmbedSparseResponse\x12K\n" +
 	"\x11sparse_embeddings\x18\x01 \x03(\v2\x1e.textembedding.SparseEmbeddingR\x10sparseEmbeddings\"E\n" +
 	"\x0fSparseEmbedding\x122\n" +
 	"\x06values\x18\x01 \x03(\v2\x1a.textembedding.SparseValueR\x06values\"9\n" +
 	"\vSparseValue\x12\x14\n" +
 	"\x05index\x18\x01 \x01(\rR\x05index\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x02R\x05value\"\xb3\x01\n" +
+	"\x05value\x18\x02 \x01(\x02R\x05value\"\xee\x02\n" +
+	"\x12EmbedHybridRequest\x12\x16\n" +
+	"\x06inputs\x18\x01 \x03(\tR\x06inputs\x12!\n" +
+	"\tnormalize\x18\x02 \x01(\bH\x00R\tnormalize\x88\x01\x01\x12$\n" +
+	"\vprompt_name\x18\x03 \x01(\tH\x01R\n" +
+	"promptName\x88\x01\x01\x12\x1f\n" +
+	"\btruncate\x18\x04 \x01(\bH\x02R\btruncate\x88\x01\x01\x12Z\n" +
+	"\x14truncation_direction\x18\x05 \x01(\x0e2\".textembedding.TruncationDirectionH\x03R\x13truncationDirection\x88\x01\x01\x12%\n" +
+	"\fsparse_top_k\x18\x06 \x01(\rH\x04R\n" +
+	"sparseTopK\x88\x01\x01B\f\n" +
+	"\n" +
+	"_normalizeB\x0e\n" +
+	"\f_prompt_nameB\v\n" +
+	"\t_truncateB\x17\n" +
+	"\x15_truncation_directionB\x0f\n" +
+	"\r_sparse_top_k\"\xa7\x01\n" +
+	"\x13EmbedHybridResponse\x12C\n" +
+	"\x10dense_embeddings\x18\x01 \x03(\v2\x18.textembedding.EmbeddingR\x0fdenseEmbeddings\x12K\n" +
+	"\x11sparse_embeddings\x18\x02 \x03(\v2\x1e.textembedding.SparseEmbeddingR\x10sparseEmbeddings\"\xb3\x01\n" +
 	"\x11SimilarityRequest\x12'\n" +
 	"\x0fsource_sentence\x18\x01 \x01(\tR\x0esourceSentence\x12\x1c\n" +
 	"\tsentences\x18\x02 \x03(\tR\tsentences\x12H\n" +
 	"\n" +
 	"parameters\x18\x03 \x01(\v2#.textembedding.SimilarityParametersH\x00R\n" +
 	"parameters\x88\x01\x01B\r\n" +
-	"\v_parameters\"\xef\x01\n" +
+	"\v_parameters\"\xe0\x02\n" +
 	"\x14SimilarityParameters\x12$\n" +
 	"\vprompt_name\x18\x01 \x01(\tH\x00R\n" +
 	"promptName\x88\x01\x01\x12\x1f\n" +
 	"\btruncate\x18\x02 \x01(\bH\x01R\btruncate\x88\x01\x01\x12Z\n" +
-	"\x14truncation_direction\x18\x03 \x01(\x0e2\".textembedding.TruncationDirectionH\x02R\x13truncationDirection\x88\x01\x01B\x0e\n" +
+	"\x14truncation_direction\x18\x03 \x01(\x0e2\".textembedding.TruncationDirectionH\x02R\x13truncationDirection\x88\x01\x01\x12<\n" +
+	"\x06metric\x18\x04 \x01(\x0e2\x1f.textembedding.SimilarityMetricH\x03R\x06metric\x88\x01\x01\x12\x1b\n" +
+	"\x06ranked\x18\x05 \x01(\bH\x04R\x06ranked\x88\x01\x01B\x0e\n" +
 	"\f_prompt_nameB\v\n" +
 	"\t_truncateB\x17\n" +
-	"\x15_truncation_direction\"8\n" +
+	"\x15_truncation_directionB\t\n" +
+	"\a_metricB\t\n" +
+	"\a_ranked\"o\n" +
 	"\x12SimilarityResponse\x12\"\n" +
-	"\fsimilarities\x18\x01 \x03(\x02R\fsimilarities*z\n" +
+	"\fsimilarities\x18\x01 \x03(\x02R\fsimilarities\x125\n" +
+	"\x06ranked\x18\x02 \x03(\v2\x1d.textembedding.RankedSentenceR\x06ranked\"b\n" +
+	"\x0eRankedSentence\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12\x1a\n" +
+	"\bsentence\x18\x02 \x01(\tR\bsentence\x12\x1e\n" +
+	"\n" +
+	"similarity\x18\x03 \x01(\x02R\n" +
+	"similarity\"\x81\x02\n" +
+	"\x17StreamSimilarityRequest\x12'\n" +
+	"\x0fsource_sentence\x18\x01 \x01(\tR\x0esourceSentence\x12\x1c\n" +
+	"\tsentences\x18\x02 \x03(\tR\tsentences\x12H\n" +
+	"\n" +
+	"parameters\x18\x03 \x01(\v2#.textembedding.SimilarityParametersH\x00R\n" +
+	"parameters\x88\x01\x01\x12\x13\n" +
+	"\x05top_k\x18\x04 \x01(\rR\x04topK\x12\"\n" +
+	"\n" +
+	"chunk_size\x18\x05 \x01(\rH\x01R\tchunkSize\x88\x01\x01B\r\n" +
+	"\v_parametersB\r\n" +
+	"\v_chunk_size\"\x9d\x01\n" +
+	"\x18StreamSimilarityResponse\x12?\n" +
+	"\vtop_matches\x18\x01 \x03(\v2\x1e.textembedding.SimilarityMatchR\n" +
+	"topMatches\x12\x16\n" +
+	"\x06scored\x18\x02 \x01(\rR\x06scored\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\rR\x05total\x12\x12\n" +
+	"\x04done\x18\x04 \x01(\bR\x04done\"=\n" +
+	"\x0fSimilarityMatch\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x02R\x05score\"\x80\x01\n" +
+	"\x12EmbedStreamRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12:\n" +
+	"\aoptions\x18\x03 \x01(\v2\x1b.textembedding.EmbedRequestH\x00R\aoptions\x88\x01\x01B\n" +
+	"\n" +
+	"\b_options\"Q\n" +
+	"\x13EmbedStreamResponse\x12:\n" +
+	"\aresults\x18\x01 \x03(\v2 .textembedding.EmbedStreamResultR\aresults\"\x9f\x01\n" +
+	"\x11EmbedStreamResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x126\n" +
+	"\tembedding\x18\x02 \x01(\v2\x18.textembedding.EmbeddingR\tembedding\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x03 \x01(\x05R\terrorCode\x12#\n" +
+	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage\"\x95\x01\n" +
+	"\x0fValidateRequest\x123\n" +
+	"\x05embed\x18\x01 \x01(\v2\x1b.textembedding.EmbedRequestH\x00R\x05embed\x12B\n" +
+	"\n" +
+	"similarity\x18\x02 \x01(\v2 .textembedding.SimilarityRequestH\x00R\n" +
+	"similarityB\t\n" +
+	"\arequest\"g\n" +
+	"\x10ValidateResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12=\n" +
+	"\n" +
+	"violations\x18\x02 \x03(\v2\x1d.textembedding.FieldViolationR\n" +
+	"violations\"@\n" +
+	"\x0eFieldViolation\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xac\x01\n" +
+	"\x12CountTokensRequest\x12\x16\n" +
+	"\x06inputs\x18\x01 \x03(\tR\x06inputs\x121\n" +
+	"\x12add_special_tokens\x18\x02 \x01(\bH\x00R\x10addSpecialTokens\x88\x01\x01\x12$\n" +
+	"\vprompt_name\x18\x03 \x01(\tH\x01R\n" +
+	"promptName\x88\x01\x01B\x15\n" +
+	"\x13_add_special_tokensB\x0e\n" +
+	"\f_prompt_name\"\x88\x01\n" +
+	"\x13CountTokensResponse\x121\n" +
+	"\x06counts\x18\x01 \x03(\v2\x19.textembedding.TokenCountR\x06counts\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\rR\x05total\x12(\n" +
+	"\x10max_input_tokens\x18\x03 \x01(\rR\x0emaxInputTokens\"U\n" +
+	"\n" +
+	"TokenCount\x12\x16\n" +
+	"\x06tokens\x18\x01 \x01(\rR\x06tokens\x12!\n" +
+	"\ttruncated\x18\x02 \x01(\bH\x00R\ttruncated\x88\x01\x01B\f\n" +
+	"\n" +
+	"_truncated*z\n" +
 	"\x13TruncationDirection\x12$\n" +
 	" TRUNCATION_DIRECTION_UNSPECIFIED\x10\x00\x12\x1d\n" +
 	"\x19TRUNCATION_DIRECTION_LEFT\x10\x01\x12\x1e\n" +
-	"\x1aTRUNCATION_DIRECTION_RIGHT\x10\x02*h\n" +
+	"\x1aTRUNCATION_DIRECTION_RIGHT\x10\x02*\x82\x01\n" +
 	"\x0eEncodingFormat\x12\x1f\n" +
 	"\x1bENCODING_FORMAT_UNSPECIFIED\x10\x00\x12\x19\n" +
 	"\x15ENCODING_FORMAT_FLOAT\x10\x01\x12\x1a\n" +
-	"\x16ENCODING_FORMAT_BASE64\x10\x022\xda\x02\n" +
+	"\x16ENCODING_FORMAT_BASE64\x10\x02\x12\x18\n" +
+	"\x14ENCODING_FORMAT_INT8\x10\x03*\x8f\x01\n" +
+	"\x10SimilarityMetric\x12!\n" +
+	"\x1dSIMILARITY_METRIC_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18SIMILARITY_METRIC_COSINE\x10\x01\x12\x19\n" +
+	"\x15SIMILARITY_METRIC_DOT\x10\x02\x12\x1f\n" +
+	"\x1bSIMILARITY_METRIC_EUCLIDEAN\x10\x03*V\n" +
+	"\tInputRole\x12\x1a\n" +
+	"\x16INPUT_ROLE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10INPUT_ROLE_QUERY\x10\x01\x12\x17\n" +
+	"\x13INPUT_ROLE_DOCUMENT\x10\x022\xb2\a\n" +
 	"\x15TextEmbeddingsService\x12B\n" +
-	"\x05Embed\x12\x1b.textembedding.EmbedRequest\x1a\x1c.textembedding.EmbedResponse\x12K\n" +
+	"\x05Embed\x12\x1b.textembedding.EmbedRequest\x1a\x1c.textembedding.EmbedResponse\x12N\n" +
+	"\vEmbedTokens\x12!.textembedding.EmbedTokensRequest\x1a\x1c.textembedding.EmbedResponse\x12L\n" +
+	"\n" +
+	"EmbedImage\x12 .textembedding.EmbedImageRequest\x1a\x1c.textembedding.EmbedResponse\x12K\n" +
 	"\bEmbedAll\x12\x1e.textembedding.EmbedAllRequest\x1a\x1f.textembedding.EmbedAllResponse\x12T\n" +
-	"\vEmbedSparse\x12!.textembedding.EmbedSparseRequest\x1a\".textembedding.EmbedSparseResponse\x12Z\n" +
-	"\x13CalculateSimilarity\x12 .textembedding.SimilarityRequest\x1a!.textembedding.SimilarityResponseB\x14Z\x12./protos/gen/v1;v1b\x06proto3"
+	"\vEmbedSparse\x12!.textembedding.EmbedSparseRequest\x1a\".textembedding.EmbedSparseResponse\x12T\n" +
+	"\vEmbedHybrid\x12!.textembedding.EmbedHybridRequest\x1a\".textembedding.EmbedHybridResponse\x12Z\n" +
+	"\x13CalculateSimilarity\x12 .textembedding.SimilarityRequest\x1a!.textembedding.SimilarityResponse\x12e\n" +
+	"\x10StreamSimilarity\x12&.textembedding.StreamSimilarityRequest\x1a'.textembedding.StreamSimilarityResponse0\x01\x12X\n" +
+	"\vEmbedStream\x12!.textembedding.EmbedStreamRequest\x1a\".textembedding.EmbedStreamResponse(\x010\x01\x12K\n" +
+	"\bValidate\x12\x1e.textembedding.ValidateRequest\x1a\x1f.textembedding.ValidateResponse\x12T\n" +
+	"\vCountTokens\x12!.textembedding.CountTokensRequest\x1a\".textembedding.CountTokensResponseB\x14Z\x12./protos/gen/v1;v1b\x06proto3"
 
 var (
 	file_v1_service_proto_rawDescOnce sync.Once
@@ -908,49 +2725,109 @@ func file_v1_service_proto_rawDescGZIP() []byte {
 	return file_v1_service_proto_rawDescData
 }
 
-var file_v1_service_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_v1_service_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_v1_service_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_v1_service_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_v1_service_proto_goTypes = []any{
-	(TruncationDirection)(0),     // 0: textembedding.TruncationDirection
-	(EncodingFormat)(0),          // 1: textembedding.EncodingFormat
-	(*EmbedRequest)(nil),         // 2: textembedding.EmbedRequest
-	(*EmbedResponse)(nil),        // 3: textembedding.EmbedResponse
-	(*Embedding)(nil),            // 4: textembedding.Embedding
-	(*EmbedAllRequest)(nil),      // 5: textembedding.EmbedAllRequest
-	(*EmbedAllResponse)(nil),     // 6: textembedding.EmbedAllResponse
-	(*TokenEmbeddings)(nil),      // 7: textembedding.TokenEmbeddings
-	(*EmbedSparseRequest)(nil),   // 8: textembedding.EmbedSparseRequest
-	(*EmbedSparseResponse)(nil),  // 9: textembedding.EmbedSparseResponse
-	(*SparseEmbedding)(nil),      // 10: textembedding.SparseEmbedding
-	(*SparseValue)(nil),          // 11: textembedding.SparseValue
-	(*SimilarityRequest)(nil),    // 12: textembedding.SimilarityRequest
-	(*SimilarityParameters)(nil), // 13: textembedding.SimilarityParameters
-	(*SimilarityResponse)(nil),   // 14: textembedding.SimilarityResponse
+	(TruncationDirection)(0),         // 0: textembedding.TruncationDirection
+	(EncodingFormat)(0),              // 1: textembedding.EncodingFormat
+	(SimilarityMetric)(0),            // 2: textembedding.SimilarityMetric
+	(InputRole)(0),                   // 3: textembedding.InputRole
+	(*EmbedRequest)(nil),             // 4: textembedding.EmbedRequest
+	(*EmbedTokensRequest)(nil),       // 5: textembedding.EmbedTokensRequest
+	(*TokenIDs)(nil),                 // 6: textembedding.TokenIDs
+	(*EmbedImageRequest)(nil),        // 7: textembedding.EmbedImageRequest
+	(*ImageInput)(nil),               // 8: textembedding.ImageInput
+	(*EmbedResponse)(nil),            // 9: textembedding.EmbedResponse
+	(*InputError)(nil),               // 10: textembedding.InputError
+	(*RequestEcho)(nil),              // 11: textembedding.RequestEcho
+	(*Embedding)(nil),                // 12: textembedding.Embedding
+	(*QuantizedEmbedding)(nil),       // 13: textembedding.QuantizedEmbedding
+	(*EmbedAllRequest)(nil),          // 14: textembedding.EmbedAllRequest
+	(*EmbedAllResponse)(nil),         // 15: textembedding.EmbedAllResponse
+	(*TokenEmbeddings)(nil),          // 16: textembedding.TokenEmbeddings
+	(*EmbedSparseRequest)(nil),       // 17: textembedding.EmbedSparseRequest
+	(*EmbedSparseResponse)(nil),      // 18: textembedding.EmbedSparseResponse
+	(*SparseEmbedding)(nil),          // 19: textembedding.SparseEmbedding
+	(*SparseValue)(nil),              // 20: textembedding.SparseValue
+	(*EmbedHybridRequest)(nil),       // 21: textembedding.EmbedHybridRequest
+	(*EmbedHybridResponse)(nil),      // 22: textembedding.EmbedHybridResponse
+	(*SimilarityRequest)(nil),        // 23: textembedding.SimilarityRequest
+	(*SimilarityParameters)(nil),     // 24: textembedding.SimilarityParameters
+	(*SimilarityResponse)(nil),       // 25: textembedding.SimilarityResponse
+	(*RankedSentence)(nil),           // 26: textembedding.RankedSentence
+	(*StreamSimilarityRequest)(nil),  // 27: textembedding.StreamSimilarityRequest
+	(*StreamSimilarityResponse)(nil), // 28: textembedding.StreamSimilarityResponse
+	(*SimilarityMatch)(nil),          // 29: textembedding.SimilarityMatch
+	(*EmbedStreamRequest)(nil),       // 30: textembedding.EmbedStreamRequest
+	(*EmbedStreamResponse)(nil),      // 31: textembedding.EmbedStreamResponse
+	(*EmbedStreamResult)(nil),        // 32: textembedding.EmbedStreamResult
+	(*ValidateRequest)(nil),          // 33: textembedding.ValidateRequest
+	(*ValidateResponse)(nil),         // 34: textembedding.ValidateResponse
+	(*FieldViolation)(nil),           // 35: textembedding.FieldViolation
+	(*CountTokensRequest)(nil),       // 36: textembedding.CountTokensRequest
+	(*CountTokensResponse)(nil),      // 37: textembedding.CountTokensResponse
+	(*TokenCount)(nil),               // 38: textembedding.TokenCount
 }
 var file_v1_service_proto_depIdxs = []int32{
 	0,  // 0: textembedding.EmbedRequest.truncation_direction:type_name -> textembedding.TruncationDirection
-	4,  // 1: textembedding.EmbedResponse.embeddings:type_name -> textembedding.Embedding
-	0,  // 2: textembedding.EmbedAllRequest.truncation_direction:type_name -> textembedding.TruncationDirection
-	7,  // 3: textembedding.EmbedAllResponse.token_embeddings:type_name -> textembedding.TokenEmbeddings
-	4,  // 4: textembedding.TokenEmbeddings.embeddings:type_name -> textembedding.Embedding
-	0,  // 5: textembedding.EmbedSparseRequest.truncation_direction:type_name -> textembedding.TruncationDirection
-	10, // 6: textembedding.EmbedSparseResponse.sparse_embeddings:type_name -> textembedding.SparseEmbedding
-	11, // 7: textembedding.SparseEmbedding.values:type_name -> textembedding.SparseValue
-	13, // 8: textembedding.SimilarityRequest.parameters:type_name -> textembedding.SimilarityParameters
-	0,  // 9: textembedding.SimilarityParameters.truncation_direction:type_name -> textembedding.TruncationDirection
-	2,  // 10: textembedding.TextEmbeddingsService.Embed:input_type -> textembedding.EmbedRequest
-	5,  // 11: textembedding.TextEmbeddingsService.EmbedAll:input_type -> textembedding.EmbedAllRequest
-	8,  // 12: textembedding.TextEmbeddingsService.EmbedSparse:input_type -> textembedding.EmbedSparseRequest
-	12, // 13: textembedding.TextEmbeddingsService.CalculateSimilarity:input_type -> textembedding.SimilarityRequest
-	3,  // 14: textembedding.TextEmbeddingsService.Embed:output_type -> textembedding.EmbedResponse
-	6,  // 15: textembedding.TextEmbeddingsService.EmbedAll:output_type -> textembedding.EmbedAllResponse
-	9,  // 16: textembedding.TextEmbeddingsService.EmbedSparse:output_type -> textembedding.EmbedSparseResponse
-	14, // 17: textembedding.TextEmbeddingsService.CalculateSimilarity:output_type -> textembedding.SimilarityResponse
-	14, // [14:18] is the sub-list for method output_type
-	10, // [10:14] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	3,  // 1: textembedding.EmbedRequest.input_role:type_name -> textembedding.InputRole
+	1,  // 2: textembedding.EmbedRequest.encoding_format:type_name -> textembedding.EncodingFormat
+	6,  // 3: textembedding.EmbedTokensRequest.inputs:type_name -> textembedding.TokenIDs
+	0,  // 4: textembedding.EmbedTokensRequest.truncation_direction:type_name -> textembedding.TruncationDirection
+	8,  // 5: textembedding.EmbedImageRequest.images:type_name -> textembedding.ImageInput
+	12, // 6: textembedding.EmbedResponse.embeddings:type_name -> textembedding.Embedding
+	11, // 7: textembedding.EmbedResponse.echo:type_name -> textembedding.RequestEcho
+	13, // 8: textembedding.EmbedResponse.quantized_embeddings:type_name -> textembedding.QuantizedEmbedding
+	10, // 9: textembedding.EmbedResponse.errors:type_name -> textembedding.InputError
+	0,  // 10: textembedding.EmbedAllRequest.truncation_direction:type_name -> textembedding.TruncationDirection
+	16, // 11: textembedding.EmbedAllResponse.token_embeddings:type_name -> textembedding.TokenEmbeddings
+	12, // 12: textembedding.TokenEmbeddings.embeddings:type_name -> textembedding.Embedding
+	0,  // 13: textembedding.EmbedSparseRequest.truncation_direction:type_name -> textembedding.TruncationDirection
+	19, // 14: textembedding.EmbedSparseResponse.sparse_embeddings:type_name -> textembedding.SparseEmbedding
+	20, // 15: textembedding.SparseEmbedding.values:type_name -> textembedding.SparseValue
+	0,  // 16: textembedding.EmbedHybridRequest.truncation_direction:type_name -> textembedding.TruncationDirection
+	12, // 17: textembedding.EmbedHybridResponse.dense_embeddings:type_name -> textembedding.Embedding
+	19, // 18: textembedding.EmbedHybridResponse.sparse_embeddings:type_name -> textembedding.SparseEmbedding
+	24, // 19: textembedding.SimilarityRequest.parameters:type_name -> textembedding.SimilarityParameters
+	0,  // 20: textembedding.SimilarityParameters.truncation_direction:type_name -> textembedding.TruncationDirection
+	2,  // 21: textembedding.SimilarityParameters.metric:type_name -> textembedding.SimilarityMetric
+	26, // 22: textembedding.SimilarityResponse.ranked:type_name -> textembedding.RankedSentence
+	24, // 23: textembedding.StreamSimilarityRequest.parameters:type_name -> textembedding.SimilarityParameters
+	29, // 24: textembedding.StreamSimilarityResponse.top_matches:type_name -> textembedding.SimilarityMatch
+	4,  // 25: textembedding.EmbedStreamRequest.options:type_name -> textembedding.EmbedRequest
+	32, // 26: textembedding.EmbedStreamResponse.results:type_name -> textembedding.EmbedStreamResult
+	12, // 27: textembedding.EmbedStreamResult.embedding:type_name -> textembedding.Embedding
+	4,  // 28: textembedding.ValidateRequest.embed:type_name -> textembedding.EmbedRequest
+	23, // 29: textembedding.ValidateRequest.similarity:type_name -> textembedding.SimilarityRequest
+	35, // 30: textembedding.ValidateResponse.violations:type_name -> textembedding.FieldViolation
+	38, // 31: textembedding.CountTokensResponse.counts:type_name -> textembedding.TokenCount
+	4,  // 32: textembedding.TextEmbeddingsService.Embed:input_type -> textembedding.EmbedRequest
+	5,  // 33: textembedding.TextEmbeddingsService.EmbedTokens:input_type -> textembedding.EmbedTokensRequest
+	7,  // 34: textembedding.TextEmbeddingsService.EmbedImage:input_type -> textembedding.EmbedImageRequest
+	14, // 35: textembedding.TextEmbeddingsService.EmbedAll:input_type -> textembedding.EmbedAllRequest
+	17, // 36: textembedding.TextEmbeddingsService.EmbedSparse:input_type -> textembedding.EmbedSparseRequest
+	21, // 37: textembedding.TextEmbeddingsService.EmbedHybrid:input_type -> textembedding.EmbedHybridRequest
+	23, // 38: textembedding.TextEmbeddingsService.CalculateSimilarity:input_type -> textembedding.SimilarityRequest
+	27, // 39: textembedding.TextEmbeddingsService.StreamSimilarity:input_type -> textembedding.StreamSimilarityRequest
+	30, // 40: textembedding.TextEmbeddingsService.EmbedStream:input_type -> textembedding.EmbedStreamRequest
+	33, // 41: textembedding.TextEmbeddingsService.Validate:input_type -> textembedding.ValidateRequest
+	36, // 42: textembedding.TextEmbeddingsService.CountTokens:input_type -> textembedding.CountTokensRequest
+	9,  // 43: textembedding.TextEmbeddingsService.Embed:output_type -> textembedding.EmbedResponse
+	9,  // 44: textembedding.TextEmbeddingsService.EmbedTokens:output_type -> textembedding.EmbedResponse
+	9,  // 45: textembedding.TextEmbeddingsService.EmbedImage:output_type -> textembedding.EmbedResponse
+	15, // 46: textembedding.TextEmbeddingsService.EmbedAll:output_type -> textembedding.EmbedAllResponse
+	18, // 47: textembedding.TextEmbeddingsService.EmbedSparse:output_type -> textembedding.EmbedSparseResponse
+	22, // 48: textembedding.TextEmbeddingsService.EmbedHybrid:output_type -> textembedding.EmbedHybridResponse
+	25, // 49: textembedding.TextEmbeddingsService.CalculateSimilarity:output_type -> textembedding.SimilarityResponse
+	28, // 50: textembedding.TextEmbeddingsService.StreamSimilarity:output_type -> textembedding.StreamSimilarityResponse
+	31, // 51: textembedding.TextEmbeddingsService.EmbedStream:output_type -> textembedding.EmbedStreamResponse
+	34, // 52: textembedding.TextEmbeddingsService.Validate:output_type -> textembedding.ValidateResponse
+	37, // 53: textembedding.TextEmbeddingsService.CountTokens:output_type -> textembedding.CountTokensResponse
+	43, // [43:54] is the sub-list for method output_type
+	32, // [32:43] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
 }
 
 func init() { file_v1_service_proto_init() }
@@ -959,17 +2836,33 @@ func file_v1_service_proto_init() {
 		return
 	}
 	file_v1_service_proto_msgTypes[0].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[1].OneofWrappers = []any{}
 	file_v1_service_proto_msgTypes[3].OneofWrappers = []any{}
-	file_v1_service_proto_msgTypes[6].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[4].OneofWrappers = []any{
+		(*ImageInput_Url)(nil),
+		(*ImageInput_Data)(nil),
+	}
+	file_v1_service_proto_msgTypes[5].OneofWrappers = []any{}
 	file_v1_service_proto_msgTypes[10].OneofWrappers = []any{}
-	file_v1_service_proto_msgTypes[11].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[13].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[17].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[19].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[20].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[23].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[26].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[29].OneofWrappers = []any{
+		(*ValidateRequest_Embed)(nil),
+		(*ValidateRequest_Similarity)(nil),
+	}
+	file_v1_service_proto_msgTypes[32].OneofWrappers = []any{}
+	file_v1_service_proto_msgTypes[34].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_v1_service_proto_rawDesc), len(file_v1_service_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   13,
+			NumEnums:      4,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -20,9 +20,16 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	TextEmbeddingsService_Embed_FullMethodName               = "/textembedding.TextEmbeddingsService/Embed"
+	TextEmbeddingsService_EmbedTokens_FullMethodName         = "/textembedding.TextEmbeddingsService/EmbedTokens"
+	TextEmbeddingsService_EmbedImage_FullMethodName          = "/textembedding.TextEmbeddingsService/EmbedImage"
 	TextEmbeddingsService_EmbedAll_FullMethodName            = "/textembedding.TextEmbeddingsService/EmbedAll"
 	TextEmbeddingsService_EmbedSparse_FullMethodName         = "/textembedding.TextEmbeddingsService/EmbedSparse"
+	TextEmbeddingsService_EmbedHybrid_FullMethodName         = "/textembedding.TextEmbeddingsService/EmbedHybrid"
 	TextEmbeddingsService_CalculateSimilarity_FullMethodName = "/textembedding.TextEmbeddingsService/CalculateSimilarity"
+	TextEmbeddingsService_StreamSimilarity_FullMethodName    = "/textembedding.TextEmbeddingsService/StreamSimilarity"
+	TextEmbeddingsService_EmbedStream_FullMethodName         = "/textembedding.TextEmbeddingsService/EmbedStream"
+	TextEmbeddingsService_Validate_FullMethodName            = "/textembedding.TextEmbeddingsService/Validate"
+	TextEmbeddingsService_CountTokens_FullMethodName         = "/textembedding.TextEmbeddingsService/CountTokens"
 )
 
 // TextEmbeddingsServiceClient is the client API for TextEmbeddingsService service.
@@ -30,9 +37,16 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TextEmbeddingsServiceClient interface {
 	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	EmbedTokens(ctx context.Context, in *EmbedTokensRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	EmbedImage(ctx context.Context, in *EmbedImageRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
 	EmbedAll(ctx context.Context, in *EmbedAllRequest, opts ...grpc.CallOption) (*EmbedAllResponse, error)
 	EmbedSparse(ctx context.Context, in *EmbedSparseRequest, opts ...grpc.CallOption) (*EmbedSparseResponse, error)
+	EmbedHybrid(ctx context.Context, in *EmbedHybridRequest, opts ...grpc.CallOption) (*EmbedHybridResponse, error)
 	CalculateSimilarity(ctx context.Context, in *SimilarityRequest, opts ...grpc.CallOption) (*SimilarityResponse, error)
+	StreamSimilarity(ctx context.Context, in *StreamSimilarityRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamSimilarityResponse], error)
+	EmbedStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[EmbedStreamRequest, EmbedStreamResponse], error)
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error)
+	CountTokens(ctx context.Context, in *CountTokensRequest, opts ...grpc.CallOption) (*CountTokensResponse, error)
 }
 
 type textEmbeddingsServiceClient struct {
@@ -53,6 +67,26 @@ func (c *textEmbeddingsServiceClient) Embed(ctx context.Context, in *EmbedReques
 	return out, nil
 }
 
+func (c *textEmbeddingsServiceClient) EmbedTokens(ctx context.Context, in *EmbedTokensRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbedResponse)
+	err := c.cc.Invoke(ctx, TextEmbeddingsService_EmbedTokens_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *textEmbeddingsServiceClient) EmbedImage(ctx context.Context, in *EmbedImageRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbedResponse)
+	err := c.cc.Invoke(ctx, TextEmbeddingsService_EmbedImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *textEmbeddingsServiceClient) EmbedAll(ctx context.Context, in *EmbedAllRequest, opts ...grpc.CallOption) (*EmbedAllResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(EmbedAllResponse)
@@ -73,6 +107,16 @@ func (c *textEmbeddingsServiceClient) EmbedSparse(ctx context.Context, in *Embed
 	return out, nil
 }
 
+func (c *textEmbeddingsServiceClient) EmbedHybrid(ctx context.Context, in *EmbedHybridRequest, opts ...grpc.CallOption) (*EmbedHybridResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbedHybridResponse)
+	err := c.cc.Invoke(ctx, TextEmbeddingsService_EmbedHybrid_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *textEmbeddingsServiceClient) CalculateSimilarity(ctx context.Context, in *SimilarityRequest, opts ...grpc.CallOption) (*SimilarityResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SimilarityResponse)
@@ -83,14 +127,73 @@ func (c *textEmbeddingsServiceClient) CalculateSimilarity(ctx context.Context, i
 	return out, nil
 }
 
+func (c *textEmbeddingsServiceClient) StreamSimilarity(ctx context.Context, in *StreamSimilarityRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamSimilarityResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TextEmbeddingsService_ServiceDesc.Streams[0], TextEmbeddingsService_StreamSimilarity_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamSimilarityRequest, StreamSimilarityResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TextEmbeddingsService_StreamSimilarityClient = grpc.ServerStreamingClient[StreamSimilarityResponse]
+
+func (c *textEmbeddingsServiceClient) EmbedStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[EmbedStreamRequest, EmbedStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TextEmbeddingsService_ServiceDesc.Streams[1], TextEmbeddingsService_EmbedStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EmbedStreamRequest, EmbedStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TextEmbeddingsService_EmbedStreamClient = grpc.BidiStreamingClient[EmbedStreamRequest, EmbedStreamResponse]
+
+func (c *textEmbeddingsServiceClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateResponse)
+	err := c.cc.Invoke(ctx, TextEmbeddingsService_Validate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *textEmbeddingsServiceClient) CountTokens(ctx context.Context, in *CountTokensRequest, opts ...grpc.CallOption) (*CountTokensResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountTokensResponse)
+	err := c.cc.Invoke(ctx, TextEmbeddingsService_CountTokens_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TextEmbeddingsServiceServer is the server API for TextEmbeddingsService service.
 // All implementations must embed UnimplementedTextEmbeddingsServiceServer
 // for forward compatibility.
 type TextEmbeddingsServiceServer interface {
 	Embed(context.Context, *EmbedRequest) (*EmbedResponse, error)
+	EmbedTokens(context.Context, *EmbedTokensRequest) (*EmbedResponse, error)
+	EmbedImage(context.Context, *EmbedImageRequest) (*EmbedResponse, error)
 	EmbedAll(context.Context, *EmbedAllRequest) (*EmbedAllResponse, error)
 	EmbedSparse(context.Context, *EmbedSparseRequest) (*EmbedSparseResponse, error)
+	EmbedHybrid(context.Context, *EmbedHybridRequest) (*EmbedHybridResponse, error)
 	CalculateSimilarity(context.Context, *SimilarityRequest) (*SimilarityResponse, error)
+	StreamSimilarity(*StreamSimilarityRequest, grpc.ServerStreamingServer[StreamSimilarityResponse]) error
+	EmbedStream(grpc.BidiStreamingServer[EmbedStreamRequest, EmbedStreamResponse]) error
+	Validate(context.Context, *ValidateRequest) (*ValidateResponse, error)
+	CountTokens(context.Context, *CountTokensRequest) (*CountTokensResponse, error)
 	mustEmbedUnimplementedTextEmbeddingsServiceServer()
 }
 
@@ -104,15 +207,36 @@ type UnimplementedTextEmbeddingsServiceServer struct{}
 func (UnimplementedTextEmbeddingsServiceServer) Embed(context.Context, *EmbedRequest) (*EmbedResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Embed not implemented")
 }
+func (UnimplementedTextEmbeddingsServiceServer) EmbedTokens(context.Context, *EmbedTokensRequest) (*EmbedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EmbedTokens not implemented")
+}
+func (UnimplementedTextEmbeddingsServiceServer) EmbedImage(context.Context, *EmbedImageRequest) (*EmbedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EmbedImage not implemented")
+}
 func (UnimplementedTextEmbeddingsServiceServer) EmbedAll(context.Context, *EmbedAllRequest) (*EmbedAllResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method EmbedAll not implemented")
 }
 func (UnimplementedTextEmbeddingsServiceServer) EmbedSparse(context.Context, *EmbedSparseRequest) (*EmbedSparseResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method EmbedSparse not implemented")
 }
+func (UnimplementedTextEmbeddingsServiceServer) EmbedHybrid(context.Context, *EmbedHybridRequest) (*EmbedHybridResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EmbedHybrid not implemented")
+}
 func (UnimplementedTextEmbeddingsServiceServer) CalculateSimilarity(context.Context, *SimilarityRequest) (*SimilarityResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CalculateSimilarity not implemented")
 }
+func (UnimplementedTextEmbeddingsServiceServer) StreamSimilarity(*StreamSimilarityRequest, grpc.ServerStreamingServer[StreamSimilarityResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSimilarity not implemented")
+}
+func (UnimplementedTextEmbeddingsServiceServer) EmbedStream(grpc.BidiStreamingServer[EmbedStreamRequest, EmbedStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method EmbedStream not implemented")
+}
+func (UnimplementedTextEmbeddingsServiceServer) Validate(context.Context, *ValidateRequest) (*ValidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedTextEmbeddingsServiceServer) CountTokens(context.Context, *CountTokensRequest) (*CountTokensResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountTokens not implemented")
+}
 func (UnimplementedTextEmbeddingsServiceServer) mustEmbedUnimplementedTextEmbeddingsServiceServer() {}
 func (UnimplementedTextEmbeddingsServiceServer) testEmbeddedByValue()                               {}
 
@@ -152,6 +276,42 @@ func _TextEmbeddingsService_Embed_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TextEmbeddingsService_EmbedTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextEmbeddingsServiceServer).EmbedTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TextEmbeddingsService_EmbedTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextEmbeddingsServiceServer).EmbedTokens(ctx, req.(*EmbedTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TextEmbeddingsService_EmbedImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextEmbeddingsServiceServer).EmbedImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TextEmbeddingsService_EmbedImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextEmbeddingsServiceServer).EmbedImage(ctx, req.(*EmbedImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TextEmbeddingsService_EmbedAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(EmbedAllRequest)
 	if err := dec(in); err != nil {
@@ -188,6 +348,24 @@ func _TextEmbeddingsService_EmbedSparse_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TextEmbeddingsService_EmbedHybrid_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedHybridRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextEmbeddingsServiceServer).EmbedHybrid(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TextEmbeddingsService_EmbedHybrid_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextEmbeddingsServiceServer).EmbedHybrid(ctx, req.(*EmbedHybridRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TextEmbeddingsService_CalculateSimilarity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SimilarityRequest)
 	if err := dec(in); err != nil {
@@ -206,6 +384,60 @@ func _TextEmbeddingsService_CalculateSimilarity_Handler(srv interface{}, ctx con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TextEmbeddingsService_StreamSimilarity_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamSimilarityRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TextEmbeddingsServiceServer).StreamSimilarity(m, &grpc.GenericServerStream[StreamSimilarityRequest, StreamSimilarityResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TextEmbeddingsService_StreamSimilarityServer = grpc.ServerStreamingServer[StreamSimilarityResponse]
+
+func _TextEmbeddingsService_EmbedStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TextEmbeddingsServiceServer).EmbedStream(&grpc.GenericServerStream[EmbedStreamRequest, EmbedStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TextEmbeddingsService_EmbedStreamServer = grpc.BidiStreamingServer[EmbedStreamRequest, EmbedStreamResponse]
+
+func _TextEmbeddingsService_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextEmbeddingsServiceServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TextEmbeddingsService_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextEmbeddingsServiceServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TextEmbeddingsService_CountTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextEmbeddingsServiceServer).CountTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TextEmbeddingsService_CountTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextEmbeddingsServiceServer).CountTokens(ctx, req.(*CountTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TextEmbeddingsService_ServiceDesc is the grpc.ServiceDesc for TextEmbeddingsService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -217,6 +449,14 @@ var TextEmbeddingsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Embed",
 			Handler:    _TextEmbeddingsService_Embed_Handler,
 		},
+		{
+			MethodName: "EmbedTokens",
+			Handler:    _TextEmbeddingsService_EmbedTokens_Handler,
+		},
+		{
+			MethodName: "EmbedImage",
+			Handler:    _TextEmbeddingsService_EmbedImage_Handler,
+		},
 		{
 			MethodName: "EmbedAll",
 			Handler:    _TextEmbeddingsService_EmbedAll_Handler,
@@ -225,11 +465,35 @@ var TextEmbeddingsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "EmbedSparse",
 			Handler:    _TextEmbeddingsService_EmbedSparse_Handler,
 		},
+		{
+			MethodName: "EmbedHybrid",
+			Handler:    _TextEmbeddingsService_EmbedHybrid_Handler,
+		},
 		{
 			MethodName: "CalculateSimilarity",
 			Handler:    _TextEmbeddingsService_CalculateSimilarity_Handler,
 		},
+		{
+			MethodName: "Validate",
+			Handler:    _TextEmbeddingsService_Validate_Handler,
+		},
+		{
+			MethodName: "CountTokens",
+			Handler:    _TextEmbeddingsService_CountTokens_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSimilarity",
+			Handler:       _TextEmbeddingsService_StreamSimilarity_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "EmbedStream",
+			Handler:       _TextEmbeddingsService_EmbedStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "v1/service.proto",
 }